@@ -6,13 +6,56 @@ import (
 	"net/http"
 	db "simple_bank/db/models"
 	"simple_bank/token"
+	"simple_bank/util"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 )
 
+// accountResponse carries the balance and the overdraft limit both in minor
+// units and formatted in the major unit of the account currency.
+type accountResponse struct {
+	ID                int64                `json:"id"`
+	Owner             string               `json:"owner"`
+	Balance           util.FormattedMoney  `json:"balance"`
+	Currency          string               `json:"currency"`
+	OverdraftLimit    util.FormattedMoney  `json:"overdraft_limit"`
+	Product           string               `json:"product"`
+	ApprovalThreshold *util.FormattedMoney `json:"approval_threshold,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	rsp := accountResponse{
+		ID:             account.ID,
+		Owner:          account.Owner,
+		Balance:        util.NewMoney(account.Balance, account.Currency).Formatted(),
+		Currency:       account.Currency,
+		OverdraftLimit: util.NewMoney(account.OverdraftLimit, account.Currency).Formatted(),
+		Product:        account.Product,
+		CreatedAt:      account.CreatedAt,
+	}
+	if account.ApprovalThreshold != nil {
+		threshold := util.NewMoney(*account.ApprovalThreshold, account.Currency).Formatted()
+		rsp.ApprovalThreshold = &threshold
+	}
+	return rsp
+}
+
+func newAccountResponses(accounts []db.Account) []accountResponse {
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountResponse(account)
+	}
+	return rsp
+}
+
 type createAccountRequest struct {
-	Currency string `json:"currency" binding:"required,currency"`
+	Currency string `json:"currency" binding:"required,enabled_currency"`
+	// Product defaults to checking. Unknown products are rejected by the
+	// foreign key on accounts.product.
+	Product string `json:"product" binding:"omitempty,alphanum"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
@@ -27,6 +70,10 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
+		Product:  req.Product,
+	}
+	if arg.Product == "" {
+		arg.Product = db.ProductChecking
 	}
 
 	account, err := server.store.CreateAccount(ctx, arg)
@@ -42,7 +89,7 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type getAccountRequest struct {
@@ -68,18 +115,32 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
+	if !canReadAccount(authPayload, account) {
 		err := errors.New("account doesn't belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	Owner    string `form:"owner" binding:"omitempty,alphanum"`
+	PageID   *int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+// listAccountResponse is returned when paging by cursor. Requests with a
+// page_id keep receiving a plain array.
+type listAccountResponse struct {
+	Accounts   []accountResponse `json:"accounts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type accountCursor struct {
+	Owner   string `json:"owner"`
+	AfterID int64  `json:"after_id"`
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
@@ -89,11 +150,33 @@ func (server *Server) listAccount(ctx *gin.Context) {
 		return
 	}
 
+	if req.PageID != nil && req.Cursor != "" {
+		err := errors.New("page_id and cursor cannot be used together")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	owner := authPayload.Username
+	if req.Owner != "" {
+		owner = req.Owner
+	}
+
+	if !canListAccounts(authPayload, owner) {
+		err := errors.New("not allowed to list accounts of another user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if req.PageID == nil {
+		server.listAccountAfter(ctx, owner, req)
+		return
+	}
+
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
+		Owner:  owner,
 		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		Offset: (*req.PageID - 1) * req.PageSize,
 	}
 
 	accounts, err := server.store.ListAccounts(ctx, arg)
@@ -102,5 +185,41 @@ func (server *Server) listAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, newAccountResponses(accounts))
+}
+
+func (server *Server) listAccountAfter(ctx *gin.Context, owner string, req listAccountRequest) {
+	position := accountCursor{Owner: owner}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || position.Owner != owner {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	accounts, err := server.store.ListAccountsAfter(ctx, db.ListAccountsAfterParams{
+		Owner:   owner,
+		AfterID: position.AfterID,
+		Limit:   req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listAccountResponse{Accounts: newAccountResponses(accounts)}
+	if len(accounts) > int(req.PageSize) {
+		rsp.Accounts = rsp.Accounts[:req.PageSize]
+		position.AfterID = rsp.Accounts[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
 }
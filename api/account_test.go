@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -35,7 +36,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "OK",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -51,7 +52,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "UnauthorizedUser",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -62,6 +63,22 @@ func TestGetAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name:      "BankerReadsOtherAccount",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
 		{
 			name:      "NoAuthorization",
 			accountID: account.ID,
@@ -79,7 +96,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "NotFound",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -94,7 +111,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "InternalError",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -109,7 +126,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "InvalidID",
 			accountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -157,13 +174,14 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				arg := db.CreateAccountParams{
 					Owner:    account.Owner,
 					Currency: account.Currency,
 					Balance:  0,
+					Product:  db.ProductChecking,
 				}
 
 				storeMock.
@@ -175,6 +193,55 @@ func TestCreateAccountAPI(t *testing.T) {
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name: "SavingsProduct",
+			requestBody: gin.H{
+				"currency": account.Currency,
+				"product":  db.ProductSavings,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				arg := db.CreateAccountParams{
+					Owner:    account.Owner,
+					Currency: account.Currency,
+					Balance:  0,
+					Product:  db.ProductSavings,
+				}
+
+				savings := account
+				savings.Product = db.ProductSavings
+				storeMock.
+					On("CreateAccount", mock.Anything, arg).
+					Return(savings, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp accountResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.ProductSavings, rsp.Product)
+			},
+		},
+		{
+			name: "UnknownProduct",
+			requestBody: gin.H{
+				"currency": account.Currency,
+				"product":  "platinum",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateAccount", mock.Anything, mock.Anything).
+					Return(db.Account{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
 			requestBody: gin.H{
@@ -198,7 +265,7 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -209,6 +276,20 @@ func TestCreateAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "DisabledCurrency",
+			requestBody: gin.H{
+				"owner":    account.Owner,
+				"currency": util.JPY,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidCurrency",
 			requestBody: gin.H{
@@ -216,7 +297,7 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": "Invalid Currency",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -259,6 +340,7 @@ func TestListAccountAPI(t *testing.T) {
 	}
 
 	type Query struct {
+		owner    string
 		pageID   int
 		pageSize int
 	}
@@ -277,7 +359,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(mockStore *mocks.Store) {
 				arg := db.ListAccountsParams{
@@ -294,6 +376,46 @@ func TestListAccountAPI(t *testing.T) {
 				requireBodyMatchAccounts(t, recorder.Body, accounts)
 			},
 		},
+		{
+			name: "BankerListsOtherOwner",
+			query: Query{
+				owner:    user.Username,
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				arg := db.ListAccountsParams{
+					Owner:  user.Username,
+					Limit:  int32(n),
+					Offset: 0,
+				}
+				mockStore.
+					On("ListAccounts", mock.Anything, arg).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts)
+			},
+		},
+		{
+			name: "DepositorListsOtherOwner",
+			query: Query{
+				owner:    user.Username,
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
 			query: Query{
@@ -317,7 +439,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(mockStore *mocks.Store) {
 				mockStore.
@@ -335,7 +457,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(mockStore *mocks.Store) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -349,7 +471,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: 100,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(mockStore *mocks.Store) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -372,6 +494,9 @@ func TestListAccountAPI(t *testing.T) {
 			require.NoError(t, err)
 
 			q := request.URL.Query()
+			if tc.query.owner != "" {
+				q.Add("owner", tc.query.owner)
+			}
 			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
 			request.URL.RawQuery = q.Encode()
@@ -389,6 +514,7 @@ func randomAccount(owner string) db.Account {
 		Owner:    owner,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
+		Product:  db.ProductChecking,
 	}
 }
 
@@ -396,18 +522,195 @@ func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Accoun
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotAccount db.Account
+	var gotAccount accountResponse
 	err = json.Unmarshal(data, &gotAccount)
 	require.NoError(t, err)
-	require.Equal(t, account, gotAccount)
+	require.Equal(t, newAccountResponse(account), gotAccount)
 }
 
 func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotAccounts []db.Account
+	var gotAccounts []accountResponse
 	err = json.Unmarshal(data, &gotAccounts)
 	require.NoError(t, err)
-	require.Equal(t, accounts, gotAccounts)
+	require.Equal(t, newAccountResponses(accounts), gotAccounts)
+}
+
+func TestListAccountCursorAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	pageSize := 5
+	accounts := make([]db.Account, pageSize+1)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
+		accounts[i].ID = int64(i + 1)
+	}
+
+	testCases := []struct {
+		name          string
+		cursor        func(t *testing.T, codec *cursorCodec) string
+		pageID        string
+		buildStubs    func(mockStore *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec)
+	}{
+		{
+			name: "FirstPage",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				return ""
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				arg := db.ListAccountsAfterParams{
+					Owner:   user.Username,
+					AfterID: 0,
+					Limit:   int32(pageSize + 1),
+				}
+				mockStore.
+					On("ListAccountsAfter", mock.Anything, arg).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp listAccountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newAccountResponses(accounts[:pageSize]), rsp.Accounts)
+
+				var position accountCursor
+				err = codec.decode(rsp.NextCursor, &position)
+				require.NoError(t, err)
+				require.Equal(t, accountCursor{Owner: user.Username, AfterID: accounts[pageSize-1].ID}, position)
+			},
+		},
+		{
+			name: "LastPage",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				cursor, err := codec.encode(accountCursor{Owner: user.Username, AfterID: accounts[pageSize-1].ID})
+				require.NoError(t, err)
+				return cursor
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				arg := db.ListAccountsAfterParams{
+					Owner:   user.Username,
+					AfterID: accounts[pageSize-1].ID,
+					Limit:   int32(pageSize + 1),
+				}
+				mockStore.
+					On("ListAccountsAfter", mock.Anything, arg).
+					Return(accounts[pageSize:], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp listAccountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newAccountResponses(accounts[pageSize:]), rsp.Accounts)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name: "InvalidCursor",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				return util.RandomString(20) + "." + util.RandomString(20)
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CursorOfOtherOwner",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				cursor, err := codec.encode(accountCursor{Owner: util.RandomOwner(), AfterID: 1})
+				require.NoError(t, err)
+				return cursor
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CursorWithPageID",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				cursor, err := codec.encode(accountCursor{Owner: user.Username, AfterID: 1})
+				require.NoError(t, err)
+				return cursor
+			},
+			pageID:     "1",
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				return ""
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("ListAccountsAfter", mock.Anything, mock.Anything).
+					Return([]db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := mocks.NewStore(t)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := "/accounts"
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			if cursor := tc.cursor(t, server.cursors); cursor != "" {
+				q.Add("cursor", cursor)
+			}
+			if tc.pageID != "" {
+				q.Add("page_id", tc.pageID)
+			}
+			q.Add("page_size", fmt.Sprintf("%d", pageSize))
+			request.URL.RawQuery = q.Encode()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.cursors)
+		})
+	}
+}
+
+func TestAccountResponseFormatsAmounts(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+	account.Currency = util.USD
+	account.Balance = -1234
+	account.OverdraftLimit = 5000
+
+	data, err := json.Marshal(newAccountResponse(account))
+	require.NoError(t, err)
+
+	var rsp map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &rsp))
+	require.Equal(t, map[string]interface{}{
+		"amount":    float64(-1234),
+		"currency":  util.USD,
+		"formatted": "-12.34",
+	}, rsp["balance"])
+	require.Equal(t, map[string]interface{}{
+		"amount":    float64(5000),
+		"currency":  util.USD,
+		"formatted": "50.00",
+	}, rsp["overdraft_limit"])
 }
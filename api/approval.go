@@ -0,0 +1,443 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var errApproverNotFound = errors.New("approver not found")
+
+// approvalPolicyResponse tells which transfers of an account need a second
+// person's approval, and who may give it.
+type approvalPolicyResponse struct {
+	AccountID         int64                `json:"account_id"`
+	ApprovalThreshold *util.FormattedMoney `json:"approval_threshold,omitempty"`
+	Approvers         []string             `json:"approvers"`
+}
+
+func newApprovalPolicyResponse(account db.Account, approvers []db.AccountApprover) approvalPolicyResponse {
+	rsp := approvalPolicyResponse{
+		AccountID: account.ID,
+		Approvers: make([]string, len(approvers)),
+	}
+	if account.ApprovalThreshold != nil {
+		threshold := util.NewMoney(*account.ApprovalThreshold, account.Currency).Formatted()
+		rsp.ApprovalThreshold = &threshold
+	}
+	for i, approver := range approvers {
+		rsp.Approvers[i] = approver.Username
+	}
+	return rsp
+}
+
+type setApprovalPolicyRequest struct {
+	// ApprovalThreshold is the largest amount transferred without approval,
+	// in the currency of the account. Leaving it out turns approvals off.
+	ApprovalThreshold json.RawMessage `json:"approval_threshold"`
+	Approvers         []string        `json:"approvers" binding:"max=10,dive,required,alphanum"`
+}
+
+// setApprovalPolicy replaces the approval threshold and the approvers of an
+// account. Only the owner of the account may change them.
+func (server *Server) setApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setApprovalPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.SetApprovalPolicyTxParams{
+		AccountID: account.ID,
+		Approvers: req.Approvers,
+	}
+	if len(req.ApprovalThreshold) > 0 && string(req.ApprovalThreshold) != "null" {
+		threshold, valid := requestAmount(ctx, req.ApprovalThreshold, account.Currency)
+		if !valid {
+			return
+		}
+		arg.ApprovalThreshold = &threshold.Amount
+	}
+
+	if err := checkApprovers(account, arg); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the owner alone may only tighten a policy; anything else waits for one
+	// of the current approvers
+	if account.ApprovalThreshold != nil {
+		approvers, err := server.store.ListAccountApprovers(ctx, account.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if loosensApprovalPolicy(account, approvers, arg) {
+			change, err := server.store.CreateApprovalPolicyChange(ctx, db.CreateApprovalPolicyChangeParams{
+				AccountID:         account.ID,
+				RequestedBy:       authPayload.Username,
+				ApprovalThreshold: arg.ApprovalThreshold,
+				Approvers:         arg.Approvers,
+				Status:            db.ApprovalPolicyChangePendingApproval,
+			})
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			ctx.JSON(http.StatusAccepted, newApprovalPolicyChangeResponse(account, change))
+			return
+		}
+	}
+
+	arg.RequestedBy = authPayload.Username
+	result, err := server.store.SetApprovalPolicyTx(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusNotFound, errorResponse(errApproverNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newApprovalPolicyResponse(result.Account, result.Approvers))
+}
+
+// checkApprovers makes sure that transfers held for approval can be approved
+// by someone other than the owner who requests them.
+func checkApprovers(account db.Account, arg db.SetApprovalPolicyTxParams) error {
+	if arg.ApprovalThreshold != nil && len(arg.Approvers) == 0 {
+		return errors.New("an approval threshold needs at least one approver")
+	}
+
+	seen := make(map[string]bool, len(arg.Approvers))
+	for _, username := range arg.Approvers {
+		if username == account.Owner {
+			return errors.New("the account owner cannot approve their own transfers")
+		}
+		if seen[username] {
+			return fmt.Errorf("approver %s is listed twice", username)
+		}
+		seen[username] = true
+	}
+	return nil
+}
+
+// loosensApprovalPolicy reports whether arg removes or raises the approval
+// threshold of account, or changes who approves its transfers.
+func loosensApprovalPolicy(account db.Account, approvers []db.AccountApprover, arg db.SetApprovalPolicyTxParams) bool {
+	if account.ApprovalThreshold == nil {
+		return false
+	}
+	if arg.ApprovalThreshold == nil || *arg.ApprovalThreshold > *account.ApprovalThreshold {
+		return true
+	}
+
+	if len(arg.Approvers) != len(approvers) {
+		return true
+	}
+	current := make(map[string]bool, len(approvers))
+	for _, approver := range approvers {
+		current[approver.Username] = true
+	}
+	for _, username := range arg.Approvers {
+		if !current[username] {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *Server) getApprovalPolicy(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canReadAccount(authPayload, account) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	approvers, err := server.store.ListAccountApprovers(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newApprovalPolicyResponse(account, approvers))
+}
+
+// approvalPolicyChangeResponse is a change of the approval policy of an
+// account, applied or waiting for an approver.
+type approvalPolicyChangeResponse struct {
+	ID                int64                `json:"id"`
+	AccountID         int64                `json:"account_id"`
+	RequestedBy       string               `json:"requested_by"`
+	ApprovalThreshold *util.FormattedMoney `json:"approval_threshold,omitempty"`
+	Approvers         []string             `json:"approvers"`
+	Status            string               `json:"status"`
+	DecidedBy         *string              `json:"decided_by"`
+	DecidedAt         *time.Time           `json:"decided_at"`
+	CreatedAt         time.Time            `json:"created_at"`
+}
+
+func newApprovalPolicyChangeResponse(account db.Account, change db.ApprovalPolicyChange) approvalPolicyChangeResponse {
+	rsp := approvalPolicyChangeResponse{
+		ID:          change.ID,
+		AccountID:   change.AccountID,
+		RequestedBy: change.RequestedBy,
+		Approvers:   change.Approvers,
+		Status:      change.Status,
+		DecidedBy:   change.DecidedBy,
+		DecidedAt:   change.DecidedAt,
+		CreatedAt:   change.CreatedAt,
+	}
+	if rsp.Approvers == nil {
+		rsp.Approvers = []string{}
+	}
+	if change.ApprovalThreshold != nil {
+		threshold := util.NewMoney(*change.ApprovalThreshold, account.Currency).Formatted()
+		rsp.ApprovalThreshold = &threshold
+	}
+	return rsp
+}
+
+type listApprovalPolicyChangesRequest struct {
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listApprovalPolicyChangesResponse struct {
+	Changes    []approvalPolicyChangeResponse `json:"changes"`
+	NextCursor string                         `json:"next_cursor,omitempty"`
+}
+
+type approvalPolicyChangeCursor struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+}
+
+// listApprovalPolicyChanges pages through the policy changes of an account,
+// oldest first. The owner and the approvers of the account may list them.
+func (server *Server) listApprovalPolicyChanges(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listApprovalPolicyChangesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canReadAccount(authPayload, account) {
+		if !server.checkAccountApprover(ctx, account.ID, authPayload) {
+			return
+		}
+	}
+
+	position := approvalPolicyChangeCursor{AccountID: account.ID}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || position.AccountID != account.ID {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	changes, err := server.store.ListApprovalPolicyChangesAfter(ctx, db.ListApprovalPolicyChangesAfterParams{
+		AccountID: account.ID,
+		AfterID:   position.AfterID,
+		Limit:     req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listApprovalPolicyChangesResponse{Changes: make([]approvalPolicyChangeResponse, 0, len(changes))}
+	for _, change := range changes {
+		rsp.Changes = append(rsp.Changes, newApprovalPolicyChangeResponse(account, change))
+	}
+	if len(changes) > int(req.PageSize) {
+		rsp.Changes = rsp.Changes[:req.PageSize]
+		position.AfterID = rsp.Changes[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type approveApprovalPolicyChangeResponse struct {
+	Change approvalPolicyChangeResponse `json:"change"`
+	Policy approvalPolicyResponse       `json:"policy"`
+}
+
+// approveApprovalPolicyChange applies a policy change that loosens the policy
+// of an account. Only a current approver of the account may approve it.
+func (server *Server) approveApprovalPolicyChange(ctx *gin.Context) {
+	change, _, valid := server.decidableApprovalPolicyChange(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.ApproveApprovalPolicyChangeTx(ctx, db.ApproveApprovalPolicyChangeTxParams{
+		ID:         change.ID,
+		ApprovedBy: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrApprovalPolicyChangeDecided) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusNotFound, errorResponse(errApproverNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, approveApprovalPolicyChangeResponse{
+		Change: newApprovalPolicyChangeResponse(result.Account, result.Change),
+		Policy: newApprovalPolicyResponse(result.Account, result.Approvers),
+	})
+}
+
+// rejectApprovalPolicyChange drops a policy change, leaving the policy as it
+// is.
+func (server *Server) rejectApprovalPolicyChange(ctx *gin.Context) {
+	change, account, valid := server.decidableApprovalPolicyChange(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	rejected, err := server.store.DecideApprovalPolicyChange(ctx, db.DecideApprovalPolicyChangeParams{
+		ID:        change.ID,
+		Status:    db.ApprovalPolicyChangeRejected,
+		DecidedBy: authPayload.Username,
+	})
+	if err != nil {
+		// another approver got there first
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(db.ErrApprovalPolicyChangeDecided))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newApprovalPolicyChangeResponse(account, rejected))
+}
+
+type getApprovalPolicyChangeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// decidableApprovalPolicyChange returns the policy change of the request and
+// its account when the authenticated user may approve or reject it, and it is
+// still waiting for a decision.
+func (server *Server) decidableApprovalPolicyChange(ctx *gin.Context) (db.ApprovalPolicyChange, db.Account, bool) {
+	var req getApprovalPolicyChangeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ApprovalPolicyChange{}, db.Account{}, false
+	}
+
+	change, err := server.store.GetApprovalPolicyChange(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return change, db.Account{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return change, db.Account{}, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.checkAccountApprover(ctx, change.AccountID, authPayload) {
+		return change, db.Account{}, false
+	}
+
+	if change.Status != db.ApprovalPolicyChangePendingApproval {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrApprovalPolicyChangeDecided))
+		return change, db.Account{}, false
+	}
+
+	account, err := server.store.GetAccount(ctx, change.AccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return change, account, false
+	}
+
+	return change, account, true
+}
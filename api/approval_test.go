@@ -0,0 +1,580 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetApprovalPolicyAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+
+	account := randomAccount(owner.Username)
+	account.Currency = util.USD
+
+	threshold := int64(100000)
+	updated := account
+	updated.ApprovalThreshold = &threshold
+
+	testCases := []struct {
+		name          string
+		username      string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			requestBody: gin.H{
+				"approval_threshold": "1000.00",
+				"approvers":          []string{approver.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("SetApprovalPolicyTx", mock.Anything, db.SetApprovalPolicyTxParams{
+						AccountID:         account.ID,
+						ApprovalThreshold: &threshold,
+						Approvers:         []string{approver.Username},
+						RequestedBy:       owner.Username,
+					}).
+					Return(db.SetApprovalPolicyTxResult{
+						Account:   updated,
+						Approvers: []db.AccountApprover{{AccountID: account.ID, Username: approver.Username}},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp approvalPolicyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Equal(t, util.NewMoney(threshold, util.USD), util.Money(*rsp.ApprovalThreshold))
+				require.Equal(t, []string{approver.Username}, rsp.Approvers)
+			},
+		},
+		{
+			name:        "Disable",
+			username:    owner.Username,
+			requestBody: gin.H{"approval_threshold": nil},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("SetApprovalPolicyTx", mock.Anything, db.SetApprovalPolicyTxParams{AccountID: account.ID, RequestedBy: owner.Username}).
+					Return(db.SetApprovalPolicyTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp approvalPolicyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Nil(t, rsp.ApprovalThreshold)
+				require.Empty(t, rsp.Approvers)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: approver.Username,
+			requestBody: gin.H{
+				"approval_threshold": "1000.00",
+				"approvers":          []string{approver.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:        "ThresholdWithoutApprovers",
+			username:    owner.Username,
+			requestBody: gin.H{"approval_threshold": "1000.00"},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "OwnerAsApprover",
+			username: owner.Username,
+			requestBody: gin.H{
+				"approval_threshold": "1000.00",
+				"approvers":          []string{owner.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "DuplicateApprover",
+			username: owner.Username,
+			requestBody: gin.H{
+				"approval_threshold": "1000.00",
+				"approvers":          []string{approver.Username, approver.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidThreshold",
+			username: owner.Username,
+			requestBody: gin.H{
+				"approval_threshold": "0",
+				"approvers":          []string{approver.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "ApproverNotFound",
+			username: owner.Username,
+			requestBody: gin.H{
+				"approval_threshold": "1000.00",
+				"approvers":          []string{"nobody"},
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("SetApprovalPolicyTx", mock.Anything, mock.Anything).
+					Return(db.SetApprovalPolicyTxResult{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			require.NoError(t, json.NewEncoder(&body).Encode(tc.requestBody))
+
+			url := fmt.Sprintf("/accounts/%d/approval_policy", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSetApprovalPolicyAPILoosening(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	threshold := int64(100000)
+	account := randomAccount(owner.Username)
+	account.Currency = util.USD
+	account.ApprovalThreshold = &threshold
+
+	lower := int64(50000)
+	higher := int64(200000)
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "LowerThreshold",
+			requestBody: gin.H{
+				"approval_threshold": "500.00",
+				"approvers":          []string{approver.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("SetApprovalPolicyTx", mock.Anything, db.SetApprovalPolicyTxParams{
+						AccountID:         account.ID,
+						ApprovalThreshold: &lower,
+						Approvers:         []string{approver.Username},
+						RequestedBy:       owner.Username,
+					}).
+					Return(db.SetApprovalPolicyTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RaiseThreshold",
+			requestBody: gin.H{
+				"approval_threshold": "2000.00",
+				"approvers":          []string{approver.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateApprovalPolicyChange", mock.Anything, db.CreateApprovalPolicyChangeParams{
+						AccountID:         account.ID,
+						RequestedBy:       owner.Username,
+						ApprovalThreshold: &higher,
+						Approvers:         []string{approver.Username},
+						Status:            db.ApprovalPolicyChangePendingApproval,
+					}).
+					Return(db.ApprovalPolicyChange{
+						ID:                1,
+						AccountID:         account.ID,
+						RequestedBy:       owner.Username,
+						ApprovalThreshold: &higher,
+						Approvers:         []string{approver.Username},
+						Status:            db.ApprovalPolicyChangePendingApproval,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var rsp approvalPolicyChangeResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(1), rsp.ID)
+				require.Equal(t, db.ApprovalPolicyChangePendingApproval, rsp.Status)
+				require.Equal(t, util.NewMoney(higher, util.USD), util.Money(*rsp.ApprovalThreshold))
+			},
+		},
+		{
+			name:        "Disable",
+			requestBody: gin.H{"approval_threshold": nil},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateApprovalPolicyChange", mock.Anything, mock.MatchedBy(func(arg db.CreateApprovalPolicyChangeParams) bool {
+						return arg.ApprovalThreshold == nil && arg.Status == db.ApprovalPolicyChangePendingApproval
+					})).
+					Return(db.ApprovalPolicyChange{ID: 2, Status: db.ApprovalPolicyChangePendingApproval}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name: "ReplaceApprovers",
+			requestBody: gin.H{
+				"approval_threshold": "1000.00",
+				"approvers":          []string{other.Username},
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateApprovalPolicyChange", mock.Anything, mock.MatchedBy(func(arg db.CreateApprovalPolicyChangeParams) bool {
+						return *arg.ApprovalThreshold == threshold && arg.Status == db.ApprovalPolicyChangePendingApproval
+					})).
+					Return(db.ApprovalPolicyChange{ID: 3, Status: db.ApprovalPolicyChangePendingApproval}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+			storeMock.
+				On("ListAccountApprovers", mock.Anything, account.ID).
+				Return([]db.AccountApprover{{AccountID: account.ID, Username: approver.Username}}, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			require.NoError(t, json.NewEncoder(&body).Encode(tc.requestBody))
+
+			url := fmt.Sprintf("/accounts/%d/approval_policy", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, owner.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDecideApprovalPolicyChangeAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	stranger, _ := randomUser(t)
+
+	threshold := int64(100000)
+	account := randomAccount(owner.Username)
+	account.ApprovalThreshold = &threshold
+
+	change := db.ApprovalPolicyChange{
+		ID:          util.RandomInt(1, 1000),
+		AccountID:   account.ID,
+		RequestedBy: owner.Username,
+		Approvers:   []string{},
+		Status:      db.ApprovalPolicyChangePendingApproval,
+	}
+	applied := change
+	applied.Status = db.ApprovalPolicyChangeApplied
+	applied.DecidedBy = &approver.Username
+
+	testCases := []struct {
+		name          string
+		action        string
+		username      string
+		change        db.ApprovalPolicyChange
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Approve",
+			action:   "approve",
+			username: approver.Username,
+			change:   change,
+			buildStubs: func(storeMock *mocks.Store) {
+				disabled := account
+				disabled.ApprovalThreshold = nil
+
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+				storeMock.
+					On("ApproveApprovalPolicyChangeTx", mock.Anything, db.ApproveApprovalPolicyChangeTxParams{
+						ID:         change.ID,
+						ApprovedBy: approver.Username,
+					}).
+					Return(db.SetApprovalPolicyTxResult{Account: disabled, Change: applied}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp approveApprovalPolicyChangeResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.ApprovalPolicyChangeApplied, rsp.Change.Status)
+				require.Equal(t, approver.Username, *rsp.Change.DecidedBy)
+				require.Nil(t, rsp.Policy.ApprovalThreshold)
+			},
+		},
+		{
+			name:     "Reject",
+			action:   "reject",
+			username: approver.Username,
+			change:   change,
+			buildStubs: func(storeMock *mocks.Store) {
+				rejected := change
+				rejected.Status = db.ApprovalPolicyChangeRejected
+
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+				storeMock.
+					On("DecideApprovalPolicyChange", mock.Anything, db.DecideApprovalPolicyChangeParams{
+						ID:        change.ID,
+						Status:    db.ApprovalPolicyChangeRejected,
+						DecidedBy: approver.Username,
+					}).
+					Return(rejected, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "OwnerCannotApprove",
+			action:     "approve",
+			username:   owner.Username,
+			change:     change,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "NotApprover",
+			action:     "reject",
+			username:   stranger.Username,
+			change:     change,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "AlreadyDecided",
+			action:     "approve",
+			username:   approver.Username,
+			change:     applied,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "ApprovedConcurrently",
+			action:   "approve",
+			username: approver.Username,
+			change:   change,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+				storeMock.
+					On("ApproveApprovalPolicyChangeTx", mock.Anything, mock.Anything).
+					Return(db.SetApprovalPolicyTxResult{}, db.ErrApprovalPolicyChangeDecided)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetApprovalPolicyChange", mock.Anything, change.ID).Return(tc.change, nil)
+			storeMock.
+				On("GetAccountApprover", mock.Anything, db.GetAccountApproverParams{AccountID: account.ID, Username: approver.Username}).
+				Return(db.AccountApprover{AccountID: account.ID, Username: approver.Username}, nil).
+				Maybe()
+			storeMock.
+				On("GetAccountApprover", mock.Anything, mock.Anything).
+				Return(db.AccountApprover{}, sql.ErrNoRows).
+				Maybe()
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/approval_policy_changes/%d/%s", change.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListApprovalPolicyChangesAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	stranger, _ := randomUser(t)
+
+	account := randomAccount(owner.Username)
+	changes := []db.ApprovalPolicyChange{
+		{ID: 1, AccountID: account.ID, RequestedBy: owner.Username, Status: db.ApprovalPolicyChangeApplied},
+		{ID: 2, AccountID: account.ID, RequestedBy: owner.Username, Status: db.ApprovalPolicyChangePendingApproval},
+	}
+
+	testCases := []struct {
+		name       string
+		username   string
+		buildStubs func(storeMock *mocks.Store)
+		wantStatus int
+	}{
+		{
+			name:     "Owner",
+			username: owner.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ListApprovalPolicyChangesAfter", mock.Anything, db.ListApprovalPolicyChangesAfterParams{
+						AccountID: account.ID,
+						Limit:     6,
+					}).
+					Return(changes, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "Approver",
+			username: approver.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccountApprover", mock.Anything, db.GetAccountApproverParams{AccountID: account.ID, Username: approver.Username}).
+					Return(db.AccountApprover{AccountID: account.ID, Username: approver.Username}, nil)
+				storeMock.On("ListApprovalPolicyChangesAfter", mock.Anything, mock.Anything).Return(changes, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "Stranger",
+			username: stranger.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccountApprover", mock.Anything, mock.Anything).Return(db.AccountApprover{}, sql.ErrNoRows)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/approval_policy_changes?page_size=5", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.wantStatus, recorder.Code)
+
+			if tc.wantStatus == http.StatusOK {
+				var rsp listApprovalPolicyChangesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Changes, 2)
+				require.Empty(t, rsp.NextCursor)
+			}
+		})
+	}
+}
+
+func TestGetApprovalPolicyAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+
+	threshold := int64(5000)
+	account := randomAccount(owner.Username)
+	account.ApprovalThreshold = &threshold
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+	storeMock.
+		On("ListAccountApprovers", mock.Anything, account.ID).
+		Return([]db.AccountApprover{{AccountID: account.ID, Username: approver.Username}}, nil)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/approval_policy", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, owner.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp approvalPolicyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, newApprovalPolicyResponse(account, []db.AccountApprover{{Username: approver.Username}}), rsp)
+}
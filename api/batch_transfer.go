@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/risk"
+	"simple_bank/token"
+	"simple_bank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchTransferRequest struct {
+	// Transfers is bounded, as the batch runs in a single transaction holding
+	// the lock of every account involved.
+	Transfers []batchTransferLegRequest `json:"transfers" binding:"required,min=1,max=1000,dive"`
+}
+
+type batchTransferLegRequest struct {
+	FromAccountID     int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID       int64           `json:"to_account_id" binding:"required,min=1"`
+	Amount            json.RawMessage `json:"amount" binding:"required"`
+	Currency          string          `json:"currency" binding:"required,currency"`
+	Description       string          `json:"description,omitempty" binding:"max=255"`
+	ExternalReference string          `json:"external_reference,omitempty" binding:"max=255"`
+	Metadata          json.RawMessage `json:"metadata,omitempty"`
+}
+
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.BatchTransferTxParams{
+		Transfers: make([]db.TransferTxParams, len(req.Transfers)),
+	}
+
+	// every leg is validated before anything moves, and the error names the
+	// first invalid one
+	accounts := make(map[int64]db.Account)
+	for i, leg := range req.Transfers {
+		amount, err := parseAmount(leg.Amount, leg.Currency)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("transfers[%d]: %w", i, err)))
+			return
+		}
+
+		status, err := server.checkBatchTransferLeg(ctx, authPayload, leg, amount.Amount, accounts)
+		if err != nil {
+			ctx.JSON(status, errorResponse(fmt.Errorf("transfers[%d]: %w", i, err)))
+			return
+		}
+
+		metadata, err := parseMetadata(leg.Metadata)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("transfers[%d]: %w", i, err)))
+			return
+		}
+
+		arg.Transfers[i] = db.TransferTxParams{
+			FromAccountID:     leg.FromAccountID,
+			ToAccountID:       leg.ToAccountID,
+			Amount:            amount.Amount,
+			Description:       leg.Description,
+			ExternalReference: optionalString(leg.ExternalReference),
+			Metadata:          metadata,
+		}
+	}
+
+	var valid bool
+	arg.Idempotency, valid = idempotencyKey(ctx, authPayload.Username, req)
+	if !valid {
+		return
+	}
+
+	if !server.screenBatchTransfer(ctx, authPayload.Username, arg, req, accounts) {
+		return
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, util.ErrAmountOverflow) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBatchTransferResponse(result, authPayload.Username))
+}
+
+// checkBatchTransferLeg applies the checks of createTransfer to one leg of a
+// batch. Accounts are looked up once per batch and cached in accounts. Legs
+// that would need approval are refused, as a batch cannot be held.
+func (server *Server) checkBatchTransferLeg(
+	ctx *gin.Context,
+	authPayload *token.Payload,
+	leg batchTransferLegRequest,
+	amount int64,
+	accounts map[int64]db.Account,
+) (int, error) {
+	fromAccount, status, err := server.cachedAccount(ctx, accounts, leg.FromAccountID, leg.Currency)
+	if err != nil {
+		return status, err
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		return http.StatusUnauthorized, errors.New("from account doesn't belong to the authenticated user")
+	}
+
+	if needsApproval(fromAccount, amount) {
+		return http.StatusUnprocessableEntity, errTransferNeedsApproval
+	}
+
+	toAccount, status, err := server.cachedAccount(ctx, accounts, leg.ToAccountID, leg.Currency)
+	if err != nil {
+		return status, err
+	}
+
+	if db.IsSystemUsername(toAccount.Owner) {
+		return http.StatusForbidden, errors.New("cannot transfer to a system account")
+	}
+
+	return http.StatusOK, nil
+}
+
+// screenBatchTransfer runs the risk rules on every leg of a batch, as if it
+// were a transfer of its own, and links each leg to its decision. A denied
+// leg refuses the whole batch.
+func (server *Server) screenBatchTransfer(
+	ctx *gin.Context,
+	username string,
+	arg db.BatchTransferTxParams,
+	req batchTransferRequest,
+	accounts map[int64]db.Account,
+) bool {
+	if server.risk == nil {
+		return true
+	}
+
+	replay, valid := server.replayedIdempotencyKey(ctx, arg.Idempotency)
+	if replay || !valid {
+		return valid
+	}
+
+	// the rules count the legs screened before, as they are not stored yet
+	screened := make([]risk.Transfer, 0, len(arg.Transfers))
+	for i := range arg.Transfers {
+		leg := &arg.Transfers[i]
+		transfer := riskTransfer(username, *leg, accounts[leg.ToAccountID], req.Transfers[i].Currency)
+		transfer.Earlier = screened
+
+		result, err := server.risk.Check(ctx, transfer)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+
+		if denial := result.Denial(); denial != nil {
+			rsp := riskDeniedResponse(result, *denial)
+			rsp["error"] = fmt.Sprintf("transfers[%d]: %s", i, rsp["error"])
+			ctx.JSON(http.StatusForbidden, rsp)
+			return false
+		}
+		leg.RiskDecisionID = &result.DecisionID
+
+		transfer.Earlier = nil
+		screened = append(screened, transfer)
+	}
+	return true
+}
+
+func (server *Server) cachedAccount(ctx *gin.Context, accounts map[int64]db.Account, accountID int64, currency string) (db.Account, int, error) {
+	account, ok := accounts[accountID]
+	if !ok {
+		account, status, err := server.checkAccount(ctx, accountID, currency)
+		if err == nil {
+			accounts[accountID] = account
+		}
+		return account, status, err
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account {%d} currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		return account, http.StatusBadRequest, err
+	}
+	return account, http.StatusOK, nil
+}
+
+type batchTransferLegResponse struct {
+	Transfer   transferResponse `json:"transfer"`
+	FromEntry  db.Entry         `json:"from_entry"`
+	ToEntry    db.Entry         `json:"to_entry"`
+	Fee        *feeResponse     `json:"fee,omitempty"`
+	FeeEntries []db.Entry       `json:"fee_entries,omitempty"`
+}
+
+type batchTransferResponse struct {
+	Transfers []batchTransferLegResponse `json:"transfers"`
+	// Accounts are the accounts of the authenticated user after the batch.
+	Accounts []accountResponse `json:"accounts"`
+}
+
+func newBatchTransferResponse(result db.BatchTransferTxResult, username string) batchTransferResponse {
+	currencies := make(map[int64]string, len(result.Accounts))
+	rsp := batchTransferResponse{
+		Transfers: make([]batchTransferLegResponse, len(result.Transfers)),
+		Accounts:  []accountResponse{},
+	}
+
+	for _, account := range result.Accounts {
+		currencies[account.ID] = account.Currency
+		if account.Owner == username {
+			rsp.Accounts = append(rsp.Accounts, newAccountResponse(account))
+		}
+	}
+
+	for i, leg := range result.Transfers {
+		currency := currencies[leg.Transfer.FromAccountID]
+		rsp.Transfers[i] = batchTransferLegResponse{
+			Transfer:   newTransferResponse(leg.Transfer, currency, currency),
+			FromEntry:  leg.FromEntry,
+			ToEntry:    leg.ToEntry,
+			Fee:        newFeeResponse(leg.Fee),
+			FeeEntries: leg.FeeEntries,
+		}
+	}
+	return rsp
+}
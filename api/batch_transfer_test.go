@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBatchTransferAPI(t *testing.T) {
+	employer, _ := randomUser(t)
+	employee1, _ := randomUser(t)
+	employee2, _ := randomUser(t)
+
+	payroll := randomAccount(employer.Username)
+	account1 := randomAccount(employee1.Username)
+	account2 := randomAccount(employee2.Username)
+	payroll.Currency = util.USD
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	legs := []gin.H{
+		{"from_account_id": payroll.ID, "to_account_id": account1.ID, "amount": "1000.00", "currency": util.USD},
+		{"from_account_id": payroll.ID, "to_account_id": account2.ID, "amount": 120000, "currency": util.USD},
+	}
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			requestBody: gin.H{"transfers": legs},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(payroll, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Once().Return(account2, nil)
+
+				arg := db.BatchTransferTxParams{
+					Transfers: []db.TransferTxParams{
+						{FromAccountID: payroll.ID, ToAccountID: account1.ID, Amount: 100000},
+						{FromAccountID: payroll.ID, ToAccountID: account2.ID, Amount: 120000},
+					},
+				}
+				storeMock.
+					On("BatchTransferTx", mock.Anything, arg).
+					Return(db.BatchTransferTxResult{
+						Transfers: []db.BatchTransferLeg{
+							{Transfer: db.Transfer{ID: 1, FromAccountID: payroll.ID, ToAccountID: account1.ID, Amount: 100000, ToAmount: 100000}},
+							{Transfer: db.Transfer{ID: 2, FromAccountID: payroll.ID, ToAccountID: account2.ID, Amount: 120000, ToAmount: 120000}},
+						},
+						Accounts: []db.Account{payroll, account1, account2},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp batchTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Transfers, 2)
+				require.Equal(t, util.NewMoney(100000, util.USD), util.Money(rsp.Transfers[0].Transfer.Amount))
+				require.Equal(t, account2.ID, rsp.Transfers[1].Transfer.ToAccountID)
+
+				// balances of the recipients are not disclosed
+				require.Len(t, rsp.Accounts, 1)
+				require.Equal(t, payroll.ID, rsp.Accounts[0].ID)
+			},
+		},
+		{
+			name:        "Empty",
+			requestBody: gin.H{"transfers": []gin.H{}},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidLegAmount",
+			requestBody: gin.H{"transfers": []gin.H{
+				legs[0],
+				{"from_account_id": payroll.ID, "to_account_id": account2.ID, "amount": "1.001", "currency": util.USD},
+			}},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(payroll, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "transfers[1]")
+			},
+		},
+		{
+			name: "FromAccountOfAnotherUser",
+			requestBody: gin.H{"transfers": []gin.H{
+				legs[0],
+				{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 100, "currency": util.USD},
+			}},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(payroll, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "transfers[1]")
+			},
+		},
+		{
+			name:        "NeedsApproval",
+			requestBody: gin.H{"transfers": legs},
+			buildStubs: func(storeMock *mocks.Store) {
+				threshold := int64(100000)
+				guarded := payroll
+				guarded.ApprovalThreshold = &threshold
+
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(guarded, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "transfers[1]")
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			requestBody: gin.H{"transfers": []gin.H{
+				legs[0],
+				{"from_account_id": payroll.ID, "to_account_id": account2.ID, "amount": 100, "currency": util.EUR},
+			}},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(payroll, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ToSystemAccount",
+			requestBody: gin.H{"transfers": []gin.H{
+				{"from_account_id": payroll.ID, "to_account_id": account1.ID, "amount": 100, "currency": util.USD},
+			}},
+			buildStubs: func(storeMock *mocks.Store) {
+				systemAccount := account1
+				systemAccount.Owner = db.SystemUsername
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(payroll, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(systemAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:        "InsufficientFunds",
+			requestBody: gin.H{"transfers": legs},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, payroll.ID).Once().Return(payroll, nil)
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Once().Return(account2, nil)
+				storeMock.
+					On("BatchTransferTx", mock.Anything, mock.Anything).
+					Return(db.BatchTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, employer.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cashRequest struct {
+	Amount   json.RawMessage `json:"amount" binding:"required"`
+	Currency string          `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) createDeposit(ctx *gin.Context) {
+	server.moveCash(ctx, canDeposit, server.store.DepositTx)
+}
+
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	server.moveCash(ctx, canWithdraw, server.store.WithdrawTx)
+}
+
+type cashTxFunc func(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error)
+
+func (server *Server) moveCash(ctx *gin.Context, allowed func(*token.Payload, db.Account) bool, cashTx cashTxFunc) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := requestAmount(ctx, req.Amount, req.Currency)
+	if !valid {
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !allowed(authPayload, account) {
+		err := errors.New("the authenticated user cannot move cash for this account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if account.Currency != req.Currency {
+		err := fmt.Errorf("account {%d} currency mismatch: %s vs %s", account.ID, account.Currency, req.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := cashTx(ctx, db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, util.ErrAmountOverflow) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cashResponse{
+		Account:   newAccountResponse(result.Account),
+		Entry:     result.Entry,
+		CashEntry: result.CashEntry,
+	})
+}
+
+type cashResponse struct {
+	Account   accountResponse `json:"account"`
+	Entry     db.Entry        `json:"entry"`
+	CashEntry db.Entry        `json:"cash_entry"`
+}
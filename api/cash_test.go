@@ -0,0 +1,301 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/token"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCashAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+
+	amount := int64(10)
+	arg := db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	}
+
+	testCases := []struct {
+		name          string
+		path          string
+		requestBody   gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "DepositOK",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				storeMock.
+					On("DepositTx", mock.Anything, arg).
+					Return(db.CashTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "WithdrawalOK",
+			path: "withdrawals",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				storeMock.
+					On("WithdrawTx", mock.Anything, arg).
+					Return(db.CashTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BankerDepositsForOthers",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				storeMock.
+					On("DepositTx", mock.Anything, arg).
+					Return(db.CashTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "DepositorDepositsForOthers",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			// money cannot be minted into an account by its owner
+			name: "DepositorDepositsForSelf",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth:  func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.EUR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   -amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			path: "withdrawals",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				storeMock.
+					On("WithdrawTx", mock.Anything, arg).
+					Return(db.CashTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "BalanceOverflow",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				storeMock.
+					On("DepositTx", mock.Anything, arg).
+					Return(db.CashTxResult{}, fmt.Errorf("account %d: %w", account.ID, util.ErrAmountOverflow))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			path: "deposits",
+			requestBody: gin.H{
+				"amount":   amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				storeMock.
+					On("DepositTx", mock.Anything, arg).
+					Return(db.CashTxResult{}, sql.ErrTxDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/%s", account.ID, tc.path)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
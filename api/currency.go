@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+	"simple_bank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listCurrencies returns the currencies accounts can be opened in, with the
+// number of decimals clients need to format amounts.
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, util.EnabledCurrencies())
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	server := newTestServer(t, mocks.NewStore(t))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/currencies", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var currencies []util.Currency
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &currencies))
+	require.Equal(t, util.EnabledCurrencies(), currencies)
+}
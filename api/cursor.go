@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const minCursorKeySize = 32
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorCodec turns keyset positions into opaque next_cursor tokens. Tokens
+// are signed, so clients can neither forge a position nor edit the scope of
+// the listing they were issued for.
+type cursorCodec struct {
+	key []byte
+}
+
+func newCursorCodec(key string) (*cursorCodec, error) {
+	if len(key) < minCursorKeySize {
+		return nil, fmt.Errorf("invalid cursor key size: must be at least %d characters", minCursorKeySize)
+	}
+	return &cursorCodec{key: []byte(key)}, nil
+}
+
+func (codec *cursorCodec) encode(position interface{}) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(codec.sign(payload)), nil
+}
+
+func (codec *cursorCodec) decode(cursor string, position interface{}) error {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errInvalidCursor
+	}
+
+	if !hmac.Equal(signature, codec.sign(payload)) {
+		return errInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(position); err != nil {
+		return errInvalidCursor
+	}
+	return nil
+}
+
+func (codec *cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, codec.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
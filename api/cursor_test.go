@@ -0,0 +1,67 @@
+package api
+
+import (
+	"simple_bank/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec, err := newCursorCodec(util.RandomString(32))
+	require.NoError(t, err)
+
+	position := accountCursor{Owner: util.RandomOwner(), AfterID: util.RandomInt(1, 1000)}
+	cursor, err := codec.encode(position)
+	require.NoError(t, err)
+
+	var decoded accountCursor
+	err = codec.decode(cursor, &decoded)
+	require.NoError(t, err)
+	require.Equal(t, position, decoded)
+}
+
+func TestCursorCodecInvalidCursor(t *testing.T) {
+	codec, err := newCursorCodec(util.RandomString(32))
+	require.NoError(t, err)
+
+	otherCodec, err := newCursorCodec(util.RandomString(32))
+	require.NoError(t, err)
+
+	cursor, err := codec.encode(accountCursor{Owner: util.RandomOwner(), AfterID: 1})
+	require.NoError(t, err)
+
+	forged, err := codec.encode(accountCursor{Owner: util.RandomOwner(), AfterID: 2})
+	require.NoError(t, err)
+
+	parts := strings.Split(cursor, ".")
+	forgedParts := strings.Split(forged, ".")
+
+	testCases := []struct {
+		name   string
+		cursor string
+		codec  *cursorCodec
+	}{
+		{name: "Empty", cursor: "", codec: codec},
+		{name: "NoSignature", cursor: parts[0], codec: codec},
+		{name: "NotBase64", cursor: "!!!.???", codec: codec},
+		{name: "TamperedPayload", cursor: forgedParts[0] + "." + parts[1], codec: codec},
+		{name: "OtherKey", cursor: cursor, codec: otherCodec},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var position accountCursor
+			err := tc.codec.decode(tc.cursor, &position)
+			require.ErrorIs(t, err, errInvalidCursor)
+		})
+	}
+}
+
+func TestNewCursorCodecShortKey(t *testing.T) {
+	codec, err := newCursorCodec(util.RandomString(minCursorKeySize - 1))
+	require.Error(t, err)
+	require.Nil(t, codec)
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type listAccountEntriesRequest struct {
+	From     time.Time `form:"from" binding:"required"`
+	To       time.Time `form:"to" binding:"required,gtfield=From"`
+	PageID   *int32    `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32     `form:"page_size" binding:"required,min=5,max=100"`
+	Cursor   string    `form:"cursor"`
+}
+
+type accountStatementResponse struct {
+	AccountID      int64                        `json:"account_id"`
+	Currency       string                       `json:"currency"`
+	From           time.Time                    `json:"from"`
+	To             time.Time                    `json:"to"`
+	OpeningBalance int64                        `json:"opening_balance"`
+	ClosingBalance int64                        `json:"closing_balance"`
+	Entries        []db.ListStatementEntriesRow `json:"entries"`
+	NextCursor     string                       `json:"next_cursor,omitempty"`
+}
+
+type entryCursor struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	AfterTime time.Time `json:"after_time"`
+	AfterID   int64     `json:"after_id"`
+}
+
+// matches reports whether the cursor was issued for the same statement.
+func (cursor entryCursor) matches(accountID int64, req listAccountEntriesRequest) bool {
+	return cursor.AccountID == accountID && cursor.From.Equal(req.From) && cursor.To.Equal(req.To)
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.PageID != nil && req.Cursor != "" {
+		err := errors.New("page_id and cursor cannot be used together")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.readableAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	balances, err := server.store.GetStatementBalances(ctx, db.GetStatementBalancesParams{
+		AccountID: account.ID,
+		FromTime:  req.From,
+		ToTime:    req.To,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := accountStatementResponse{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		From:           req.From,
+		To:             req.To,
+		OpeningBalance: balances.OpeningBalance,
+		ClosingBalance: balances.ClosingBalance,
+	}
+
+	if req.PageID == nil {
+		server.listAccountEntriesAfter(ctx, req, rsp)
+		return
+	}
+
+	rsp.Entries, err = server.store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  req.From,
+		ToTime:    req.To,
+		Limit:     req.PageSize,
+		Offset:    (*req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) listAccountEntriesAfter(ctx *gin.Context, req listAccountEntriesRequest, rsp accountStatementResponse) {
+	position := entryCursor{
+		AccountID: rsp.AccountID,
+		From:      req.From,
+		To:        req.To,
+		AfterTime: req.From,
+	}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || !position.matches(rsp.AccountID, req) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	entries, err := server.store.ListStatementEntriesAfter(ctx, db.ListStatementEntriesAfterParams{
+		AccountID: rsp.AccountID,
+		ToTime:    req.To,
+		AfterTime: position.AfterTime,
+		AfterID:   position.AfterID,
+		Limit:     req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp.Entries = entries
+	if len(entries) > int(req.PageSize) {
+		rsp.Entries = entries[:req.PageSize]
+		last := rsp.Entries[req.PageSize-1]
+		position.AfterTime = last.CreatedAt
+		position.AfterID = last.ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// readableAccount loads the account and checks that the authenticated user
+// may read it, writing the error response otherwise.
+func (server *Server) readableAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canReadAccount(authPayload, account) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/token"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+
+	n := 5
+	balances := db.GetStatementBalancesRow{OpeningBalance: account.Balance}
+	entries := make([]db.ListStatementEntriesRow, n)
+	for i := 0; i < n; i++ {
+		entries[i] = randomStatementEntry(account, from.Add(time.Duration(i)*time.Minute), &balances.ClosingBalance)
+	}
+	balances.ClosingBalance += balances.OpeningBalance
+	for i := range entries {
+		entries[i].RunningBalance += balances.OpeningBalance
+	}
+
+	type Query struct {
+		from     time.Time
+		to       time.Time
+		pageID   int
+		pageSize int
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query:     Query{from: from, to: to, pageID: 1, pageSize: n},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, db.GetStatementBalancesParams{
+						AccountID: account.ID,
+						FromTime:  from,
+						ToTime:    to,
+					}).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntries", mock.Anything, db.ListStatementEntriesParams{
+						AccountID: account.ID,
+						FromTime:  from,
+						ToTime:    to,
+						Limit:     int32(n),
+						Offset:    0,
+					}).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchStatement(t, recorder.Body, accountStatementResponse{
+					AccountID:      account.ID,
+					Currency:       account.Currency,
+					From:           from,
+					To:             to,
+					OpeningBalance: balances.OpeningBalance,
+					ClosingBalance: balances.ClosingBalance,
+					Entries:        entries,
+				})
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			query:     Query{from: from, to: to, pageID: 1, pageSize: n},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "NoAuthorization",
+			accountID:  account.ID,
+			query:      Query{from: from, to: to, pageID: 1, pageSize: n},
+			setupAuth:  func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			query:     Query{from: from, to: to, pageID: 1, pageSize: n},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidRange",
+			accountID: account.ID,
+			query:     Query{from: to, to: from, pageID: 1, pageSize: n},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingRange",
+			accountID: account.ID,
+			query:     Query{pageID: 1, pageSize: n},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidPageSize",
+			accountID: account.ID,
+			query:     Query{from: from, to: to, pageID: 1, pageSize: 1000},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "ListStatementEntriesError",
+			accountID: account.ID,
+			query:     Query{from: from, to: to, pageID: 1, pageSize: n},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntries", mock.Anything, mock.Anything).
+					Return([]db.ListStatementEntriesRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := mocks.NewStore(t)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries", tc.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			if !tc.query.from.IsZero() {
+				q.Add("from", tc.query.from.Format(time.RFC3339))
+			}
+			if !tc.query.to.IsZero() {
+				q.Add("to", tc.query.to.Format(time.RFC3339))
+			}
+			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
+			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			request.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// randomStatementEntry creates an entry and adds its amount to *total, which
+// becomes the running balance relative to the opening balance.
+func randomStatementEntry(account db.Account, createdAt time.Time, total *int64) db.ListStatementEntriesRow {
+	amount := util.RandomMoney()
+	*total += amount
+	return db.ListStatementEntriesRow{
+		ID:             util.RandomInt(1, 1000),
+		AccountID:      account.ID,
+		Amount:         amount,
+		CreatedAt:      createdAt,
+		RunningBalance: *total,
+	}
+}
+
+func requireBodyMatchStatement(t *testing.T, body *bytes.Buffer, statement accountStatementResponse) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotStatement accountStatementResponse
+	err = json.Unmarshal(data, &gotStatement)
+	require.NoError(t, err)
+	require.Equal(t, statement, gotStatement)
+}
+
+func TestListAccountEntriesCursorAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+
+	pageSize := 5
+	var total int64
+	entries := make([]db.ListStatementEntriesRow, pageSize+1)
+	for i := range entries {
+		entries[i] = randomStatementEntry(account, from.Add(time.Duration(i)*time.Minute), &total)
+	}
+	balances := db.GetStatementBalancesRow{ClosingBalance: total}
+	last := entries[pageSize-1]
+
+	testCases := []struct {
+		name          string
+		cursor        func(t *testing.T, codec *cursorCodec) string
+		buildStubs    func(mockStore *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec)
+	}{
+		{
+			name: "FirstPage",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				return ""
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntriesAfter", mock.Anything, db.ListStatementEntriesAfterParams{
+						AccountID: account.ID,
+						ToTime:    to,
+						AfterTime: from,
+						AfterID:   0,
+						Limit:     int32(pageSize + 1),
+					}).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp accountStatementResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, entries[:pageSize], rsp.Entries)
+
+				var position entryCursor
+				err = codec.decode(rsp.NextCursor, &position)
+				require.NoError(t, err)
+				require.Equal(t, account.ID, position.AccountID)
+				require.True(t, last.CreatedAt.Equal(position.AfterTime))
+				require.Equal(t, last.ID, position.AfterID)
+			},
+		},
+		{
+			name: "NextPage",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				cursor, err := codec.encode(entryCursor{
+					AccountID: account.ID,
+					From:      from,
+					To:        to,
+					AfterTime: last.CreatedAt,
+					AfterID:   last.ID,
+				})
+				require.NoError(t, err)
+				return cursor
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntriesAfter", mock.Anything, db.ListStatementEntriesAfterParams{
+						AccountID: account.ID,
+						ToTime:    to,
+						AfterTime: last.CreatedAt,
+						AfterID:   last.ID,
+						Limit:     int32(pageSize + 1),
+					}).
+					Return(entries[pageSize:], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp accountStatementResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, entries[pageSize:], rsp.Entries)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name: "CursorOfOtherPeriod",
+			cursor: func(t *testing.T, codec *cursorCodec) string {
+				cursor, err := codec.encode(entryCursor{
+					AccountID: account.ID,
+					From:      from.Add(-time.Hour),
+					To:        to,
+					AfterTime: last.CreatedAt,
+					AfterID:   last.ID,
+				})
+				require.NoError(t, err)
+				return cursor
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, codec *cursorCodec) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := mocks.NewStore(t)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			if cursor := tc.cursor(t, server.cursors); cursor != "" {
+				q.Add("cursor", cursor)
+			}
+			q.Add("from", from.Format(time.RFC3339))
+			q.Add("to", to.Format(time.RFC3339))
+			q.Add("page_size", fmt.Sprintf("%d", pageSize))
+			request.URL.RawQuery = q.Encode()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.cursors)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/fx"
+	"simple_bank/token"
+	"simple_bank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultQuoteDuration is how long a quote stays valid when
+// FX_QUOTE_DURATION is not set.
+const defaultQuoteDuration = 30 * time.Second
+
+var (
+	errQuoteNotFound  = errors.New("exchange quote not found")
+	errAmountTooSmall = errors.New("amount is too small to be converted")
+)
+
+// newFXSpread parses the spread charged on top of the mid rate. An empty
+// value means no spread.
+func newFXSpread(spread string) (*big.Rat, error) {
+	if spread == "" {
+		return new(big.Rat), nil
+	}
+
+	rat, err := fx.ParseDecimal(spread)
+	if err != nil {
+		return nil, err
+	}
+	if rat.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, fmt.Errorf("%w: spread %s must be below 1", fx.ErrInvalidRate, spread)
+	}
+	return rat, nil
+}
+
+type createExchangeQuoteRequest struct {
+	FromCurrency string          `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string          `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	Amount       json.RawMessage `json:"amount" binding:"required"`
+}
+
+type exchangeQuoteResponse struct {
+	db.ExchangeQuote
+	Amount   util.FormattedMoney `json:"amount"`
+	ToAmount util.FormattedMoney `json:"to_amount"`
+}
+
+// createExchangeQuote fixes the rate of a cross-currency transfer for a short
+// time. The returned id is passed as quote_id to POST /transfers.
+func (server *Server) createExchangeQuote(ctx *gin.Context) {
+	var req createExchangeQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := requestAmount(ctx, req.Amount, req.FromCurrency)
+	if !valid {
+		return
+	}
+
+	exchangeRate, err := server.store.GetExchangeRate(ctx, db.GetExchangeRateParams{
+		BaseCurrency:  req.FromCurrency,
+		QuoteCurrency: req.ToCurrency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("no exchange rate for %s/%s", req.FromCurrency, req.ToCurrency)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	midRate, err := fx.ParseDecimal(exchangeRate.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	rate := fx.ApplySpread(midRate, server.fxSpread)
+
+	// an amount that cannot be converted must not leave a quote behind
+	toAmount, err := convertAmount(amount.Amount, rate, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	duration := server.config.FXQuoteDuration
+	if duration <= 0 {
+		duration = defaultQuoteDuration
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	quote, err := server.store.CreateExchangeQuote(ctx, db.CreateExchangeQuoteParams{
+		ID:            uuid.New(),
+		Username:      authPayload.Username,
+		BaseCurrency:  req.FromCurrency,
+		QuoteCurrency: req.ToCurrency,
+		Rate:          fx.FormatDecimal(rate),
+		Spread:        fx.FormatDecimal(server.fxSpread),
+		ExpiresAt:     time.Now().Add(duration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, exchangeQuoteResponse{
+		ExchangeQuote: quote,
+		Amount:        amount.Formatted(),
+		ToAmount:      util.NewMoney(toAmount, req.ToCurrency).Formatted(),
+	})
+}
+
+// validQuote loads a quote of the authenticated user that is still valid and
+// converts from currency, writing the error response otherwise.
+func (server *Server) validQuote(ctx *gin.Context, quoteID string, currency string) (db.ExchangeQuote, bool) {
+	id, err := uuid.Parse(quoteID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ExchangeQuote{}, false
+	}
+
+	quote, err := server.store.GetExchangeQuote(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errQuoteNotFound))
+			return quote, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return quote, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if quote.Username != authPayload.Username {
+		ctx.JSON(http.StatusNotFound, errorResponse(errQuoteNotFound))
+		return quote, false
+	}
+
+	if time.Now().After(quote.ExpiresAt) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrQuoteExpired))
+		return quote, false
+	}
+
+	if quote.BaseCurrency != currency {
+		err := fmt.Errorf("exchange quote converts from %s, not %s", quote.BaseCurrency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return quote, false
+	}
+
+	return quote, true
+}
+
+// exchangeTransfer converts arg.Amount at the quoted rate and runs the
+// cross-currency transfer.
+func (server *Server) exchangeTransfer(ctx *gin.Context, arg db.TransferTxParams, quote db.ExchangeQuote) (db.TransferTxResult, error) {
+	rate, err := fx.ParseDecimal(quote.Rate)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	toAmount, err := convertAmount(arg.Amount, rate, quote.BaseCurrency, quote.QuoteCurrency)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+	if toAmount <= 0 {
+		return db.TransferTxResult{}, errAmountTooSmall
+	}
+
+	return server.store.ExchangeTransferTx(ctx, db.ExchangeTransferTxParams{
+		TransferTxParams: arg,
+		ToAmount:         toAmount,
+		ExchangeRate:     quote.Rate,
+		ExchangeSpread:   quote.Spread,
+		QuoteID:          quote.ID,
+	})
+}
+
+// convertAmount converts minor units of from into minor units of to, which
+// may have a different number of decimals.
+func convertAmount(amount int64, rate *big.Rat, from string, to string) (int64, error) {
+	return fx.ConvertMinorUnits(amount, rate, util.MinorUnits(from), util.MinorUnits(to))
+}
@@ -0,0 +1,403 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/token"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExchangeQuoteAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	amount := int64(1000)
+
+	exchangeRate := db.ExchangeRate{
+		BaseCurrency:  util.USD,
+		QuoteCurrency: util.EUR,
+		Rate:          "0.9",
+	}
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			requestBody: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetExchangeRate", mock.Anything, db.GetExchangeRateParams{
+						BaseCurrency:  util.USD,
+						QuoteCurrency: util.EUR,
+					}).
+					Return(exchangeRate, nil)
+				storeMock.
+					On("CreateExchangeQuote", mock.Anything, mock.MatchedBy(func(arg db.CreateExchangeQuoteParams) bool {
+						// 0.9 lowered by the 1% spread
+						return arg.Username == user.Username && arg.Rate == "0.891" && arg.Spread == "0.01"
+					})).
+					Return(func(_ context.Context, arg db.CreateExchangeQuoteParams) db.ExchangeQuote {
+						return db.ExchangeQuote{
+							ID:            arg.ID,
+							Username:      arg.Username,
+							BaseCurrency:  arg.BaseCurrency,
+							QuoteCurrency: arg.QuoteCurrency,
+							Rate:          arg.Rate,
+							Spread:        arg.Spread,
+							ExpiresAt:     arg.ExpiresAt,
+						}
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp exchangeQuoteResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEqual(t, uuid.Nil, rsp.ID)
+				require.Equal(t, "0.891", rsp.Rate)
+				require.Equal(t, util.NewMoney(amount, util.USD), util.Money(rsp.Amount))
+				require.Equal(t, util.NewMoney(891, util.EUR), util.Money(rsp.ToAmount))
+				require.WithinDuration(t, time.Now().Add(defaultQuoteDuration), rsp.ExpiresAt, time.Second)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			requestBody: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        amount,
+			},
+			setupAuth:  func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SameCurrency",
+			requestBody: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.USD,
+				"amount":        amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "RateNotFound",
+			requestBody: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.CAD,
+				"amount":        amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetExchangeRate", mock.Anything, mock.Anything).
+					Return(db.ExchangeRate{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			// no quote is saved for an amount that cannot be converted
+			name: "ConversionOverflow",
+			requestBody: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				rate := exchangeRate
+				rate.Rate = "100000000000000000000"
+				storeMock.
+					On("GetExchangeRate", mock.Anything, mock.Anything).
+					Return(rate, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "CreateQuoteError",
+			requestBody: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        amount,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetExchangeRate", mock.Anything, mock.Anything).
+					Return(exchangeRate, nil)
+				storeMock.
+					On("CreateExchangeQuote", mock.Anything, mock.Anything).
+					Return(db.ExchangeQuote{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			server.fxSpread = big.NewRat(1, 100)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/exchange_quotes", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateExchangeTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account1.Currency = util.USD
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = util.EUR
+
+	amount := int64(100)
+	quote := db.ExchangeQuote{
+		ID:            uuid.New(),
+		Username:      user1.Username,
+		BaseCurrency:  util.USD,
+		QuoteCurrency: util.EUR,
+		Rate:          "0.9",
+		Spread:        "0.005",
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	requestBody := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          amount,
+		"currency":        util.USD,
+		"quote_id":        quote.ID,
+	}
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				arg := db.ExchangeTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+					},
+					ToAmount:       90,
+					ExchangeRate:   quote.Rate,
+					ExchangeSpread: quote.Spread,
+					QuoteID:        quote.ID,
+				}
+
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(quote, nil)
+				storeMock.
+					On("ExchangeTransferTx", mock.Anything, arg).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "QuoteNotFound",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(db.ExchangeQuote{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "QuoteOfAnotherUser",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				otherQuote := quote
+				otherQuote.Username = user2.Username
+
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(otherQuote, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "QuoteExpired",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				expiredQuote := quote
+				expiredQuote.ExpiresAt = time.Now().Add(-time.Second)
+
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(expiredQuote, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:        "ToAccountCurrencyMismatch",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				cadAccount := account2
+				cadAccount.Currency = util.CAD
+
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(cadAccount, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(quote, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AmountTooSmall",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1,
+				"currency":        util.USD,
+				"quote_id":        quote.ID,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(quote, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:        "QuoteExpiredDuringTransfer",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(quote, nil)
+				storeMock.
+					On("ExchangeTransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, db.ErrQuoteExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:        "QuoteAlreadyUsed",
+			requestBody: requestBody,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+				storeMock.On("GetExchangeQuote", mock.Anything, quote.ID).Return(quote, nil)
+				storeMock.
+					On("ExchangeTransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, db.ErrQuoteAlreadyUsed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidQuoteID",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"quote_id":        "not-a-uuid",
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
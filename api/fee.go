@@ -0,0 +1,329 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var errFeeProductNotFound = errors.New("account product not found")
+
+type feeResponse struct {
+	Kind       string               `json:"kind"`
+	Rate       string               `json:"rate,omitempty"`
+	Calculated util.FormattedMoney  `json:"calculated"`
+	MinFee     util.FormattedMoney  `json:"min_fee"`
+	MaxFee     *util.FormattedMoney `json:"max_fee"`
+	Amount     util.FormattedMoney  `json:"amount"`
+}
+
+func newFeeResponse(fee *db.FeeBreakdown) *feeResponse {
+	if fee == nil {
+		return nil
+	}
+
+	rsp := &feeResponse{
+		Kind:       fee.Kind,
+		Rate:       fee.Rate,
+		Calculated: util.NewMoney(fee.Calculated, fee.Currency).Formatted(),
+		MinFee:     util.NewMoney(fee.MinFee, fee.Currency).Formatted(),
+		Amount:     util.NewMoney(fee.Amount, fee.Currency).Formatted(),
+	}
+	if fee.MaxFee != nil {
+		maxFee := util.NewMoney(*fee.MaxFee, fee.Currency).Formatted()
+		rsp.MaxFee = &maxFee
+	}
+	return rsp
+}
+
+type transferFeeResponse struct {
+	Currency  string               `json:"currency"`
+	Product   string               `json:"product"`
+	Kind      string               `json:"kind"`
+	Amount    *util.FormattedMoney `json:"amount,omitempty"`
+	Rate      string               `json:"rate,omitempty"`
+	MinFee    util.FormattedMoney  `json:"min_fee"`
+	MaxFee    *util.FormattedMoney `json:"max_fee"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func newTransferFeeResponse(fee db.TransferFee) transferFeeResponse {
+	rsp := transferFeeResponse{
+		Currency:  fee.Currency,
+		Product:   fee.Product,
+		Kind:      fee.Kind,
+		MinFee:    util.NewMoney(fee.MinFee, fee.Currency).Formatted(),
+		UpdatedAt: fee.UpdatedAt,
+	}
+	if fee.Kind == db.FeeFlat {
+		amount := util.NewMoney(fee.Amount, fee.Currency).Formatted()
+		rsp.Amount = &amount
+	} else {
+		rsp.Rate = fee.Rate
+	}
+	if fee.MaxFee != nil {
+		maxFee := util.NewMoney(*fee.MaxFee, fee.Currency).Formatted()
+		rsp.MaxFee = &maxFee
+	}
+	return rsp
+}
+
+// listTransferFees shows the whole fee schedule. Currencies and products
+// without a row charge no fee.
+func (server *Server) listTransferFees(ctx *gin.Context) {
+	fees, err := server.store.ListTransferFees(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferFeeResponse, 0, len(fees))
+	for _, fee := range fees {
+		rsp = append(rsp, newTransferFeeResponse(fee))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type transferFeeURI struct {
+	Currency string `uri:"currency" binding:"required,currency"`
+	Product  string `uri:"product" binding:"required,alphanum"`
+}
+
+type setTransferFeeRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=flat percentage"`
+	// Amount is the fee of a flat kind, and Rate the share of the amount
+	// charged by a percentage kind, e.g. "0.01" for 1%.
+	Amount json.RawMessage `json:"amount"`
+	Rate   string          `json:"rate" binding:"required_if=Kind percentage"`
+	MinFee json.RawMessage `json:"min_fee"`
+	MaxFee json.RawMessage `json:"max_fee"`
+}
+
+// setTransferFee creates or replaces the fee charged on transfers from
+// accounts of a currency and product.
+func (server *Server) setTransferFee(ctx *gin.Context) {
+	var uri transferFeeURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setTransferFeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canManageTransferFees(authPayload) {
+		err := errors.New("not allowed to change the fee schedule")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg, err := req.params(uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fee, err := server.store.UpsertTransferFee(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusNotFound, errorResponse(errFeeProductNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferFeeResponse(fee))
+}
+
+// params checks the amounts and rate of the request, which depend on the
+// currency and kind of the fee.
+func (req setTransferFeeRequest) params(uri transferFeeURI) (db.UpsertTransferFeeParams, error) {
+	arg := db.UpsertTransferFeeParams{
+		Currency: uri.Currency,
+		Product:  uri.Product,
+		Kind:     req.Kind,
+		Rate:     "0",
+	}
+
+	var err error
+	switch req.Kind {
+	case db.FeeFlat:
+		if len(req.Amount) == 0 {
+			return arg, errors.New("a flat fee needs an amount")
+		}
+		if req.Rate != "" {
+			return arg, errors.New("a flat fee takes an amount, not a rate")
+		}
+		if arg.Amount, err = feeAmount(req.Amount, uri.Currency); err != nil {
+			return arg, fmt.Errorf("invalid amount: %w", err)
+		}
+	case db.FeePercentage:
+		if len(req.Amount) != 0 {
+			return arg, errors.New("a percentage fee takes a rate, not an amount")
+		}
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(req.Rate))
+		if !ok || rate.Sign() <= 0 || rate.Cmp(big.NewRat(1, 1)) > 0 {
+			return arg, fmt.Errorf("invalid rate %q: must be above 0 and at most 1", req.Rate)
+		}
+		arg.Rate = strings.TrimSpace(req.Rate)
+	}
+
+	if len(req.MinFee) != 0 {
+		if arg.MinFee, err = feeAmount(req.MinFee, uri.Currency); err != nil {
+			return arg, fmt.Errorf("invalid min_fee: %w", err)
+		}
+	}
+	if len(req.MaxFee) != 0 {
+		maxFee, err := feeAmount(req.MaxFee, uri.Currency)
+		if err != nil {
+			return arg, fmt.Errorf("invalid max_fee: %w", err)
+		}
+		if maxFee < arg.MinFee {
+			return arg, errors.New("max_fee is below min_fee")
+		}
+		arg.MaxFee = &maxFee
+	}
+
+	return arg, nil
+}
+
+// feeAmount parses an amount of the fee schedule, which unlike the amount of
+// a transfer may be zero.
+func feeAmount(amount json.RawMessage, currency string) (int64, error) {
+	money, err := util.ParseMoneyJSON(amount, currency)
+	if err != nil {
+		return 0, err
+	}
+	if money.Amount < 0 {
+		return 0, errors.New("amount must not be negative")
+	}
+	return money.Amount, nil
+}
+
+// deleteTransferFee stops charging a fee on transfers from accounts of a
+// currency and product.
+func (server *Server) deleteTransferFee(ctx *gin.Context) {
+	var uri transferFeeURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canManageTransferFees(authPayload) {
+		err := errors.New("not allowed to change the fee schedule")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	err := server.store.DeleteTransferFee(ctx, db.DeleteTransferFeeParams{
+		Currency: uri.Currency,
+		Product:  uri.Product,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type createFeeQuoteRequest struct {
+	FromAccountID int64           `json:"from_account_id" binding:"required,min=1"`
+	Amount        json.RawMessage `json:"amount" binding:"required"`
+	Currency      string          `json:"currency" binding:"required,currency"`
+}
+
+type feeQuoteResponse struct {
+	FromAccountID int64               `json:"from_account_id"`
+	Amount        util.FormattedMoney `json:"amount"`
+	// Fee is null when the transfer is free.
+	Fee *feeResponse `json:"fee"`
+	// Total is debited from the account: the amount and the fee.
+	Total util.FormattedMoney `json:"total"`
+}
+
+// createFeeQuote shows the fee a transfer from an account of the
+// authenticated user would be charged, without transferring anything. It
+// applies to transfers to an account in another currency as well. The
+// fee is computed again when the transfer is made, so a change of the
+// schedule in between applies.
+func (server *Server) createFeeQuote(ctx *gin.Context) {
+	var req createFeeQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := requestAmount(ctx, req.Amount, req.Currency)
+	if !valid {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	rsp := feeQuoteResponse{
+		FromAccountID: fromAccount.ID,
+		Amount:        amount.Formatted(),
+		Total:         amount.Formatted(),
+	}
+
+	schedule, err := server.store.GetTransferFee(ctx, db.GetTransferFeeParams{
+		Currency: fromAccount.Currency,
+		Product:  fromAccount.Product,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, rsp)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	fee, err := db.ComputeFee(schedule, amount.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if fee.Amount == 0 {
+		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	total, err := amount.Add(util.NewMoney(fee.Amount, fee.Currency))
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	rsp.Fee = newFeeResponse(&fee)
+	rsp.Total = total.Formatted()
+	ctx.JSON(http.StatusOK, rsp)
+}
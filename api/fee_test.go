@@ -0,0 +1,467 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/token"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFeeQuoteAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+	account.Product = db.ProductSavings
+
+	maxFee := int64(500)
+	schedule := db.TransferFee{
+		Currency: util.USD,
+		Product:  db.ProductSavings,
+		Kind:     db.FeePercentage,
+		Rate:     "0.01",
+		MinFee:   50,
+		MaxFee:   &maxFee,
+	}
+	scheduleParams := db.GetTransferFeeParams{Currency: util.USD, Product: db.ProductSavings}
+
+	requestBody := gin.H{
+		"from_account_id": account.ID,
+		"amount":          "123.45",
+		"currency":        util.USD,
+	}
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			requestBody: requestBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+				storeMock.On("GetTransferFee", mock.Anything, scheduleParams).Return(schedule, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp feeQuoteResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.FromAccountID)
+				require.Equal(t, util.NewMoney(12345, util.USD), util.Money(rsp.Amount))
+				require.NotNil(t, rsp.Fee)
+				require.Equal(t, db.FeePercentage, rsp.Fee.Kind)
+				require.Equal(t, "0.01", rsp.Fee.Rate)
+				require.Equal(t, util.NewMoney(123, util.USD), util.Money(rsp.Fee.Calculated))
+				require.Equal(t, util.NewMoney(123, util.USD), util.Money(rsp.Fee.Amount))
+				require.Equal(t, util.NewMoney(500, util.USD), util.Money(*rsp.Fee.MaxFee))
+				require.Equal(t, util.NewMoney(12468, util.USD), util.Money(rsp.Total))
+			},
+		},
+		{
+			name: "MinFee",
+			requestBody: gin.H{
+				"from_account_id": account.ID,
+				"amount":          1000,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+				storeMock.On("GetTransferFee", mock.Anything, scheduleParams).Return(schedule, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp feeQuoteResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, util.NewMoney(10, util.USD), util.Money(rsp.Fee.Calculated))
+				require.Equal(t, util.NewMoney(50, util.USD), util.Money(rsp.Fee.Amount))
+				require.Equal(t, util.NewMoney(1050, util.USD), util.Money(rsp.Total))
+			},
+		},
+		{
+			name:        "NoFee",
+			requestBody: requestBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+				storeMock.On("GetTransferFee", mock.Anything, scheduleParams).Return(db.TransferFee{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "null", string(rsp["fee"]))
+
+				var quote feeQuoteResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &quote))
+				require.Equal(t, quote.Amount, quote.Total)
+			},
+		},
+		{
+			name:        "UnauthorizedUser",
+			requestBody: requestBody,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, other.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:        "NoAuthorization",
+			requestBody: requestBody,
+			setupAuth:   func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			requestBody: gin.H{
+				"from_account_id": account.ID,
+				"amount":          1000,
+				"currency":        util.EUR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/fee_quotes", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSetTransferFeeAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	currency := util.USD
+	product := db.ProductSavings
+
+	testCases := []struct {
+		name          string
+		product       string
+		requestBody   gin.H
+		role          string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "Flat",
+			product: product,
+			requestBody: gin.H{
+				"kind":   db.FeeFlat,
+				"amount": "0.25",
+			},
+			role: util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				arg := db.UpsertTransferFeeParams{
+					Currency: currency,
+					Product:  product,
+					Kind:     db.FeeFlat,
+					Amount:   25,
+					Rate:     "0",
+				}
+				storeMock.
+					On("UpsertTransferFee", mock.Anything, arg).
+					Return(db.TransferFee{Currency: currency, Product: product, Kind: db.FeeFlat, Amount: 25, Rate: "0"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferFeeResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.FeeFlat, rsp.Kind)
+				require.Equal(t, util.NewMoney(25, currency), util.Money(*rsp.Amount))
+				require.Empty(t, rsp.Rate)
+				require.Nil(t, rsp.MaxFee)
+			},
+		},
+		{
+			name:    "Percentage",
+			product: product,
+			requestBody: gin.H{
+				"kind":    db.FeePercentage,
+				"rate":    "0.01",
+				"min_fee": 50,
+				"max_fee": "5.00",
+			},
+			role: util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("UpsertTransferFee", mock.Anything, mock.MatchedBy(func(arg db.UpsertTransferFeeParams) bool {
+						return arg.Kind == db.FeePercentage && arg.Rate == "0.01" && arg.Amount == 0 &&
+							arg.MinFee == 50 && arg.MaxFee != nil && *arg.MaxFee == 500
+					})).
+					Return(func(_ context.Context, arg db.UpsertTransferFeeParams) db.TransferFee {
+						return db.TransferFee{
+							Currency: arg.Currency,
+							Product:  arg.Product,
+							Kind:     arg.Kind,
+							Rate:     arg.Rate,
+							MinFee:   arg.MinFee,
+							MaxFee:   arg.MaxFee,
+						}
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferFeeResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "0.01", rsp.Rate)
+				require.Nil(t, rsp.Amount)
+				require.Equal(t, util.NewMoney(50, currency), util.Money(rsp.MinFee))
+				require.Equal(t, util.NewMoney(500, currency), util.Money(*rsp.MaxFee))
+			},
+		},
+		{
+			name:    "NotAdmin",
+			product: product,
+			requestBody: gin.H{
+				"kind":   db.FeeFlat,
+				"amount": 25,
+			},
+			role:       util.BankerRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:    "FlatWithoutAmount",
+			product: product,
+			requestBody: gin.H{
+				"kind": db.FeeFlat,
+			},
+			role:       util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "FlatWithRate",
+			product: product,
+			requestBody: gin.H{
+				"kind":   db.FeeFlat,
+				"amount": 25,
+				"rate":   "0.01",
+			},
+			role:       util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "PercentageWithAmount",
+			product: product,
+			requestBody: gin.H{
+				"kind":   db.FeePercentage,
+				"rate":   "0.01",
+				"amount": 25,
+			},
+			role:       util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "InvalidRate",
+			product: product,
+			requestBody: gin.H{
+				"kind": db.FeePercentage,
+				"rate": "1.5",
+			},
+			role:       util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "NegativeAmount",
+			product: product,
+			requestBody: gin.H{
+				"kind":   db.FeeFlat,
+				"amount": -25,
+			},
+			role:       util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "MaxFeeBelowMinFee",
+			product: product,
+			requestBody: gin.H{
+				"kind":    db.FeePercentage,
+				"rate":    "0.01",
+				"min_fee": 500,
+				"max_fee": 50,
+			},
+			role:       util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "ProductNotFound",
+			product: "premium",
+			requestBody: gin.H{
+				"kind":   db.FeeFlat,
+				"amount": 25,
+			},
+			role: util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("UpsertTransferFee", mock.Anything, mock.Anything).
+					Return(db.TransferFee{}, &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/transfer_fees/%s/%s", currency, tc.product)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteTransferFeeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	arg := db.DeleteTransferFeeParams{Currency: util.USD, Product: db.ProductSavings}
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("DeleteTransferFee", mock.Anything, arg).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:       "NotAdmin",
+			role:       util.DepositorRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("DeleteTransferFee", mock.Anything, arg).Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer_fees/%s/%s", arg.Currency, arg.Product)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"simple_bank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errAmountNotPositive = errors.New("amount must be greater than zero")
+
+// requestAmount parses the amount of a request in currency. Clients send
+// either an integer number of minor units or a decimal string such as
+// "12.34". It writes the error response when the amount is not positive.
+func requestAmount(ctx *gin.Context, amount json.RawMessage, currency string) (util.Money, bool) {
+	money, err := parseAmount(amount, currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return money, false
+	}
+
+	return money, true
+}
+
+// parseAmount is requestAmount without the error response.
+func parseAmount(amount json.RawMessage, currency string) (util.Money, error) {
+	money, err := util.ParseMoneyJSON(amount, currency)
+	if err != nil {
+		return money, err
+	}
+
+	if !money.IsPositive() {
+		return money, errAmountNotPositive
+	}
+
+	return money, nil
+}
@@ -0,0 +1,384 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPaymentRequestDuration = 7 * 24 * time.Hour
+
+var (
+	errRequestOwnPayment     = errors.New("cannot request a payment from yourself")
+	errNotPaymentRequestUser = errors.New("payment request doesn't involve the authenticated user")
+	errNotPayer              = errors.New("only the payer can accept or decline a payment request")
+	errNotRequester          = errors.New("only the requester can cancel a payment request")
+)
+
+type createPaymentRequestRequest struct {
+	Payer       string          `json:"payer" binding:"required,alphanum"`
+	Amount      json.RawMessage `json:"amount" binding:"required"`
+	Currency    string          `json:"currency" binding:"required,currency"`
+	Description string          `json:"description,omitempty" binding:"max=255"`
+}
+
+type paymentRequestResponse struct {
+	ID          int64               `json:"id"`
+	Requester   string              `json:"requester"`
+	Payer       string              `json:"payer"`
+	ToAccountID int64               `json:"to_account_id"`
+	Amount      util.FormattedMoney `json:"amount"`
+	Description string              `json:"description"`
+	Status      string              `json:"status"`
+	TransferID  *int64              `json:"transfer_id"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+func newPaymentRequestResponse(request db.PaymentRequest) paymentRequestResponse {
+	return paymentRequestResponse{
+		ID:          request.ID,
+		Requester:   request.Requester,
+		Payer:       request.Payer,
+		ToAccountID: request.ToAccountID,
+		Amount:      util.NewMoney(request.Amount, request.Currency).Formatted(),
+		Description: request.Description,
+		Status:      request.Status,
+		TransferID:  request.TransferID,
+		ExpiresAt:   request.ExpiresAt,
+		CreatedAt:   request.CreatedAt,
+		UpdatedAt:   request.UpdatedAt,
+	}
+}
+
+// createPaymentRequest asks another user for money. The account of the
+// requester in the currency of the request is credited once the payer
+// accepts.
+func (server *Server) createPaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := requestAmount(ctx, req.Amount, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Payer == authPayload.Username {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errRequestOwnPayment))
+		return
+	}
+
+	if db.IsSystemUsername(req.Payer) {
+		err := errors.New("cannot request a payment from a system account")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	payer, err := server.store.GetUser(ctx, req.Payer)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("payer %q not found", req.Payer)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	toAccount, err := server.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    authPayload.Username,
+		Currency: req.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("you have no %s account", req.Currency)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	duration := server.config.PaymentRequestDuration
+	if duration <= 0 {
+		duration = defaultPaymentRequestDuration
+	}
+
+	request, err := server.store.CreatePaymentRequest(ctx, db.CreatePaymentRequestParams{
+		Requester:   authPayload.Username,
+		Payer:       payer.Username,
+		ToAccountID: toAccount.ID,
+		Amount:      amount.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		ExpiresAt:   time.Now().Add(duration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPaymentRequestResponse(request))
+}
+
+type listPaymentRequestsRequest struct {
+	Direction string `form:"direction" binding:"required,oneof=incoming outgoing"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending accepted declined cancelled expired"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor    string `form:"cursor"`
+}
+
+type listPaymentRequestsResponse struct {
+	PaymentRequests []paymentRequestResponse `json:"payment_requests"`
+	NextCursor      string                   `json:"next_cursor,omitempty"`
+}
+
+// paymentRequestCursor keeps the filters of the first page, so that later
+// pages cannot change them.
+type paymentRequestCursor struct {
+	Username  string `json:"username"`
+	Direction string `json:"direction"`
+	Status    string `json:"status"`
+	AfterID   int64  `json:"after_id"`
+}
+
+// listPaymentRequests pages through the payment requests the authenticated
+// user received (incoming) or sent (outgoing).
+func (server *Server) listPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	position := paymentRequestCursor{
+		Username:  authPayload.Username,
+		Direction: req.Direction,
+		Status:    req.Status,
+	}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || position.Username != authPayload.Username ||
+			position.Direction != req.Direction || position.Status != req.Status {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	arg := db.ListPaymentRequestsAfterParams{
+		Status:  optionalString(position.Status),
+		AfterID: position.AfterID,
+		Limit:   req.PageSize + 1,
+	}
+	if position.Direction == "incoming" {
+		arg.Payer = &position.Username
+	} else {
+		arg.Requester = &position.Username
+	}
+
+	requests, err := server.store.ListPaymentRequestsAfter(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listPaymentRequestsResponse{PaymentRequests: make([]paymentRequestResponse, 0, len(requests))}
+	for _, request := range requests {
+		rsp.PaymentRequests = append(rsp.PaymentRequests, newPaymentRequestResponse(request))
+	}
+	if len(requests) > int(req.PageSize) {
+		rsp.PaymentRequests = rsp.PaymentRequests[:req.PageSize]
+		position.AfterID = rsp.PaymentRequests[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// getPaymentRequest shows a payment request to its requester and its payer.
+func (server *Server) getPaymentRequest(ctx *gin.Context) {
+	request, valid := server.requestPaymentRequest(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Requester != authPayload.Username && request.Payer != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errNotPaymentRequestUser))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPaymentRequestResponse(request))
+}
+
+type acceptPaymentRequestResponse struct {
+	PaymentRequest paymentRequestResponse `json:"payment_request"`
+	Transfer       transferResponse       `json:"transfer"`
+}
+
+// acceptPaymentRequest pays a payment request from the account of the payer
+// in the currency of the request. It goes through the same risk rules as any
+// other transfer, but cannot wait for approval.
+func (server *Server) acceptPaymentRequest(ctx *gin.Context) {
+	request, valid := server.openPaymentRequest(ctx, true)
+	if !valid {
+		return
+	}
+
+	fromAccount, err := server.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    request.Payer,
+		Currency: request.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("you have no %s account", request.Currency)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if needsApproval(fromAccount, request.Amount) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errTransferNeedsApproval))
+		return
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Description:   request.Description,
+	}
+	// the to account was looked up by owner when the request was created
+	toAccount := db.Account{ID: request.ToAccountID, Owner: request.Requester}
+	decisionID, valid := server.screenTransfer(ctx, request.Payer, arg, toAccount, request.Currency)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.AcceptPaymentRequestTx(ctx, db.AcceptPaymentRequestTxParams{
+		ID:             request.ID,
+		FromAccountID:  fromAccount.ID,
+		RiskDecisionID: decisionID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, util.ErrAmountOverflow) ||
+			errors.Is(err, db.ErrPaymentRequestExpired) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrPaymentRequestClosed) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, acceptPaymentRequestResponse{
+		PaymentRequest: newPaymentRequestResponse(result.PaymentRequest),
+		Transfer:       newTransferResponse(result.Transfer, result.FromAccount.Currency, result.ToAccount.Currency),
+	})
+}
+
+// declinePaymentRequest lets the payer turn down a payment request.
+func (server *Server) declinePaymentRequest(ctx *gin.Context) {
+	server.closePaymentRequest(ctx, true, db.PaymentRequestDeclined)
+}
+
+// cancelPaymentRequest lets the requester withdraw a payment request.
+func (server *Server) cancelPaymentRequest(ctx *gin.Context) {
+	server.closePaymentRequest(ctx, false, db.PaymentRequestCancelled)
+}
+
+func (server *Server) closePaymentRequest(ctx *gin.Context, byPayer bool, status string) {
+	request, valid := server.openPaymentRequest(ctx, byPayer)
+	if !valid {
+		return
+	}
+
+	closed, err := server.store.ClosePaymentRequest(ctx, db.ClosePaymentRequestParams{
+		ID:     request.ID,
+		Status: status,
+	})
+	if err != nil {
+		// the other user, or the expiry, got there first
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(db.ErrPaymentRequestClosed))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPaymentRequestResponse(closed))
+}
+
+// openPaymentRequest returns the payment request of the request when the
+// authenticated user is its payer, or its requester when byPayer is false,
+// and it is still pending.
+func (server *Server) openPaymentRequest(ctx *gin.Context, byPayer bool) (db.PaymentRequest, bool) {
+	request, valid := server.requestPaymentRequest(ctx)
+	if !valid {
+		return request, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if byPayer && request.Payer != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errNotPayer))
+		return request, false
+	}
+	if !byPayer && request.Requester != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errNotRequester))
+		return request, false
+	}
+
+	switch request.Status {
+	case db.PaymentRequestPending:
+		return request, true
+	case db.PaymentRequestExpired:
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrPaymentRequestExpired))
+		return request, false
+	default:
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrPaymentRequestClosed))
+		return request, false
+	}
+}
+
+func (server *Server) requestPaymentRequest(ctx *gin.Context) (db.PaymentRequest, bool) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.PaymentRequest{}, false
+	}
+
+	request, err := server.store.GetPaymentRequest(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return request, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return request, false
+	}
+
+	return request, true
+}
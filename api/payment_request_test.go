@@ -0,0 +1,525 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPaymentRequest(toAccount db.Account, payer string, amount int64) db.PaymentRequest {
+	return db.PaymentRequest{
+		ID:          util.RandomInt(1, 1000),
+		Requester:   toAccount.Owner,
+		Payer:       payer,
+		ToAccountID: toAccount.ID,
+		Amount:      amount,
+		Currency:    toAccount.Currency,
+		Status:      db.PaymentRequestPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+}
+
+func TestCreatePaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(requester.Username)
+	toAccount.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"payer":       payer.Username,
+				"amount":      5000,
+				"currency":    util.USD,
+				"description": "dinner",
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetUser", mock.Anything, payer.Username).Return(payer, nil)
+				storeMock.
+					On("GetAccountByOwnerAndCurrency", mock.Anything, db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    requester.Username,
+						Currency: util.USD,
+					}).
+					Return(toAccount, nil)
+				storeMock.
+					On("CreatePaymentRequest", mock.Anything, mock.MatchedBy(func(arg db.CreatePaymentRequestParams) bool {
+						return arg.Requester == requester.Username &&
+							arg.Payer == payer.Username &&
+							arg.ToAccountID == toAccount.ID &&
+							arg.Amount == 5000 &&
+							arg.Currency == util.USD &&
+							arg.Description == "dinner" &&
+							arg.ExpiresAt.After(time.Now())
+					})).
+					Return(randomPaymentRequest(toAccount, payer.Username, 5000), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp paymentRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PaymentRequestPending, rsp.Status)
+				require.Equal(t, payer.Username, rsp.Payer)
+				require.Equal(t, util.NewMoney(5000, util.USD), util.Money(rsp.Amount))
+			},
+		},
+		{
+			name: "OwnPayment",
+			body: gin.H{
+				"payer":    requester.Username,
+				"amount":   5000,
+				"currency": util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PayerNotFound",
+			body: gin.H{
+				"payer":    payer.Username,
+				"amount":   5000,
+				"currency": util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetUser", mock.Anything, payer.Username).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NoAccountInCurrency",
+			body: gin.H{
+				"payer":    payer.Username,
+				"amount":   5000,
+				"currency": util.EUR,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetUser", mock.Anything, payer.Username).Return(payer, nil)
+				storeMock.
+					On("GetAccountByOwnerAndCurrency", mock.Anything, mock.Anything).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: gin.H{
+				"payer":    payer.Username,
+				"amount":   -1,
+				"currency": util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+
+			request, err := http.NewRequest(http.MethodPost, "/payment_requests", &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, requester.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAcceptPaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(requester.Username)
+	toAccount.Currency = util.USD
+	fromAccount := randomAccount(payer.Username)
+	fromAccount.Currency = util.USD
+	paymentRequest := randomPaymentRequest(toAccount, payer.Username, 5000)
+
+	accepted := paymentRequest
+	accepted.Status = db.PaymentRequestAccepted
+
+	testCases := []struct {
+		name           string
+		username       string
+		paymentRequest func() db.PaymentRequest
+		buildStubs     func(storeMock *mocks.Store)
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "OK",
+			username:       payer.Username,
+			paymentRequest: func() db.PaymentRequest { return paymentRequest },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccountByOwnerAndCurrency", mock.Anything, db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    payer.Username,
+						Currency: util.USD,
+					}).
+					Return(fromAccount, nil)
+				storeMock.
+					On("AcceptPaymentRequestTx", mock.Anything, db.AcceptPaymentRequestTxParams{
+						ID:            paymentRequest.ID,
+						FromAccountID: fromAccount.ID,
+					}).
+					Return(db.AcceptPaymentRequestTxResult{
+						PaymentRequest: accepted,
+						TransferTxResult: db.TransferTxResult{
+							Transfer:    db.Transfer{ID: 7, Amount: 5000, ToAmount: 5000},
+							FromAccount: fromAccount,
+							ToAccount:   toAccount,
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp acceptPaymentRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PaymentRequestAccepted, rsp.PaymentRequest.Status)
+				require.Equal(t, int64(7), rsp.Transfer.ID)
+			},
+		},
+		{
+			name:           "Requester",
+			username:       requester.Username,
+			paymentRequest: func() db.PaymentRequest { return paymentRequest },
+			buildStubs:     func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:           "AlreadyClosed",
+			username:       payer.Username,
+			paymentRequest: func() db.PaymentRequest { return accepted },
+			buildStubs:     func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "Expired",
+			username: payer.Username,
+			paymentRequest: func() db.PaymentRequest {
+				expired := paymentRequest
+				expired.Status = db.PaymentRequestExpired
+				return expired
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:           "NoAccountInCurrency",
+			username:       payer.Username,
+			paymentRequest: func() db.PaymentRequest { return paymentRequest },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccountByOwnerAndCurrency", mock.Anything, mock.Anything).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:           "NeedsApproval",
+			username:       payer.Username,
+			paymentRequest: func() db.PaymentRequest { return paymentRequest },
+			buildStubs: func(storeMock *mocks.Store) {
+				threshold := int64(1000)
+				guarded := fromAccount
+				guarded.ApprovalThreshold = &threshold
+
+				storeMock.On("GetAccountByOwnerAndCurrency", mock.Anything, mock.Anything).Return(guarded, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errTransferNeedsApproval.Error())
+			},
+		},
+		{
+			name:           "InsufficientFunds",
+			username:       payer.Username,
+			paymentRequest: func() db.PaymentRequest { return paymentRequest },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccountByOwnerAndCurrency", mock.Anything, db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    payer.Username,
+						Currency: util.USD,
+					}).
+					Return(fromAccount, nil)
+				storeMock.
+					On("AcceptPaymentRequestTx", mock.Anything, mock.Anything).
+					Return(db.AcceptPaymentRequestTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:           "ClosedConcurrently",
+			username:       payer.Username,
+			paymentRequest: func() db.PaymentRequest { return paymentRequest },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccountByOwnerAndCurrency", mock.Anything, db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    payer.Username,
+						Currency: util.USD,
+					}).
+					Return(fromAccount, nil)
+				storeMock.
+					On("AcceptPaymentRequestTx", mock.Anything, mock.Anything).
+					Return(db.AcceptPaymentRequestTxResult{}, db.ErrPaymentRequestClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetPaymentRequest", mock.Anything, paymentRequest.ID).Return(tc.paymentRequest(), nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/payment_requests/%d/accept", paymentRequest.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestClosePaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(requester.Username)
+	paymentRequest := randomPaymentRequest(toAccount, payer.Username, 5000)
+
+	testCases := []struct {
+		name          string
+		action        string
+		username      string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Decline",
+			action:   "decline",
+			username: payer.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				declined := paymentRequest
+				declined.Status = db.PaymentRequestDeclined
+				storeMock.
+					On("ClosePaymentRequest", mock.Anything, db.ClosePaymentRequestParams{
+						ID:     paymentRequest.ID,
+						Status: db.PaymentRequestDeclined,
+					}).
+					Return(declined, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp paymentRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PaymentRequestDeclined, rsp.Status)
+			},
+		},
+		{
+			name:       "DeclineByRequester",
+			action:     "decline",
+			username:   requester.Username,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Cancel",
+			action:   "cancel",
+			username: requester.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				cancelled := paymentRequest
+				cancelled.Status = db.PaymentRequestCancelled
+				storeMock.
+					On("ClosePaymentRequest", mock.Anything, db.ClosePaymentRequestParams{
+						ID:     paymentRequest.ID,
+						Status: db.PaymentRequestCancelled,
+					}).
+					Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp paymentRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PaymentRequestCancelled, rsp.Status)
+			},
+		},
+		{
+			name:       "CancelByPayer",
+			action:     "cancel",
+			username:   payer.Username,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "ClosedConcurrently",
+			action:   "cancel",
+			username: requester.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ClosePaymentRequest", mock.Anything, mock.Anything).
+					Return(db.PaymentRequest{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetPaymentRequest", mock.Anything, paymentRequest.ID).Return(paymentRequest, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/payment_requests/%d/%s", paymentRequest.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetPaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	paymentRequest := randomPaymentRequest(randomAccount(requester.Username), payer.Username, 5000)
+
+	for username, code := range map[string]int{
+		requester.Username: http.StatusOK,
+		payer.Username:     http.StatusOK,
+		other.Username:     http.StatusUnauthorized,
+	} {
+		storeMock := mocks.NewStore(t)
+		storeMock.On("GetPaymentRequest", mock.Anything, paymentRequest.ID).Return(paymentRequest, nil)
+
+		server := newTestServer(t, storeMock)
+		recorder := httptest.NewRecorder()
+
+		url := fmt.Sprintf("/payment_requests/%d", paymentRequest.ID)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, code, recorder.Code)
+	}
+}
+
+func TestListPaymentRequestsAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(requester.Username)
+
+	pageSize := 5
+	requests := make([]db.PaymentRequest, pageSize+1)
+	for i := range requests {
+		requests[i] = randomPaymentRequest(toAccount, payer.Username, 5000)
+		requests[i].ID = int64(i + 1)
+	}
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListPaymentRequestsAfter", mock.Anything, db.ListPaymentRequestsAfterParams{
+			Payer: &payer.Username,
+			Limit: int32(pageSize + 1),
+		}).
+		Return(requests, nil)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/payment_requests?direction=incoming&page_size=%d", pageSize)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, payer.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listPaymentRequestsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.PaymentRequests, pageSize)
+	require.NotEmpty(t, rsp.NextCursor)
+
+	// the cursor cannot switch to the outgoing requests
+	recorder = httptest.NewRecorder()
+	url = fmt.Sprintf("/payment_requests?direction=outgoing&page_size=%d&cursor=%s", pageSize, rsp.NextCursor)
+	request, err = http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, payer.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
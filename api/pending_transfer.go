@@ -0,0 +1,364 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPendingTransferDuration = 24 * time.Hour
+
+var (
+	errTransferNeedsApproval  = errors.New("amount is above the approval threshold of the account")
+	errApprovalWithQuote      = errors.New("transfers above the approval threshold cannot use an exchange quote")
+	errNotApprover            = errors.New("not an approver of the account")
+	errApproveOwnTransfer     = errors.New("cannot approve or reject a transfer you requested")
+	errPendingTransferDecided = errors.New("pending transfer was already approved or rejected")
+)
+
+// needsApproval reports whether transferring amount from account must wait
+// for an approver.
+func needsApproval(account db.Account, amount int64) bool {
+	return account.ApprovalThreshold != nil && amount > *account.ApprovalThreshold
+}
+
+type pendingTransferResponse struct {
+	ID                int64               `json:"id"`
+	RequestedBy       string              `json:"requested_by"`
+	FromAccountID     int64               `json:"from_account_id"`
+	ToAccountID       int64               `json:"to_account_id,omitempty"`
+	Amount            util.FormattedMoney `json:"amount"`
+	Description       string              `json:"description"`
+	ExternalReference *string             `json:"external_reference"`
+	Metadata          json.RawMessage     `json:"metadata"`
+	Status            string              `json:"status"`
+	DecidedBy         *string             `json:"decided_by"`
+	DecidedAt         *time.Time          `json:"decided_at"`
+	TransferID        *int64              `json:"transfer_id"`
+	ExpiresAt         time.Time           `json:"expires_at"`
+	CreatedAt         time.Time           `json:"created_at"`
+}
+
+// newPendingTransferResponse leaves out the to account when payload cannot
+// read it, as for transfers.
+func newPendingTransferResponse(pending db.PendingTransfer, payload *token.Payload, toAccount db.Account) pendingTransferResponse {
+	rsp := pendingTransferResponse{
+		ID:                pending.ID,
+		RequestedBy:       pending.RequestedBy,
+		FromAccountID:     pending.FromAccountID,
+		ToAccountID:       pending.ToAccountID,
+		Amount:            util.NewMoney(pending.Amount, pending.Currency).Formatted(),
+		Description:       pending.Description,
+		ExternalReference: pending.ExternalReference,
+		Metadata:          pending.Metadata,
+		Status:            pending.Status,
+		DecidedBy:         pending.DecidedBy,
+		DecidedAt:         pending.DecidedAt,
+		TransferID:        pending.TransferID,
+		ExpiresAt:         pending.ExpiresAt,
+		CreatedAt:         pending.CreatedAt,
+	}
+	if !canReadAccount(payload, toAccount) {
+		rsp.ToAccountID = 0
+	}
+	return rsp
+}
+
+// pendingTransferResponse loads the to account of pending to build its
+// response. accounts caches the accounts already loaded.
+func (server *Server) pendingTransferResponse(ctx *gin.Context, pending db.PendingTransfer, accounts map[int64]db.Account) (pendingTransferResponse, bool) {
+	toAccount, ok := accounts[pending.ToAccountID]
+	if !ok {
+		var err error
+		toAccount, err = server.store.GetAccount(ctx, pending.ToAccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return pendingTransferResponse{}, false
+		}
+		accounts[toAccount.ID] = toAccount
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	return newPendingTransferResponse(pending, authPayload, toAccount), true
+}
+
+// createPendingTransfer holds a transfer above the approval threshold of its
+// from account until an approver approves it.
+func (server *Server) createPendingTransfer(ctx *gin.Context, authPayload *token.Payload, toAccount db.Account, currency string, arg db.TransferTxParams) {
+	duration := server.config.PendingTransferDuration
+	if duration <= 0 {
+		duration = defaultPendingTransferDuration
+	}
+
+	pending, err := server.store.CreatePendingTransferTx(ctx, db.CreatePendingTransferTxParams{
+		CreatePendingTransferParams: db.CreatePendingTransferParams{
+			RequestedBy:       authPayload.Username,
+			FromAccountID:     arg.FromAccountID,
+			ToAccountID:       arg.ToAccountID,
+			Amount:            arg.Amount,
+			Currency:          currency,
+			Description:       arg.Description,
+			ExternalReference: arg.ExternalReference,
+			Metadata:          arg.Metadata,
+			ExpiresAt:         time.Now().Add(duration),
+		},
+		Idempotency:    arg.Idempotency,
+		RiskDecisionID: arg.RiskDecisionID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newPendingTransferResponse(pending, authPayload, toAccount))
+}
+
+type listPendingTransfersRequest struct {
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listPendingTransfersResponse struct {
+	PendingTransfers []pendingTransferResponse `json:"pending_transfers"`
+	NextCursor       string                    `json:"next_cursor,omitempty"`
+}
+
+type pendingTransferCursor struct {
+	Username string `json:"username"`
+	AfterID  int64  `json:"after_id"`
+}
+
+// listPendingTransfers pages through the transfers waiting for approval that
+// the authenticated user requested or may approve.
+func (server *Server) listPendingTransfers(ctx *gin.Context) {
+	var req listPendingTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	position := pendingTransferCursor{Username: authPayload.Username}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || position.Username != authPayload.Username {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	pendings, err := server.store.ListPendingTransfersAfter(ctx, db.ListPendingTransfersAfterParams{
+		Username: position.Username,
+		AfterID:  position.AfterID,
+		Limit:    req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listPendingTransfersResponse{PendingTransfers: make([]pendingTransferResponse, 0, len(pendings))}
+	accounts := make(map[int64]db.Account)
+	for _, pending := range pendings {
+		pendingRsp, valid := server.pendingTransferResponse(ctx, pending, accounts)
+		if !valid {
+			return
+		}
+		rsp.PendingTransfers = append(rsp.PendingTransfers, pendingRsp)
+	}
+	if len(pendings) > int(req.PageSize) {
+		rsp.PendingTransfers = rsp.PendingTransfers[:req.PageSize]
+		position.AfterID = rsp.PendingTransfers[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// getPendingTransfer shows a pending transfer to the user who requested it
+// and to the approvers of its from account.
+func (server *Server) getPendingTransfer(ctx *gin.Context) {
+	pending, valid := server.requestPendingTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if pending.RequestedBy != authPayload.Username {
+		if !server.checkApprover(ctx, pending, authPayload) {
+			return
+		}
+	}
+
+	rsp, valid := server.pendingTransferResponse(ctx, pending, make(map[int64]db.Account))
+	if !valid {
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type approvePendingTransferResponse struct {
+	PendingTransfer pendingTransferResponse `json:"pending_transfer"`
+	Transfer        transferResponse        `json:"transfer"`
+}
+
+// approvePendingTransfer executes a pending transfer. The approver must be
+// one of the account's approvers and cannot be the user who requested it.
+func (server *Server) approvePendingTransfer(ctx *gin.Context) {
+	pending, valid := server.decidablePendingTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.ApprovePendingTransferTx(ctx, db.ApprovePendingTransferTxParams{
+		ID:         pending.ID,
+		ApprovedBy: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, util.ErrAmountOverflow) ||
+			errors.Is(err, db.ErrPendingTransferExpired) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrPendingTransferDecided) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := approvePendingTransferResponse{
+		PendingTransfer: newPendingTransferResponse(result.PendingTransfer, authPayload, result.ToAccount),
+		Transfer:        newTransferResponse(result.Transfer, result.FromAccount.Currency, result.ToAccount.Currency),
+	}
+	if !canReadAccount(authPayload, result.ToAccount) {
+		rsp.Transfer.ToAccountID = 0
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// rejectPendingTransfer drops a pending transfer without moving any money.
+func (server *Server) rejectPendingTransfer(ctx *gin.Context) {
+	pending, valid := server.decidablePendingTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	rejected, err := server.store.DecidePendingTransfer(ctx, db.DecidePendingTransferParams{
+		ID:        pending.ID,
+		Status:    db.PendingTransferRejected,
+		DecidedBy: authPayload.Username,
+	})
+	if err != nil {
+		// another approver, or the expiry, got there first
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errPendingTransferDecided))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, valid := server.pendingTransferResponse(ctx, rejected, make(map[int64]db.Account))
+	if !valid {
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// decidablePendingTransfer returns the pending transfer of the request when
+// the authenticated user may approve or reject it, and it is still waiting
+// for a decision.
+func (server *Server) decidablePendingTransfer(ctx *gin.Context) (db.PendingTransfer, bool) {
+	pending, valid := server.requestPendingTransfer(ctx)
+	if !valid {
+		return pending, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if pending.RequestedBy == authPayload.Username {
+		ctx.JSON(http.StatusForbidden, errorResponse(errApproveOwnTransfer))
+		return pending, false
+	}
+
+	if !server.checkApprover(ctx, pending, authPayload) {
+		return pending, false
+	}
+
+	if pending.Status != db.PendingTransferPendingApproval {
+		ctx.JSON(http.StatusConflict, errorResponse(errPendingTransferDecided))
+		return pending, false
+	}
+
+	if !time.Now().Before(pending.ExpiresAt) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrPendingTransferExpired))
+		return pending, false
+	}
+
+	return pending, true
+}
+
+func (server *Server) requestPendingTransfer(ctx *gin.Context) (db.PendingTransfer, bool) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.PendingTransfer{}, false
+	}
+
+	pending, err := server.store.GetPendingTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return pending, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return pending, false
+	}
+
+	return pending, true
+}
+
+// checkApprover writes a 401 when the authenticated user is not an approver
+// of the from account of pending.
+func (server *Server) checkApprover(ctx *gin.Context, pending db.PendingTransfer, authPayload *token.Payload) bool {
+	return server.checkAccountApprover(ctx, pending.FromAccountID, authPayload)
+}
+
+// checkAccountApprover writes a 401 when the authenticated user is not an
+// approver of the account.
+func (server *Server) checkAccountApprover(ctx *gin.Context, accountID int64, authPayload *token.Payload) bool {
+	_, err := server.store.GetAccountApprover(ctx, db.GetAccountApproverParams{
+		AccountID: accountID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errNotApprover))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
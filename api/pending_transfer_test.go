@@ -0,0 +1,424 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPendingTransfer(requestedBy string, fromAccount db.Account, toAccount db.Account, amount int64) db.PendingTransfer {
+	return db.PendingTransfer{
+		ID:            util.RandomInt(1, 1000),
+		RequestedBy:   requestedBy,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Currency:      fromAccount.Currency,
+		Metadata:      json.RawMessage(`{}`),
+		Status:        db.PendingTransferPendingApproval,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+}
+
+func TestCreateTransferNeedsApprovalAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	threshold := int64(1000)
+	fromAccount := randomAccount(owner.Username)
+	fromAccount.Currency = util.USD
+	fromAccount.ApprovalThreshold = &threshold
+	toAccount := randomAccount(recipient.Username)
+	toAccount.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		quoteID       string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "AboveThreshold",
+			amount: threshold + 1,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreatePendingTransferTx", mock.Anything, mock.MatchedBy(func(arg db.CreatePendingTransferTxParams) bool {
+						return arg.RequestedBy == owner.Username &&
+							arg.FromAccountID == fromAccount.ID &&
+							arg.ToAccountID == toAccount.ID &&
+							arg.Amount == threshold+1 &&
+							arg.Currency == util.USD &&
+							arg.ExpiresAt.After(time.Now())
+					})).
+					Return(randomPendingTransfer(owner.Username, fromAccount, toAccount, threshold+1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var rsp pendingTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PendingTransferPendingApproval, rsp.Status)
+				require.Equal(t, util.NewMoney(threshold+1, util.USD), util.Money(rsp.Amount))
+				// the account of the recipient is not shown to the sender
+				require.Zero(t, rsp.ToAccountID)
+			},
+		},
+		{
+			name:   "AtThreshold",
+			amount: threshold,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{FromAccount: fromAccount, ToAccount: toAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "WithQuote",
+			amount:  threshold + 1,
+			quoteID: uuid.NewString(),
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetExchangeQuote", mock.Anything, mock.Anything).
+					Return(db.ExchangeQuote{
+						Username:      owner.Username,
+						BaseCurrency:  util.USD,
+						QuoteCurrency: util.USD,
+						ExpiresAt:     time.Now().Add(time.Minute),
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errApprovalWithQuote.Error())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+			storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			requestBody := gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			}
+			if tc.quoteID != "" {
+				requestBody["quote_id"] = tc.quoteID
+			}
+
+			var body bytes.Buffer
+			require.NoError(t, json.NewEncoder(&body).Encode(requestBody))
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, owner.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestApprovePendingTransferAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(owner.Username)
+	toAccount := randomAccount(recipient.Username)
+	toAccount.Currency = fromAccount.Currency
+	pending := randomPendingTransfer(owner.Username, fromAccount, toAccount, 5000)
+
+	approved := pending
+	approved.Status = db.PendingTransferApproved
+	approved.DecidedBy = &approver.Username
+
+	testCases := []struct {
+		name          string
+		username      string
+		pending       func() db.PendingTransfer
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: approver.Username,
+			pending:  func() db.PendingTransfer { return pending },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ApprovePendingTransferTx", mock.Anything, db.ApprovePendingTransferTxParams{
+						ID:         pending.ID,
+						ApprovedBy: approver.Username,
+					}).
+					Return(db.ApprovePendingTransferTxResult{
+						PendingTransfer: approved,
+						TransferTxResult: db.TransferTxResult{
+							Transfer:    db.Transfer{ID: 7, Amount: pending.Amount, ToAmount: pending.Amount},
+							FromAccount: fromAccount,
+							ToAccount:   toAccount,
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp approvePendingTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PendingTransferApproved, rsp.PendingTransfer.Status)
+				require.Equal(t, approver.Username, *rsp.PendingTransfer.DecidedBy)
+				require.Equal(t, int64(7), rsp.Transfer.ID)
+			},
+		},
+		{
+			name:       "Requester",
+			username:   owner.Username,
+			pending:    func() db.PendingTransfer { return pending },
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotApprover",
+			username: recipient.Username,
+			pending:  func() db.PendingTransfer { return pending },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccountApprover", mock.Anything, db.GetAccountApproverParams{
+						AccountID: fromAccount.ID,
+						Username:  recipient.Username,
+					}).
+					Return(db.AccountApprover{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "AlreadyDecided",
+			username:   approver.Username,
+			pending:    func() db.PendingTransfer { return approved },
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "Expired",
+			username: approver.Username,
+			pending: func() db.PendingTransfer {
+				expired := pending
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				return expired
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: approver.Username,
+			pending:  func() db.PendingTransfer { return pending },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ApprovePendingTransferTx", mock.Anything, mock.Anything).
+					Return(db.ApprovePendingTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "DecidedConcurrently",
+			username: approver.Username,
+			pending:  func() db.PendingTransfer { return pending },
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ApprovePendingTransferTx", mock.Anything, mock.Anything).
+					Return(db.ApprovePendingTransferTxResult{}, db.ErrPendingTransferDecided)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetPendingTransfer", mock.Anything, pending.ID).Return(tc.pending(), nil)
+			storeMock.
+				On("GetAccountApprover", mock.Anything, db.GetAccountApproverParams{
+					AccountID: fromAccount.ID,
+					Username:  approver.Username,
+				}).
+				Return(db.AccountApprover{AccountID: fromAccount.ID, Username: approver.Username}, nil).
+				Maybe()
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/pending_transfers/%d/approve", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRejectPendingTransferAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+
+	fromAccount := randomAccount(owner.Username)
+	toAccount := randomAccount(approver.Username)
+	pending := randomPendingTransfer(owner.Username, fromAccount, toAccount, 5000)
+
+	rejected := pending
+	rejected.Status = db.PendingTransferRejected
+	rejected.DecidedBy = &approver.Username
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("DecidePendingTransfer", mock.Anything, db.DecidePendingTransferParams{
+						ID:        pending.ID,
+						Status:    db.PendingTransferRejected,
+						DecidedBy: approver.Username,
+					}).
+					Return(rejected, nil)
+				storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp pendingTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.PendingTransferRejected, rsp.Status)
+				require.Nil(t, rsp.TransferID)
+				require.Equal(t, toAccount.ID, rsp.ToAccountID)
+			},
+		},
+		{
+			name: "DecidedConcurrently",
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("DecidePendingTransfer", mock.Anything, mock.Anything).
+					Return(db.PendingTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetPendingTransfer", mock.Anything, pending.ID).Return(pending, nil)
+			storeMock.
+				On("GetAccountApprover", mock.Anything, mock.Anything).
+				Return(db.AccountApprover{AccountID: fromAccount.ID, Username: approver.Username}, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/pending_transfers/%d/reject", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, approver.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListPendingTransfersAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	approver, _ := randomUser(t)
+
+	fromAccount := randomAccount(owner.Username)
+	toAccount := randomAccount(approver.Username)
+
+	pageSize := 5
+	pendings := make([]db.PendingTransfer, pageSize+1)
+	for i := range pendings {
+		pendings[i] = randomPendingTransfer(owner.Username, fromAccount, toAccount, 5000)
+		pendings[i].ID = int64(i + 1)
+	}
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListPendingTransfersAfter", mock.Anything, db.ListPendingTransfersAfterParams{
+			Username: approver.Username,
+			Limit:    int32(pageSize + 1),
+		}).
+		Return(pendings, nil)
+	storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil).Once()
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/pending_transfers?page_size=%d", pageSize)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, approver.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listPendingTransfersResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.PendingTransfers, pageSize)
+	require.Equal(t, toAccount.ID, rsp.PendingTransfers[0].ToAccountID)
+	require.NotEmpty(t, rsp.NextCursor)
+
+	// a cursor of another user is rejected
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, url+"&cursor="+rsp.NextCursor, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, owner.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+)
+
+type permission int
+
+const (
+	readAnyAccount permission = iota
+	listAnyAccounts
+	moveCashForAnyAccount
+	reverseAnyTransfer
+	manageTransferFees
+)
+
+// rolePermissions lists what each role may do beyond acting on its own
+// resources. Depositors only have access to what they own.
+var rolePermissions = map[string][]permission{
+	util.DepositorRole: {},
+	util.BankerRole:    {readAnyAccount, listAnyAccounts, moveCashForAnyAccount},
+	util.AdminRole:     {readAnyAccount, listAnyAccounts, moveCashForAnyAccount, reverseAnyTransfer, manageTransferFees},
+}
+
+func hasPermission(payload *token.Payload, perm permission) bool {
+	for _, p := range rolePermissions[payload.Role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func canReadAccount(payload *token.Payload, account db.Account) bool {
+	return account.Owner == payload.Username || hasPermission(payload, readAnyAccount)
+}
+
+// canReadTransfer reports whether payload may see a transfer between
+// fromAccount and toAccount, i.e. whether it may read either account.
+func canReadTransfer(payload *token.Payload, fromAccount db.Account, toAccount db.Account) bool {
+	return canReadAccount(payload, fromAccount) || canReadAccount(payload, toAccount)
+}
+
+func canListAccounts(payload *token.Payload, owner string) bool {
+	return owner == payload.Username || hasPermission(payload, listAnyAccounts)
+}
+
+// canDeposit reports whether payload may deposit cash to account. Only staff
+// handle deposits, as the money comes from the cash account of the bank.
+func canDeposit(payload *token.Payload, account db.Account) bool {
+	return hasPermission(payload, moveCashForAnyAccount)
+}
+
+// canWithdraw reports whether payload may withdraw cash from account.
+// Depositors may only withdraw from their own accounts.
+func canWithdraw(payload *token.Payload, account db.Account) bool {
+	return account.Owner == payload.Username || hasPermission(payload, moveCashForAnyAccount)
+}
+
+// canReverseTransfer reports whether payload may refund a transfer received by
+// toAccount. Only the recipient gives money back, unless an admin steps in.
+func canReverseTransfer(payload *token.Payload, toAccount db.Account) bool {
+	return toAccount.Owner == payload.Username || hasPermission(payload, reverseAnyTransfer)
+}
+
+// canManageTransferFees reports whether payload may change the fee schedule.
+func canManageTransferFees(payload *token.Payload) bool {
+	return hasPermission(payload, manageTransferFees)
+}
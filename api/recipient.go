@@ -0,0 +1,91 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simple_bank/db/models"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errRecipientNotFound is returned both for an unknown recipient and for a
+// recipient without an account in the currency, so that the error does not
+// tell whether someone is a customer.
+var errRecipientNotFound = errors.New("recipient not found")
+
+// recipientAccount resolves the account in currency of the user whose
+// username or email is recipient.
+func (server *Server) recipientAccount(ctx *gin.Context, recipient string, currency string) (db.User, db.Account, bool) {
+	var user db.User
+	var err error
+	if strings.Contains(recipient, "@") {
+		user, err = server.store.GetUserByEmail(ctx, recipient)
+	} else {
+		user, err = server.store.GetUser(ctx, recipient)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecipientNotFound))
+			return user, db.Account{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, db.Account{}, false
+	}
+
+	account, err := server.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    user.Username,
+		Currency: currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecipientNotFound))
+			return user, account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, account, false
+	}
+
+	return user, account, true
+}
+
+// maskName keeps the initial of each word of name, e.g. "John Smith" becomes
+// "J*** S***", which is enough for a sender to recognize who they paid.
+func maskName(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "***"
+	}
+
+	for i, word := range words {
+		initial, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(initial) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// recipientTransferTxResponse is returned for transfers to a username or
+// email. It leaves out the recipient's account, so that paying someone does
+// not disclose their account id or balance.
+type recipientTransferTxResponse struct {
+	Transfer      transferResponse `json:"transfer"`
+	FromAccount   accountResponse  `json:"from_account"`
+	FromEntry     db.Entry         `json:"from_entry"`
+	Fee           *feeResponse     `json:"fee,omitempty"`
+	RecipientName string           `json:"recipient_name"`
+}
+
+func newRecipientTransferTxResponse(result db.TransferTxResult, recipient db.User) recipientTransferTxResponse {
+	transfer := newTransferResponse(result.Transfer, result.FromAccount.Currency, result.ToAccount.Currency)
+	transfer.ToAccountID = 0
+
+	return recipientTransferTxResponse{
+		Transfer:      transfer,
+		FromAccount:   newAccountResponse(result.FromAccount),
+		FromEntry:     result.FromEntry,
+		Fee:           newFeeResponse(result.Fee),
+		RecipientName: maskName(recipient.FullName),
+	}
+}
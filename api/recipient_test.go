@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransferToRecipientAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	recipient.FullName = "Jane Doe"
+
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	ownerCurrency := db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    recipient.Username,
+		Currency: util.USD,
+	}
+	transferResult := db.TransferTxResult{
+		Transfer: db.Transfer{
+			ID:            1,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        10,
+			ToAmount:      10,
+		},
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+	}
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "ByUsername",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"recipient":       recipient.Username,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+				storeMock.On("GetUser", mock.Anything, recipient.Username).Return(recipient, nil)
+				storeMock.On("GetAccountByOwnerAndCurrency", mock.Anything, ownerCurrency).Return(toAccount, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, db.TransferTxParams{
+						FromAccountID: fromAccount.ID,
+						ToAccountID:   toAccount.ID,
+						Amount:        10,
+					}).
+					Return(transferResult, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "J*** D***", rsp["recipient_name"])
+				require.NotContains(t, rsp, "to_account")
+				require.NotContains(t, rsp, "to_entry")
+				require.NotContains(t, rsp["transfer"], "to_account_id")
+			},
+		},
+		{
+			name: "ByEmail",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"recipient":       recipient.Email,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+				storeMock.On("GetUserByEmail", mock.Anything, recipient.Email).Return(recipient, nil)
+				storeMock.On("GetAccountByOwnerAndCurrency", mock.Anything, ownerCurrency).Return(toAccount, nil)
+				storeMock.On("TransferTx", mock.Anything, mock.Anything).Return(transferResult, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BothRecipientAndToAccount",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"recipient":       recipient.Username,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoRecipient",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownRecipient",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"recipient":       "nobody",
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+				storeMock.On("GetUser", mock.Anything, "nobody").Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireRecipientNotFound(t, recorder)
+			},
+		},
+		{
+			name: "RecipientWithoutAccountInCurrency",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"recipient":       recipient.Username,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+				storeMock.On("GetUser", mock.Anything, recipient.Username).Return(recipient, nil)
+				storeMock.On("GetAccountByOwnerAndCurrency", mock.Anything, ownerCurrency).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireRecipientNotFound(t, recorder)
+			},
+		},
+		{
+			name: "SystemRecipient",
+			requestBody: gin.H{
+				"from_account_id": fromAccount.ID,
+				"recipient":       db.SystemUsername,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				systemUser := recipient
+				systemUser.Username = db.SystemUsername
+				cashAccount := toAccount
+				cashAccount.Owner = db.SystemUsername
+
+				storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+				storeMock.On("GetUser", mock.Anything, db.SystemUsername).Return(systemUser, nil)
+				storeMock.On("GetAccountByOwnerAndCurrency", mock.Anything, mock.Anything).Return(cashAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// requireRecipientNotFound checks that the response does not tell an
+// unknown recipient from one without an account in the currency.
+func requireRecipientNotFound(t *testing.T, recorder *httptest.ResponseRecorder) {
+	var rsp gin.H
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, gin.H{"error": errRecipientNotFound.Error()}, rsp)
+}
+
+func TestMaskName(t *testing.T) {
+	require.Equal(t, "J*** D***", maskName("Jane Doe"))
+	require.Equal(t, "É***", maskName("  Élodie "))
+	require.Equal(t, "***", maskName(""))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/token"
+	"simple_bank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type reverseTransferRequest struct {
+	// Amount refunds part of the transfer. When omitted, whatever was not
+	// refunded yet is.
+	Amount      json.RawMessage `json:"amount,omitempty"`
+	Description string          `json:"description,omitempty" binding:"max=255"`
+}
+
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body is optional, as a full refund needs no parameters
+	var req reverseTransferRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	original, err := server.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, original.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canReverseTransfer(authPayload, toAccount) {
+		err := errors.New("only the recipient of a transfer can reverse it")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	fromAccount, err := server.store.GetAccount(ctx, original.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if db.IsSystemUsername(fromAccount.Owner) || db.IsSystemUsername(toAccount.Owner) {
+		err := errors.New("cannot reverse a transfer of a system account")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	arg := db.ReverseTransferTxParams{
+		TransferID:  original.ID,
+		Description: req.Description,
+	}
+
+	if len(req.Amount) > 0 {
+		amount, valid := requestAmount(ctx, req.Amount, toAccount.Currency)
+		if !valid {
+			return
+		}
+		arg.Amount = amount.Amount
+	}
+
+	// a refund moves money out of the account of the recipient like any
+	// other transfer, so it is held to the same threshold
+	if toAccount.ApprovalThreshold != nil {
+		amount := arg.Amount
+		if amount == 0 {
+			reversed, err := server.store.GetReversedAmount(ctx, original.ID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			amount = original.Amount - reversed
+		}
+
+		if needsApproval(toAccount, amount) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errTransferNeedsApproval))
+			return
+		}
+	}
+
+	var valid bool
+	arg.Idempotency, valid = idempotencyKey(ctx, authPayload.Username, struct {
+		TransferID int64 `json:"transfer_id"`
+		reverseTransferRequest
+	}{original.ID, req})
+	if !valid {
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, util.ErrAmountOverflow) ||
+			errors.Is(err, db.ErrReversalExceedsTransfer) ||
+			errors.Is(err, db.ErrTransferNotReversible) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferAlreadyReversed) || errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result))
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	original := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1000,
+		ToAmount:      1000,
+	}
+
+	reversal := db.TransferTxResult{
+		Transfer: db.Transfer{
+			ID:            original.ID + 1,
+			FromAccountID: toAccount.ID,
+			ToAccountID:   fromAccount.ID,
+			Amount:        250,
+			ToAmount:      250,
+			ReversalOf:    &original.ID,
+		},
+		FromAccount: toAccount,
+		ToAccount:   fromAccount,
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "PartialRefund",
+			username:    recipient.Username,
+			role:        util.DepositorRole,
+			requestBody: gin.H{"amount": "2.50"},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, db.ReverseTransferTxParams{
+						TransferID: original.ID,
+						Amount:     250,
+					}).
+					Return(reversal, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferTxResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, &original.ID, rsp.Transfer.ReversalOf)
+				require.Equal(t, util.NewMoney(250, util.USD), util.Money(rsp.Transfer.Amount))
+			},
+		},
+		{
+			name:     "FullRefund",
+			username: recipient.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, db.ReverseTransferTxParams{TransferID: original.ID}).
+					Return(reversal, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Admin",
+			username: "admin_user",
+			role:     util.AdminRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, db.ReverseTransferTxParams{TransferID: original.ID}).
+					Return(reversal, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "Sender",
+			username:   sender.Username,
+			role:       util.DepositorRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "Banker",
+			username:   "banker_user",
+			role:       util.BankerRole,
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidAmount",
+			username:    recipient.Username,
+			role:        util.DepositorRole,
+			requestBody: gin.H{"amount": "-1"},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "ExceedsTransfer",
+			username:    recipient.Username,
+			role:        util.DepositorRole,
+			requestBody: gin.H{"amount": 1001},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, db.ErrReversalExceedsTransfer)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyReversed",
+			username: recipient.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, db.ErrTransferAlreadyReversed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: recipient.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetTransfer", mock.Anything, original.ID).Return(original, nil)
+			storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+			storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil).Maybe()
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.requestBody != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.requestBody))
+			}
+
+			url := fmt.Sprintf("/transfers/%d/reverse", original.ID)
+			request, err := http.NewRequest(http.MethodPost, url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReverseTransferAPINeedsApproval(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	threshold := int64(500)
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+	toAccount.ApprovalThreshold = &threshold
+
+	original := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1000,
+		ToAmount:      1000,
+	}
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "PartialRefund",
+			requestBody: gin.H{"amount": "5.01"},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "FullRefund",
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetReversedAmount", mock.Anything, original.ID).Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			// only what is left to refund counts
+			name: "RestBelowThreshold",
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetReversedAmount", mock.Anything, original.ID).Return(int64(600), nil)
+				storeMock.
+					On("ReverseTransferTx", mock.Anything, db.ReverseTransferTxParams{TransferID: original.ID}).
+					Return(db.TransferTxResult{FromAccount: toAccount, ToAccount: fromAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetTransfer", mock.Anything, original.ID).Return(original, nil)
+			storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+			storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.requestBody != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.requestBody))
+			}
+
+			url := fmt.Sprintf("/transfers/%d/reverse", original.ID)
+			request, err := http.NewRequest(http.MethodPost, url, &body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, recipient.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReverseTransferAPINotFound(t *testing.T) {
+	user, _ := randomUser(t)
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetTransfer", mock.Anything, int64(42)).Return(db.Transfer{}, sql.ErrNoRows)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodPost, "/transfers/42/reverse", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestReverseTransferAPISystemAccount(t *testing.T) {
+	user, _ := randomUser(t)
+
+	interestAccount := randomAccount(db.InterestUsername)
+	account := randomAccount(user.Username)
+	original := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: interestAccount.ID,
+		ToAccountID:   account.ID,
+		Amount:        12,
+		ToAmount:      12,
+	}
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetTransfer", mock.Anything, original.ID).Return(original, nil)
+	storeMock.On("GetAccount", mock.Anything, account.ID).Return(account, nil)
+	storeMock.On("GetAccount", mock.Anything, interestAccount.ID).Return(interestAccount, nil)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/transfers/%d/reverse", original.ID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/risk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// riskDeniedResponse names the rule that denied a transfer, so clients can
+// tell the user why without parsing the message.
+func riskDeniedResponse(result risk.Result, denial risk.Reason) gin.H {
+	return gin.H{
+		"error":       "transfer denied: " + denial.Message,
+		"code":        denial.Code,
+		"rule":        denial.Rule,
+		"decision_id": result.DecisionID,
+	}
+}
+
+// screenTransfer runs the risk rules on a transfer by username and records
+// the decision. It writes the error response and returns false when the
+// transfer is denied. Transfers up for review go through; the returned
+// decision id is meant to be linked to them.
+func (server *Server) screenTransfer(ctx *gin.Context, username string, arg db.TransferTxParams, toAccount db.Account, currency string) (*int64, bool) {
+	if server.risk == nil {
+		return nil, true
+	}
+
+	replay, valid := server.replayedIdempotencyKey(ctx, arg.Idempotency)
+	if replay || !valid {
+		return nil, valid
+	}
+
+	result, err := server.risk.Check(ctx, riskTransfer(username, arg, toAccount, currency))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+
+	if denial := result.Denial(); denial != nil {
+		ctx.JSON(http.StatusForbidden, riskDeniedResponse(result, *denial))
+		return nil, false
+	}
+	return &result.DecisionID, true
+}
+
+// replayedIdempotencyKey reports whether idempotency was already used. A
+// retry under such a key is not screened again: it was screened the first
+// time, and the transfer replays the stored response or rejects the key.
+func (server *Server) replayedIdempotencyKey(ctx *gin.Context, idempotency *db.CreateIdempotencyKeyParams) (bool, bool) {
+	if idempotency == nil {
+		return false, true
+	}
+
+	_, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: idempotency.Username,
+		Key:      idempotency.Key,
+	})
+	if err == nil {
+		return true, true
+	}
+	if err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, false
+	}
+	return false, true
+}
+
+func riskTransfer(username string, arg db.TransferTxParams, toAccount db.Account, currency string) risk.Transfer {
+	return risk.Transfer{
+		Username:      username,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		ToOwner:       toAccount.Owner,
+		Amount:        arg.Amount,
+		Currency:      currency,
+	}
+}
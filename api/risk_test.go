@@ -0,0 +1,414 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/risk"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransferRiskAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	config := risk.Config{
+		AmountThresholds: []risk.AmountThresholdConfig{
+			{Currency: util.USD, ReviewAbove: "100.00", DenyAbove: "1000.00"},
+		},
+	}
+
+	idempotencyKey := util.RandomString(32)
+
+	testCases := []struct {
+		name           string
+		amount         int64
+		idempotencyKey string
+		buildStubs     func(storeMock *mocks.Store)
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Denied",
+			amount: 100001,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Username == user.Username && arg.Decision == db.RiskDeny
+					})).
+					Return(db.RiskDecision{ID: 7}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				var rsp struct {
+					Error      string `json:"error"`
+					Code       string `json:"code"`
+					Rule       string `json:"rule"`
+					DecisionID int64  `json:"decision_id"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, risk.CodeAmountThreshold, rsp.Code)
+				require.Equal(t, "amount_threshold", rsp.Rule)
+				require.Equal(t, int64(7), rsp.DecisionID)
+				require.NotEmpty(t, rsp.Error)
+			},
+		},
+		{
+			name:   "Review",
+			amount: 10001,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Decision == db.RiskReview
+					})).
+					Return(db.RiskDecision{ID: 8}, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.MatchedBy(func(arg db.TransferTxParams) bool {
+						return arg.RiskDecisionID != nil && *arg.RiskDecisionID == 8
+					})).
+					Return(db.TransferTxResult{FromAccount: fromAccount, ToAccount: toAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Allow",
+			amount: 100,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Decision == db.RiskAllow && string(arg.Reasons) == "[]"
+					})).
+					Return(db.RiskDecision{ID: 9}, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{FromAccount: fromAccount, ToAccount: toAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:           "FirstAttemptWithKey",
+			amount:         100001,
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetIdempotencyKey", mock.Anything, db.GetIdempotencyKeyParams{Username: user.Username, Key: idempotencyKey}).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.Anything).
+					Return(db.RiskDecision{ID: 10, Decision: db.RiskDeny}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			// the retry replays the stored response without a new decision,
+			// even though the rules would deny it now
+			name:           "Replay",
+			amount:         100001,
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetIdempotencyKey", mock.Anything, db.GetIdempotencyKeyParams{Username: user.Username, Key: idempotencyKey}).
+					Return(db.IdempotencyKey{Key: idempotencyKey, Username: user.Username}, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{FromAccount: fromAccount, ToAccount: toAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "CannotRecordDecision",
+			amount: 100,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.Anything).
+					Return(db.RiskDecision{}, errors.New("connection reset"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+			storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+
+			var err error
+			server.risk, err = risk.NewEngine(storeMock, config)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			require.NoError(t, json.NewEncoder(&body).Encode(gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			}))
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", &body)
+			require.NoError(t, err)
+			if tc.idempotencyKey != "" {
+				request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateBatchTransferRiskAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	config := risk.Config{
+		AmountThresholds: []risk.AmountThresholdConfig{
+			{Currency: util.USD, ReviewAbove: "100.00", DenyAbove: "1000.00"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		amounts       []int64
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "DeniedLeg",
+			amounts: []int64{100, 100001},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Decision == db.RiskAllow
+					})).
+					Return(db.RiskDecision{ID: 1}, nil)
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Decision == db.RiskDeny
+					})).
+					Return(db.RiskDecision{ID: 2}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				var rsp struct {
+					Error      string `json:"error"`
+					DecisionID int64  `json:"decision_id"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Contains(t, rsp.Error, "transfers[1]")
+				require.Equal(t, int64(2), rsp.DecisionID)
+			},
+		},
+		{
+			name:    "LegsLinkedToDecisions",
+			amounts: []int64{100, 10001},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Decision == db.RiskAllow
+					})).
+					Return(db.RiskDecision{ID: 1}, nil)
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Decision == db.RiskReview
+					})).
+					Return(db.RiskDecision{ID: 2}, nil)
+				storeMock.
+					On("BatchTransferTx", mock.Anything, mock.MatchedBy(func(arg db.BatchTransferTxParams) bool {
+						return len(arg.Transfers) == 2 &&
+							arg.Transfers[0].RiskDecisionID != nil && *arg.Transfers[0].RiskDecisionID == 1 &&
+							arg.Transfers[1].RiskDecisionID != nil && *arg.Transfers[1].RiskDecisionID == 2
+					})).
+					Return(db.BatchTransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+			storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+
+			var err error
+			server.risk, err = risk.NewEngine(storeMock, config)
+			require.NoError(t, err)
+
+			legs := make([]gin.H, len(tc.amounts))
+			for i, amount := range tc.amounts {
+				legs[i] = gin.H{
+					"from_account_id": fromAccount.ID,
+					"to_account_id":   toAccount.ID,
+					"amount":          amount,
+					"currency":        util.USD,
+				}
+			}
+
+			var body bytes.Buffer
+			require.NoError(t, json.NewEncoder(&body).Encode(gin.H{"transfers": legs}))
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", &body)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateScheduledTransferRiskAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+	storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+	storeMock.
+		On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+			return arg.Username == user.Username && arg.Decision == db.RiskDeny
+		})).
+		Return(db.RiskDecision{ID: 7}, nil)
+
+	server := newTestServer(t, storeMock)
+
+	var err error
+	server.risk, err = risk.NewEngine(storeMock, risk.Config{
+		AmountThresholds: []risk.AmountThresholdConfig{
+			{Currency: util.USD, DenyAbove: "1000.00"},
+		},
+	})
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	require.NoError(t, json.NewEncoder(&body).Encode(gin.H{
+		"from_account_id": fromAccount.ID,
+		"to_account_id":   toAccount.ID,
+		"amount":          100001,
+		"currency":        util.USD,
+		"schedule":        "@monthly",
+	}))
+
+	request, err := http.NewRequest(http.MethodPost, "/scheduled_transfers", &body)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestCreateBatchTransferVelocityAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+	storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+	storeMock.
+		On("CountOwnerTransfersSince", mock.Anything, mock.Anything).
+		Return(int64(0), nil)
+	storeMock.
+		On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+			return arg.Decision == db.RiskAllow
+		})).
+		Return(db.RiskDecision{ID: 1}, nil).
+		Twice()
+	storeMock.
+		On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+			return arg.Decision == db.RiskDeny
+		})).
+		Return(db.RiskDecision{ID: 2}, nil).
+		Once()
+
+	server := newTestServer(t, storeMock)
+
+	var err error
+	server.risk, err = risk.NewEngine(storeMock, risk.Config{
+		Velocity: []risk.VelocityConfig{
+			{Window: "1h", MaxTransfers: 2, Decision: db.RiskDeny},
+		},
+	})
+	require.NoError(t, err)
+
+	// none of the legs is stored yet, but the third one is over the limit
+	legs := make([]gin.H, 3)
+	for i := range legs {
+		legs[i] = gin.H{
+			"from_account_id": fromAccount.ID,
+			"to_account_id":   toAccount.ID,
+			"amount":          100,
+			"currency":        util.USD,
+		}
+	}
+
+	var body bytes.Buffer
+	require.NoError(t, json.NewEncoder(&body).Encode(gin.H{"transfers": legs}))
+
+	request, err := http.NewRequest(http.MethodPost, "/transfers/batch", &body)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "transfers[2]")
+	require.Contains(t, recorder.Body.String(), risk.CodeVelocity)
+}
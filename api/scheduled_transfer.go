@@ -0,0 +1,480 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/scheduler"
+	"simple_bank/token"
+	"simple_bank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errNoRunBeforeEnd           = errors.New("schedule has no run before end_at")
+	errScheduledTransferStopped = errors.New("scheduled transfer was cancelled")
+)
+
+type scheduledTransferResponse struct {
+	ID            int64               `json:"id"`
+	Owner         string              `json:"owner"`
+	FromAccountID int64               `json:"from_account_id"`
+	ToAccountID   int64               `json:"to_account_id"`
+	Amount        util.FormattedMoney `json:"amount"`
+	Schedule      string              `json:"schedule"`
+	StartAt       time.Time           `json:"start_at"`
+	EndAt         *time.Time          `json:"end_at"`
+	NextRunAt     time.Time           `json:"next_run_at"`
+	Status        string              `json:"status"`
+	Attempts      int32               `json:"attempts"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+func newScheduledTransferResponse(scheduled db.ScheduledTransfer) scheduledTransferResponse {
+	return scheduledTransferResponse{
+		ID:            scheduled.ID,
+		Owner:         scheduled.Owner,
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        util.NewMoney(scheduled.Amount, scheduled.Currency).Formatted(),
+		Schedule:      scheduled.Schedule,
+		StartAt:       scheduled.StartAt,
+		EndAt:         scheduled.EndAt,
+		NextRunAt:     scheduled.NextRunAt,
+		Status:        scheduled.Status,
+		Attempts:      scheduled.Attempts,
+		CreatedAt:     scheduled.CreatedAt,
+		UpdatedAt:     scheduled.UpdatedAt,
+	}
+}
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64           `json:"to_account_id" binding:"required,min=1"`
+	Amount        json.RawMessage `json:"amount" binding:"required"`
+	Currency      string          `json:"currency" binding:"required,currency"`
+	// Schedule is a cron expression such as "0 9 1 * *", a descriptor such
+	// as "@monthly", or an interval such as "@every 168h".
+	Schedule string `json:"schedule" binding:"required"`
+	// StartAt defaults to now. Intervals are counted from it.
+	StartAt *time.Time `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := requestAmount(ctx, req.Amount, req.Currency)
+	if !valid {
+		return
+	}
+
+	now := time.Now().UTC()
+	startAt := now
+	if req.StartAt != nil {
+		startAt = req.StartAt.UTC()
+	}
+
+	nextRunAt, valid := firstScheduledRun(ctx, req.Schedule, startAt, req.EndAt, now)
+	if !valid {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// scheduled runs have nobody to wait for an approver
+	if needsApproval(fromAccount, amount.Amount) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errTransferNeedsApproval))
+		return
+	}
+
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if db.IsSystemUsername(toAccount.Owner) {
+		err := errors.New("cannot transfer to a system account")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	// every run is screened again; this refuses transfers that could never
+	// go through
+	_, valid = server.screenTransfer(ctx, authPayload.Username, db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount.Amount,
+	}, toAccount, req.Currency)
+	if !valid {
+		return
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount.Amount,
+		Currency:      req.Currency,
+		Schedule:      req.Schedule,
+		StartAt:       startAt,
+		EndAt:         req.EndAt,
+		NextRunAt:     nextRunAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+// firstScheduledRun parses spec and returns its first run at or after both
+// startAt and now. It writes the error response when the schedule is invalid
+// or never runs before endAt.
+func firstScheduledRun(ctx *gin.Context, spec string, startAt time.Time, endAt *time.Time, now time.Time) (time.Time, bool) {
+	schedule, err := scheduler.Parse(spec, startAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return time.Time{}, false
+	}
+
+	nextRunAt := scheduler.FirstRun(schedule, startAt, now)
+	if nextRunAt.IsZero() || endAt != nil && nextRunAt.After(*endAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNoRunBeforeEnd))
+		return time.Time{}, false
+	}
+
+	return nextRunAt, true
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type listScheduledTransfersRequest struct {
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listScheduledTransfersResponse struct {
+	ScheduledTransfers []scheduledTransferResponse `json:"scheduled_transfers"`
+	NextCursor         string                      `json:"next_cursor,omitempty"`
+}
+
+type scheduledTransferCursor struct {
+	Owner   string `json:"owner"`
+	AfterID int64  `json:"after_id"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	position := scheduledTransferCursor{Owner: authPayload.Username}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || position.Owner != authPayload.Username {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	scheduledTransfers, err := server.store.ListScheduledTransfersAfter(ctx, db.ListScheduledTransfersAfterParams{
+		Owner:   authPayload.Username,
+		AfterID: position.AfterID,
+		Limit:   req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listScheduledTransfersResponse{
+		ScheduledTransfers: make([]scheduledTransferResponse, 0, len(scheduledTransfers)),
+	}
+	for _, scheduled := range scheduledTransfers {
+		rsp.ScheduledTransfers = append(rsp.ScheduledTransfers, newScheduledTransferResponse(scheduled))
+	}
+	if len(scheduledTransfers) > int(req.PageSize) {
+		rsp.ScheduledTransfers = rsp.ScheduledTransfers[:req.PageSize]
+		position.AfterID = rsp.ScheduledTransfers[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount   json.RawMessage `json:"amount"`
+	Schedule *string         `json:"schedule"`
+	EndAt    *time.Time      `json:"end_at"`
+	Status   string          `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+// updateScheduledTransfer changes the amount, schedule or end date, or pauses
+// and resumes a scheduled transfer. A changed schedule or a resumed transfer
+// runs next at its first occurrence from now on.
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if scheduled.Status == db.ScheduledTransferCancelled {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errScheduledTransferStopped))
+		return
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID:        scheduled.ID,
+		Amount:    scheduled.Amount,
+		Schedule:  scheduled.Schedule,
+		EndAt:     scheduled.EndAt,
+		NextRunAt: scheduled.NextRunAt,
+		Status:    scheduled.Status,
+	}
+
+	if len(req.Amount) > 0 {
+		amount, valid := requestAmount(ctx, req.Amount, scheduled.Currency)
+		if !valid {
+			return
+		}
+		arg.Amount = amount.Amount
+
+		fromAccount, valid := server.validAccount(ctx, scheduled.FromAccountID, scheduled.Currency)
+		if !valid {
+			return
+		}
+
+		// scheduled runs have nobody to wait for an approver
+		if needsApproval(fromAccount, arg.Amount) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errTransferNeedsApproval))
+			return
+		}
+
+		toAccount, valid := server.validAccount(ctx, scheduled.ToAccountID, scheduled.Currency)
+		if !valid {
+			return
+		}
+
+		_, valid = server.screenTransfer(ctx, scheduled.Owner, db.TransferTxParams{
+			FromAccountID: scheduled.FromAccountID,
+			ToAccountID:   scheduled.ToAccountID,
+			Amount:        arg.Amount,
+		}, toAccount, scheduled.Currency)
+		if !valid {
+			return
+		}
+	}
+	if req.EndAt != nil {
+		arg.EndAt = req.EndAt
+	}
+	if req.Status != "" {
+		arg.Status = req.Status
+	}
+
+	resumed := arg.Status == db.ScheduledTransferActive && scheduled.Status != db.ScheduledTransferActive
+	if req.Schedule != nil || resumed {
+		if req.Schedule != nil {
+			arg.Schedule = *req.Schedule
+		}
+
+		arg.NextRunAt, valid = firstScheduledRun(ctx, arg.Schedule, scheduled.StartAt, arg.EndAt, time.Now().UTC())
+		if !valid {
+			return
+		}
+	} else if arg.EndAt != nil && arg.NextRunAt.After(*arg.EndAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNoRunBeforeEnd))
+		return
+	}
+
+	scheduled, err := server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+// deleteScheduledTransfer cancels a scheduled transfer. It is kept with its
+// executions, but never runs again.
+func (server *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	if scheduled.Status != db.ScheduledTransferCancelled {
+		var err error
+		scheduled, err = server.store.UpdateScheduledTransfer(ctx, db.UpdateScheduledTransferParams{
+			ID:        scheduled.ID,
+			Amount:    scheduled.Amount,
+			Schedule:  scheduled.Schedule,
+			EndAt:     scheduled.EndAt,
+			NextRunAt: scheduled.NextRunAt,
+			Status:    db.ScheduledTransferCancelled,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type listScheduledTransferExecutionsRequest struct {
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+type listScheduledTransferExecutionsResponse struct {
+	Executions []db.ScheduledTransferExecution `json:"executions"`
+	NextCursor string                          `json:"next_cursor,omitempty"`
+}
+
+type executionCursor struct {
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	AfterID             int64 `json:"after_id"`
+}
+
+func (server *Server) listScheduledTransferExecutions(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listScheduledTransferExecutionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.ownScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	position := executionCursor{ScheduledTransferID: scheduled.ID}
+	if req.Cursor != "" {
+		err := server.cursors.decode(req.Cursor, &position)
+		if err != nil || position.ScheduledTransferID != scheduled.ID {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+	}
+
+	// one extra row tells whether there is a next page
+	executions, err := server.store.ListScheduledTransferExecutionsAfter(ctx, db.ListScheduledTransferExecutionsAfterParams{
+		ScheduledTransferID: scheduled.ID,
+		AfterID:             position.AfterID,
+		Limit:               req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listScheduledTransferExecutionsResponse{Executions: executions}
+	if len(executions) > int(req.PageSize) {
+		rsp.Executions = executions[:req.PageSize]
+		position.AfterID = rsp.Executions[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// ownScheduledTransfer loads a scheduled transfer of the authenticated user,
+// writing the error response otherwise.
+func (server *Server) ownScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduled, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduled, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduled, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduled.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return scheduled, false
+	}
+
+	return scheduled, true
+}
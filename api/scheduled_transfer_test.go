@@ -0,0 +1,611 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomScheduledTransfer(owner string) db.ScheduledTransfer {
+	startAt := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	return db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         owner,
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1001, 2000),
+		Amount:        util.RandomMoney(),
+		Currency:      util.USD,
+		Schedule:      "0 9 1 * *",
+		StartAt:       startAt,
+		NextRunAt:     startAt.Add(9 * time.Hour),
+		Status:        db.ScheduledTransferActive,
+	}
+}
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	startAt := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	endAt := time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "1500.00",
+				"currency":        util.USD,
+				"schedule":        "0 9 1 * *",
+				"start_at":        startAt,
+				"end_at":          endAt,
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+
+				arg := db.CreateScheduledTransferParams{
+					Owner:         user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        150000,
+					Currency:      util.USD,
+					Schedule:      "0 9 1 * *",
+					StartAt:       startAt,
+					EndAt:         &endAt,
+					NextRunAt:     startAt.Add(9 * time.Hour),
+				}
+				storeMock.
+					On("CreateScheduledTransfer", mock.Anything, mock.MatchedBy(func(got db.CreateScheduledTransferParams) bool {
+						return got.EndAt != nil && got.EndAt.Equal(endAt) &&
+							got.StartAt.Equal(arg.StartAt) && got.NextRunAt.Equal(arg.NextRunAt) &&
+							got.Owner == arg.Owner && got.FromAccountID == arg.FromAccountID &&
+							got.ToAccountID == arg.ToAccountID && got.Amount == arg.Amount &&
+							got.Currency == arg.Currency && got.Schedule == arg.Schedule
+					})).
+					Return(db.ScheduledTransfer{
+						ID:            1,
+						Owner:         arg.Owner,
+						FromAccountID: arg.FromAccountID,
+						ToAccountID:   arg.ToAccountID,
+						Amount:        arg.Amount,
+						Currency:      arg.Currency,
+						Schedule:      arg.Schedule,
+						StartAt:       arg.StartAt,
+						EndAt:         arg.EndAt,
+						NextRunAt:     arg.NextRunAt,
+						Status:        db.ScheduledTransferActive,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp scheduledTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, util.NewMoney(150000, util.USD), util.Money(rsp.Amount))
+				require.Equal(t, db.ScheduledTransferActive, rsp.Status)
+				require.True(t, startAt.Add(9*time.Hour).Equal(rsp.NextRunAt))
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"schedule":        "every monday",
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoRunBeforeEnd",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"schedule":        "0 9 1 * *",
+				"start_at":        startAt,
+				"end_at":          startAt.Add(time.Hour),
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          0,
+				"currency":        util.USD,
+				"schedule":        "@monthly",
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "FromAccountOfAnotherUser",
+			requestBody: gin.H{
+				"from_account_id": account2.ID,
+				"to_account_id":   account1.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"schedule":        "@monthly",
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountCurrencyMismatch",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"schedule":        "@monthly",
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				euroAccount := account2
+				euroAccount.Currency = util.EUR
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(euroAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ToSystemAccount",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"schedule":        "@monthly",
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				systemAccount := account2
+				systemAccount.Owner = db.InterestUsername
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(systemAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          100,
+				"currency":        util.USD,
+				"schedule":        "@monthly",
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Return(account2, nil)
+				storeMock.
+					On("CreateScheduledTransfer", mock.Anything, mock.Anything).
+					Return(db.ScheduledTransfer{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetScheduledTransfer", mock.Anything, scheduled.ID).Return(scheduled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp scheduledTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newScheduledTransferResponse(scheduled), rsp)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetScheduledTransfer", mock.Anything, scheduled.ID).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "AnotherUser",
+			username: "unauthorized_user",
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetScheduledTransfer", mock.Anything, scheduled.ID).Return(scheduled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListScheduledTransfersCursorAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	pageSize := 5
+	scheduledTransfers := make([]db.ScheduledTransfer, pageSize+1)
+	for i := range scheduledTransfers {
+		scheduledTransfers[i] = randomScheduledTransfer(user.Username)
+		scheduledTransfers[i].ID = int64(i + 1)
+	}
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListScheduledTransfersAfter", mock.Anything, db.ListScheduledTransfersAfterParams{
+			Owner:   user.Username,
+			AfterID: 0,
+			Limit:   int32(pageSize + 1),
+		}).
+		Return(scheduledTransfers, nil)
+	storeMock.
+		On("ListScheduledTransfersAfter", mock.Anything, db.ListScheduledTransfersAfterParams{
+			Owner:   user.Username,
+			AfterID: int64(pageSize),
+			Limit:   int32(pageSize + 1),
+		}).
+		Return(scheduledTransfers[pageSize:], nil)
+
+	server := newTestServer(t, storeMock)
+
+	list := func(cursor string) listScheduledTransfersResponse {
+		recorder := httptest.NewRecorder()
+		url := fmt.Sprintf("/scheduled_transfers?page_size=%d&cursor=%s", pageSize, cursor)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var rsp listScheduledTransfersResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+		return rsp
+	}
+
+	page1 := list("")
+	require.Len(t, page1.ScheduledTransfers, pageSize)
+	require.NotEmpty(t, page1.NextCursor)
+
+	page2 := list(page1.NextCursor)
+	require.Len(t, page2.ScheduledTransfers, 1)
+	require.Equal(t, int64(pageSize+1), page2.ScheduledTransfers[0].ID)
+	require.Empty(t, page2.NextCursor)
+}
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+
+	paused := scheduled
+	paused.Status = db.ScheduledTransferPaused
+
+	cancelled := scheduled
+	cancelled.Status = db.ScheduledTransferCancelled
+
+	fromAccount := randomAccount(user.Username)
+	fromAccount.ID = scheduled.FromAccountID
+	fromAccount.Currency = scheduled.Currency
+
+	toAccount := randomAccount(util.RandomOwner())
+	toAccount.ID = scheduled.ToAccountID
+	toAccount.Currency = scheduled.Currency
+
+	threshold := int64(1500)
+	approvalAccount := fromAccount
+	approvalAccount.ApprovalThreshold = &threshold
+
+	testCases := []struct {
+		name          string
+		current       db.ScheduledTransfer
+		requestBody   gin.H
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Pause",
+			current:     scheduled,
+			requestBody: gin.H{"status": db.ScheduledTransferPaused},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("UpdateScheduledTransfer", mock.Anything, db.UpdateScheduledTransferParams{
+						ID:        scheduled.ID,
+						Amount:    scheduled.Amount,
+						Schedule:  scheduled.Schedule,
+						NextRunAt: scheduled.NextRunAt,
+						Status:    db.ScheduledTransferPaused,
+					}).
+					Return(paused, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "ChangeScheduleAndAmount",
+			current: scheduled,
+			requestBody: gin.H{
+				"schedule": "0 9 15 * *",
+				"amount":   "20.00",
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, scheduled.FromAccountID).Return(fromAccount, nil)
+				storeMock.On("GetAccount", mock.Anything, scheduled.ToAccountID).Return(toAccount, nil)
+				storeMock.
+					On("UpdateScheduledTransfer", mock.Anything, db.UpdateScheduledTransferParams{
+						ID:        scheduled.ID,
+						Amount:    2000,
+						Schedule:  "0 9 15 * *",
+						NextRunAt: time.Date(2099, 1, 15, 9, 0, 0, 0, time.UTC),
+						Status:    db.ScheduledTransferActive,
+					}).
+					Return(scheduled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "AmountNeedsApproval",
+			current:     scheduled,
+			requestBody: gin.H{"amount": "20.00"},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, scheduled.FromAccountID).Return(approvalAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:        "Resume",
+			current:     paused,
+			requestBody: gin.H{"status": db.ScheduledTransferActive},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("UpdateScheduledTransfer", mock.Anything, db.UpdateScheduledTransferParams{
+						ID:        scheduled.ID,
+						Amount:    scheduled.Amount,
+						Schedule:  scheduled.Schedule,
+						NextRunAt: scheduled.NextRunAt,
+						Status:    db.ScheduledTransferActive,
+					}).
+					Return(scheduled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "EndBeforeNextRun",
+			current:     scheduled,
+			requestBody: gin.H{"end_at": scheduled.NextRunAt.Add(-time.Hour)},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidSchedule",
+			current:     scheduled,
+			requestBody: gin.H{"schedule": "0 25 * * *"},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Cancelled",
+			current:     cancelled,
+			requestBody: gin.H{"status": db.ScheduledTransferActive},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidStatus",
+			current:     scheduled,
+			requestBody: gin.H{"status": db.ScheduledTransferCompleted},
+			buildStubs:  func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetScheduledTransfer", mock.Anything, scheduled.ID).Return(tc.current, nil).Maybe()
+			tc.buildStubs(storeMock)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.requestBody)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+
+	cancelled := scheduled
+	cancelled.Status = db.ScheduledTransferCancelled
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetScheduledTransfer", mock.Anything, scheduled.ID).Return(scheduled, nil)
+	storeMock.
+		On("UpdateScheduledTransfer", mock.Anything, db.UpdateScheduledTransferParams{
+			ID:        scheduled.ID,
+			Amount:    scheduled.Amount,
+			Schedule:  scheduled.Schedule,
+			NextRunAt: scheduled.NextRunAt,
+			Status:    db.ScheduledTransferCancelled,
+		}).
+		Return(cancelled, nil)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp scheduledTransferResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, db.ScheduledTransferCancelled, rsp.Status)
+}
+
+func TestListScheduledTransferExecutionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+
+	transferID := util.RandomInt(1, 1000)
+	message := "insufficient funds"
+	executions := []db.ScheduledTransferExecution{
+		{ID: 1, ScheduledTransferID: scheduled.ID, ScheduledFor: scheduled.NextRunAt, Attempt: 1, Status: db.ExecutionFailed, Error: &message},
+		{ID: 2, ScheduledTransferID: scheduled.ID, ScheduledFor: scheduled.NextRunAt.AddDate(0, 1, 0), Attempt: 1, Status: db.ExecutionSucceeded, TransferID: &transferID},
+	}
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetScheduledTransfer", mock.Anything, scheduled.ID).Return(scheduled, nil)
+	storeMock.
+		On("ListScheduledTransferExecutionsAfter", mock.Anything, db.ListScheduledTransferExecutionsAfterParams{
+			ScheduledTransferID: scheduled.ID,
+			AfterID:             0,
+			Limit:               6,
+		}).
+		Return(executions, nil)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/scheduled_transfers/%d/executions?page_size=5", scheduled.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listScheduledTransferExecutionsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Executions, 2)
+	require.Equal(t, db.ExecutionFailed, rsp.Executions[0].Status)
+	require.Equal(t, transferID, *rsp.Executions[1].TransferID)
+	require.Empty(t, rsp.NextCursor)
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	db "simple_bank/db/models"
+	"simple_bank/risk"
 	"simple_bank/token"
 	"simple_bank/util"
 
@@ -11,51 +14,155 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+const (
+	tokenTypePaseto       = "paseto"
+	tokenTypeJWT          = "jwt"
+	tokenTypePasetoPublic = "paseto_public"
+	tokenTypeJWTEdDSA     = "jwt_eddsa"
+)
+
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config      util.Config
+	store       db.Store
+	tokenMaker  token.Maker
+	tokenKeys   *token.KeySet
+	revocations *token.RevocationStore
+	cursors     *cursorCodec
+	fxSpread    *big.Rat
+	risk        *risk.Engine
+	router      *gin.Engine
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+// NewServer sets up the routes of the API. Transfers are screened by
+// riskEngine, which is shared with the scheduler; nil screens nothing.
+func NewServer(config util.Config, store db.Store, riskEngine *risk.Engine) (*Server, error) {
+	tokenMaker, tokenKeys, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	cursors, err := newCursorCodec(config.CursorSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cursor codec: %w", err)
+	}
+
+	fxSpread, err := newFXSpread(config.FXSpread)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse fx spread: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:      config,
+		store:       store,
+		tokenMaker:  tokenMaker,
+		tokenKeys:   tokenKeys,
+		revocations: token.NewRevocationStore(store),
+		cursors:     cursors,
+		fxSpread:    fxSpread,
+		risk:        riskEngine,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("enabled_currency", validEnabledCurrency)
 	}
 
 	server.setupRouter()
 	return server, nil
 }
 
+// newTokenMaker builds the maker selected by TOKEN_TYPE. The key set is only
+// returned for asymmetric makers, whose public keys are published as JWKS.
+func newTokenMaker(config util.Config) (token.Maker, *token.KeySet, error) {
+	switch config.TokenType {
+	case "", tokenTypePaseto:
+		maker, err := token.NewPasetoMaker(config.TokenSymmetricKey, config.TokenPreviousKeys...)
+		return maker, nil, err
+	case tokenTypeJWT:
+		maker, err := token.NewJWTMaker(config.TokenSymmetricKey, config.TokenPreviousKeys...)
+		return maker, nil, err
+	case tokenTypePasetoPublic, tokenTypeJWTEdDSA:
+		keys, err := token.ParseKeySet(config.TokenActiveKeyID, config.TokenSigningKeys)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var maker token.Maker
+		if config.TokenType == tokenTypePasetoPublic {
+			maker, err = token.NewPasetoPublicMaker(keys)
+		} else {
+			maker, err = token.NewJWTEdDSAMaker(keys)
+		}
+		return maker, keys, err
+	}
+	return nil, nil, fmt.Errorf("unsupported token type %q", config.TokenType)
+}
+
 func (server *Server) setupRouter() {
 	router := gin.Default()
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.GET("/currencies", server.listCurrencies)
+
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations))
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes.POST("/users/logout", server.logoutUser)
 
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
 	authRoutes.GET("/accounts", server.listAccount)
+	authRoutes.POST("/accounts/:id/deposits", server.createDeposit)
+	authRoutes.POST("/accounts/:id/withdrawals", server.createWithdrawal)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
+	authRoutes.GET("/accounts/:id/approval_policy", server.getApprovalPolicy)
+	authRoutes.PUT("/accounts/:id/approval_policy", server.setApprovalPolicy)
+	authRoutes.GET("/accounts/:id/approval_policy_changes", server.listApprovalPolicyChanges)
 
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfers/batch", server.createBatchTransfer)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+
+	authRoutes.GET("/pending_transfers", server.listPendingTransfers)
+	authRoutes.GET("/pending_transfers/:id", server.getPendingTransfer)
+	authRoutes.POST("/pending_transfers/:id/approve", server.approvePendingTransfer)
+	authRoutes.POST("/pending_transfers/:id/reject", server.rejectPendingTransfer)
+
+	authRoutes.POST("/approval_policy_changes/:id/approve", server.approveApprovalPolicyChange)
+	authRoutes.POST("/approval_policy_changes/:id/reject", server.rejectApprovalPolicyChange)
+
+	authRoutes.POST("/payment_requests", server.createPaymentRequest)
+	authRoutes.GET("/payment_requests", server.listPaymentRequests)
+	authRoutes.GET("/payment_requests/:id", server.getPaymentRequest)
+	authRoutes.POST("/payment_requests/:id/accept", server.acceptPaymentRequest)
+	authRoutes.POST("/payment_requests/:id/decline", server.declinePaymentRequest)
+	authRoutes.POST("/payment_requests/:id/cancel", server.cancelPaymentRequest)
+
+	authRoutes.POST("/scheduled_transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id", server.getScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers", server.listScheduledTransfers)
+	authRoutes.PATCH("/scheduled_transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled_transfers/:id", server.deleteScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id/executions", server.listScheduledTransferExecutions)
+
+	authRoutes.POST("/exchange_quotes", server.createExchangeQuote)
+
+	authRoutes.GET("/transfer_fees", server.listTransferFees)
+	authRoutes.PUT("/transfer_fees/:currency/:product", server.setTransferFee)
+	authRoutes.DELETE("/transfer_fees/:currency/:product", server.deleteTransferFee)
+	authRoutes.POST("/fee_quotes", server.createFeeQuote)
 
 	server.router = router
 }
 
 func (server *Server) Start(address string) error {
+	go server.revocations.Run(context.Background(), server.config.RevocationSyncInterval)
+
 	return server.router.Run(address)
 }
 
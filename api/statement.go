@@ -0,0 +1,94 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	db "simple_bank/db/models"
+	"simple_bank/statement"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatementEntries bounds the size of an exported statement. Longer
+// periods have to be exported in several parts.
+const maxStatementEntries = 10000
+
+type getAccountStatementRequest struct {
+	Format string    `form:"format" binding:"required"`
+	From   time.Time `form:"from" binding:"required"`
+	To     time.Time `form:"to" binding:"required,gtfield=From"`
+}
+
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req getAccountStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	writer, err := statement.NewWriter(req.Format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.readableAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	balances, err := server.store.GetStatementBalances(ctx, db.GetStatementBalancesParams{
+		AccountID: account.ID,
+		FromTime:  req.From,
+		ToTime:    req.To,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	entries, err := server.store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  req.From,
+		ToTime:    req.To,
+		Limit:     maxStatementEntries + 1,
+		Offset:    0,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(entries) > maxStatementEntries {
+		err := fmt.Errorf("statement has more than %d entries, use a shorter period", maxStatementEntries)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	var buf bytes.Buffer
+	err = writer.Write(&buf, statement.Statement{
+		Account:        account,
+		From:           req.From,
+		To:             req.To,
+		OpeningBalance: balances.OpeningBalance,
+		ClosingBalance: balances.ClosingBalance,
+		Entries:        entries,
+		GeneratedAt:    time.Now(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s.%s", account.ID, req.From.UTC().Format("20060102"), writer.FileExtension())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, writer.ContentType(), buf.Bytes())
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/statement"
+	"simple_bank/token"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+
+	var total int64
+	entries := []db.ListStatementEntriesRow{
+		randomStatementEntry(account, from.Add(time.Minute), &total),
+		randomStatementEntry(account, from.Add(time.Hour), &total),
+	}
+	balances := db.GetStatementBalancesRow{ClosingBalance: total}
+
+	testCases := []struct {
+		name          string
+		format        string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(mockStore *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "CSV",
+			format: statement.FormatCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, db.GetStatementBalancesParams{
+						AccountID: account.ID,
+						FromTime:  from,
+						ToTime:    to,
+					}).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntries", mock.Anything, db.ListStatementEntriesParams{
+						AccountID: account.ID,
+						FromTime:  from,
+						ToTime:    to,
+						Limit:     maxStatementEntries + 1,
+						Offset:    0,
+					}).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), ".csv")
+				require.Contains(t, recorder.Body.String(), "booking_date,entry_id")
+			},
+		},
+		{
+			name:   "OFX",
+			format: statement.FormatOFX,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntries", mock.Anything, mock.Anything).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Body.String(), "<OFX>")
+			},
+		},
+		{
+			name:   "Camt053",
+			format: statement.FormatCamt053,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntries", mock.Anything, mock.Anything).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Body.String(), "camt.053.001.02")
+			},
+		},
+		{
+			name:   "UnsupportedFormat",
+			format: "pdf",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "UnauthorizedUser",
+			format: statement.FormatCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "TooManyEntries",
+			format: statement.FormatCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(balances, nil)
+				mockStore.
+					On("ListStatementEntries", mock.Anything, mock.Anything).
+					Return(make([]db.ListStatementEntriesRow, maxStatementEntries+1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:   "GetStatementBalancesError",
+			format: statement.FormatCSV,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(mockStore *mocks.Store) {
+				mockStore.
+					On("GetAccount", mock.Anything, account.ID).
+					Return(account, nil)
+				mockStore.
+					On("GetStatementBalances", mock.Anything, mock.Anything).
+					Return(db.GetStatementBalancesRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := mocks.NewStore(t)
+			tc.buildStubs(mockStore)
+
+			server := newTestServer(t, mockStore)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			q := request.URL.Query()
+			q.Add("format", tc.format)
+			q.Add("from", from.Format(time.RFC3339))
+			q.Add("to", to.Format(time.RFC3339))
+			request.URL.RawQuery = q.Encode()
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
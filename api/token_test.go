@@ -0,0 +1,320 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/token"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	// serverTokenMaker lets a case verify the token it was given
+	var serverTokenMaker token.Maker
+
+	testCases := []struct {
+		name          string
+		buildToken    func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		buildStubs    func(storeMock *mocks.Store, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(randomSession(payload, refreshToken), nil)
+				storeMock.
+					On("GetUser", mock.Anything, user.Username).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.NotEmpty(t, res.AccessToken)
+			},
+		},
+		{
+			name: "ReloadsRole",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				serverTokenMaker = tokenMaker
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, util.BankerRole, token.TokenTypeRefreshToken, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				demoted := user
+				demoted.Role = util.DepositorRole
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(randomSession(payload, refreshToken), nil)
+				storeMock.
+					On("GetUser", mock.Anything, user.Username).
+					Return(demoted, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+
+				payload, err := serverTokenMaker.VerifyToken(res.AccessToken, token.TokenTypeAccessToken)
+				require.NoError(t, err)
+				require.Equal(t, util.DepositorRole, payload.Role)
+			},
+		},
+		{
+			name: "AccessToken",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(user.Username, user.Role, token.TokenTypeAccessToken, time.Hour)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "invalid", nil
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, -time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.IsBlocked = true
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectSessionUser",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.Username = "other_user"
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedSessionToken",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(randomSession(payload, "other_token"), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return createRefreshToken(t, tokenMaker, user.Username, time.Hour)
+			},
+			buildStubs: func(storeMock *mocks.Store, refreshToken string, payload *token.Payload) {
+				session := randomSession(payload, refreshToken)
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+				storeMock.
+					On("GetSession", mock.Anything, payload.ID).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			refreshToken, payload := tc.buildToken(t, server.tokenMaker)
+			tc.buildStubs(storeMock, refreshToken, payload)
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			url := "/tokens/renew_access"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func createRefreshToken(t *testing.T, tokenMaker token.Maker, username string, duration time.Duration) (string, *token.Payload) {
+	refreshToken, payload, err := tokenMaker.CreateToken(username, util.DepositorRole, token.TokenTypeRefreshToken, duration)
+	require.NoError(t, err)
+	return refreshToken, payload
+}
+
+func randomSession(payload *token.Payload, refreshToken string) db.Session {
+	return db.Session{
+		ID:           payload.ID,
+		Username:     payload.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiresAt,
+	}
+}
+
+func TestGetJWKSAPI(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		config        util.Config
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AsymmetricKeys",
+			config: util.Config{
+				TokenType:        "jwt_eddsa",
+				TokenSigningKeys: "k1:" + base64.StdEncoding.EncodeToString(seed),
+				TokenActiveKeyID: "k1",
+				CursorSecretKey:  util.RandomString(32),
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var jwks token.JSONWebKeySet
+				err := json.Unmarshal(recorder.Body.Bytes(), &jwks)
+				require.NoError(t, err)
+				require.Len(t, jwks.Keys, 1)
+				require.Equal(t, "k1", jwks.Keys[0].KeyID)
+
+				x, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+				require.NoError(t, err)
+				require.Equal(t, ed25519.NewKeyFromSeed(seed).Public(), ed25519.PublicKey(x))
+			},
+		},
+		{
+			name: "SymmetricKey",
+			config: util.Config{
+				TokenType:         "paseto",
+				TokenSymmetricKey: util.RandomString(32),
+				CursorSecretKey:   util.RandomString(32),
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var jwks token.JSONWebKeySet
+				err := json.Unmarshal(recorder.Body.Bytes(), &jwks)
+				require.NoError(t, err)
+				require.Empty(t, jwks.Keys)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			server, err := NewServer(tc.config, nil, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			url := "/.well-known/jwks.json"
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestNewServerUnsupportedTokenType(t *testing.T) {
+	_, err := NewServer(util.Config{TokenType: "unknown"}, nil, nil)
+	require.Error(t, err)
+}
@@ -1,21 +1,47 @@
 package api
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	db "simple_bank/db/models"
+	"simple_bank/fx"
 	"simple_bank/token"
+	"simple_bank/util"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+	maxMetadataSize         = 4096
 )
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64           `json:"to_account_id" binding:"required_without=Recipient,excluded_with=Recipient,omitempty,min=1"`
+	Amount        json.RawMessage `json:"amount" binding:"required"`
+	Currency      string          `json:"currency" binding:"required,currency"`
+	// Recipient is the username or email of the user to pay, instead of
+	// ToAccountID. Their account in the currency of the transfer is credited.
+	Recipient string `json:"recipient,omitempty" binding:"omitempty,max=255"`
+	// QuoteID selects a cross-currency transfer. Currency is then the currency
+	// of the from account, and the to account must be in the quoted currency.
+	QuoteID           string          `json:"quote_id,omitempty" binding:"omitempty,uuid"`
+	Description       string          `json:"description,omitempty" binding:"max=255"`
+	ExternalReference string          `json:"external_reference,omitempty" binding:"max=255"`
+	Metadata          json.RawMessage `json:"metadata,omitempty"`
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -25,6 +51,11 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	amount, valid := requestAmount(ctx, req.Amount, req.Currency)
+	if !valid {
+		return
+	}
+
 	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
 	if !valid {
 		return
@@ -37,43 +68,460 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	var quote *db.ExchangeQuote
+	toCurrency := req.Currency
+	if req.QuoteID != "" {
+		validQuote, valid := server.validQuote(ctx, req.QuoteID, req.Currency)
+		if !valid {
+			return
+		}
+		quote = &validQuote
+		toCurrency = quote.QuoteCurrency
+	}
+
+	var recipient db.User
+	var toAccount db.Account
+	if req.Recipient != "" {
+		recipient, toAccount, valid = server.recipientAccount(ctx, req.Recipient, toCurrency)
+	} else {
+		toAccount, valid = server.validAccount(ctx, req.ToAccountID, toCurrency)
+	}
+	if !valid {
+		return
+	}
+
+	if db.IsSystemUsername(toAccount.Owner) {
+		err := errors.New("cannot transfer to a system account")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	metadata, valid := requestMetadata(ctx, req.Metadata)
 	if !valid {
 		return
 	}
 
 	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID:     req.FromAccountID,
+		ToAccountID:       toAccount.ID,
+		Amount:            amount.Amount,
+		Description:       req.Description,
+		ExternalReference: optionalString(req.ExternalReference),
+		Metadata:          metadata,
+	}
+
+	arg.Idempotency, valid = idempotencyKey(ctx, authPayload.Username, req)
+	if !valid {
+		return
 	}
 
-	result, err := server.store.TransferTx(ctx, arg)
+	arg.RiskDecisionID, valid = server.screenTransfer(ctx, authPayload.Username, arg, toAccount, req.Currency)
+	if !valid {
+		return
+	}
+
+	if needsApproval(fromAccount, arg.Amount) {
+		if quote != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errApprovalWithQuote))
+			return
+		}
+		server.createPendingTransfer(ctx, authPayload, toAccount, req.Currency, arg)
+		return
+	}
+
+	var result db.TransferTxResult
+	var err error
+	if quote == nil {
+		result, err = server.store.TransferTx(ctx, arg)
+	} else {
+		result, err = server.exchangeTransfer(ctx, arg, *quote)
+	}
 	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, util.ErrAmountOverflow) ||
+			errors.Is(err, errAmountTooSmall) || errors.Is(err, fx.ErrConversionOverflow) ||
+			errors.Is(err, db.ErrQuoteExpired) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrIdempotencyKeyReused) || errors.Is(err, db.ErrQuoteAlreadyUsed) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	if req.Recipient != "" {
+		ctx.JSON(http.StatusOK, newRecipientTransferTxResponse(result, recipient))
+		return
+	}
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result))
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account, err := server.store.GetAccount(ctx, accountID)
+type transferResponse struct {
+	ID                int64               `json:"id"`
+	FromAccountID     int64               `json:"from_account_id"`
+	ToAccountID       int64               `json:"to_account_id,omitempty"`
+	Amount            util.FormattedMoney `json:"amount"`
+	ToAmount          util.FormattedMoney `json:"to_amount"`
+	ExchangeRate      *string             `json:"exchange_rate"`
+	ExchangeSpread    *string             `json:"exchange_spread"`
+	QuoteID           *uuid.UUID          `json:"quote_id"`
+	ReversalOf        *int64              `json:"reversal_of"`
+	Description       string              `json:"description"`
+	ExternalReference *string             `json:"external_reference"`
+	Metadata          json.RawMessage     `json:"metadata"`
+	CreatedAt         time.Time           `json:"created_at"`
+}
+
+func newTransferResponse(transfer db.Transfer, fromCurrency string, toCurrency string) transferResponse {
+	return transferResponse{
+		ID:                transfer.ID,
+		FromAccountID:     transfer.FromAccountID,
+		ToAccountID:       transfer.ToAccountID,
+		Amount:            util.NewMoney(transfer.Amount, fromCurrency).Formatted(),
+		ToAmount:          util.NewMoney(transfer.ToAmount, toCurrency).Formatted(),
+		ExchangeRate:      transfer.ExchangeRate,
+		ExchangeSpread:    transfer.ExchangeSpread,
+		QuoteID:           transfer.QuoteID,
+		ReversalOf:        transfer.ReversalOf,
+		Description:       transfer.Description,
+		ExternalReference: transfer.ExternalReference,
+		Metadata:          transfer.Metadata,
+		CreatedAt:         transfer.CreatedAt,
+	}
+}
+
+type transferTxResponse struct {
+	Transfer        transferResponse `json:"transfer"`
+	FromAccount     accountResponse  `json:"from_account"`
+	ToAccount       accountResponse  `json:"to_account"`
+	FromEntry       db.Entry         `json:"from_entry"`
+	ToEntry         db.Entry         `json:"to_entry"`
+	ExchangeEntries []db.Entry       `json:"exchange_entries,omitempty"`
+	Fee             *feeResponse     `json:"fee,omitempty"`
+	FeeEntries      []db.Entry       `json:"fee_entries,omitempty"`
+}
+
+func newTransferTxResponse(result db.TransferTxResult) transferTxResponse {
+	return transferTxResponse{
+		Transfer:        newTransferResponse(result.Transfer, result.FromAccount.Currency, result.ToAccount.Currency),
+		FromAccount:     newAccountResponse(result.FromAccount),
+		ToAccount:       newAccountResponse(result.ToAccount),
+		FromEntry:       result.FromEntry,
+		ToEntry:         result.ToEntry,
+		ExchangeEntries: result.ExchangeEntries,
+		Fee:             newFeeResponse(result.Fee),
+		FeeEntries:      result.FeeEntries,
+	}
+}
+
+// getTransfer shows a transfer to the owners of its from and to accounts. Like
+// transfers to a recipient, it leaves out the to account for those who cannot
+// read it.
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
+			return
 		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
+	fromAccount, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canReadTransfer(authPayload, fromAccount, toAccount) {
+		err := errors.New("transfer doesn't involve an account of the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	rsp := newTransferResponse(transfer, fromAccount.Currency, toAccount.Currency)
+	if !canReadAccount(authPayload, toAccount) {
+		rsp.ToAccountID = 0
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// transferFilters are the filters of GET /transfers. They are kept as sent,
+// so that the cursor of a page can be checked against the next request.
+type transferFilters struct {
+	ExternalReference     string `form:"external_reference" json:"external_reference,omitempty" binding:"max=255"`
+	Direction             string `form:"direction" json:"direction,omitempty" binding:"omitempty,oneof=incoming outgoing"`
+	CounterpartyAccountID int64  `form:"counterparty_account_id" json:"counterparty_account_id,omitempty" binding:"omitempty,min=1"`
+	// MinAmount and MaxAmount are decimal amounts, e.g. "12.34", in Currency.
+	Currency  string `form:"currency" json:"currency,omitempty" binding:"required_with=MinAmount MaxAmount,omitempty,currency"`
+	MinAmount string `form:"min_amount" json:"min_amount,omitempty"`
+	MaxAmount string `form:"max_amount" json:"max_amount,omitempty"`
+	// Since and Until select the transfers created in [Since, Until).
+	Since string `form:"since" json:"since,omitempty" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Until string `form:"until" json:"until,omitempty" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// params turns the filters into the query parameters of ListOwnerTransfers.
+func (filters transferFilters) params(owner string) (db.ListOwnerTransfersParams, error) {
+	arg := db.ListOwnerTransfersParams{
+		Owner:             owner,
+		ExternalReference: optionalString(filters.ExternalReference),
+		Direction:         optionalString(filters.Direction),
+		Currency:          optionalString(filters.Currency),
+	}
+
+	if filters.CounterpartyAccountID != 0 {
+		arg.CounterpartyAccountID = &filters.CounterpartyAccountID
+	}
+
+	var err error
+	if arg.MinAmount, err = filterAmount(filters.MinAmount, filters.Currency); err != nil {
+		return arg, fmt.Errorf("invalid min_amount: %w", err)
+	}
+	if arg.MaxAmount, err = filterAmount(filters.MaxAmount, filters.Currency); err != nil {
+		return arg, fmt.Errorf("invalid max_amount: %w", err)
+	}
+	if arg.MinAmount != nil && arg.MaxAmount != nil && *arg.MinAmount > *arg.MaxAmount {
+		return arg, errors.New("min_amount is greater than max_amount")
+	}
+
+	if arg.Since, err = filterTime(filters.Since); err != nil {
+		return arg, fmt.Errorf("invalid since: %w", err)
+	}
+	if arg.Until, err = filterTime(filters.Until); err != nil {
+		return arg, fmt.Errorf("invalid until: %w", err)
+	}
+	if arg.Since != nil && arg.Until != nil && !arg.Since.Before(*arg.Until) {
+		return arg, errors.New("since must be before until")
+	}
+
+	return arg, nil
+}
+
+func filterAmount(amount string, currency string) (*int64, error) {
+	if amount == "" {
+		return nil, nil
+	}
+
+	money, err := util.ParseMoney(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	if !money.IsPositive() {
+		return nil, errAmountNotPositive
+	}
+	return &money.Amount, nil
+}
+
+func filterTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+type listTransfersRequest struct {
+	transferFilters
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listTransfersResponse struct {
+	Transfers  []transferResponse `json:"transfers"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type transferCursor struct {
+	Owner   string          `json:"owner"`
+	Filters transferFilters `json:"filters"`
+	AfterID int64           `json:"after_id"`
+}
+
+// listTransfers pages through the transfers sent or received by the accounts
+// of the authenticated user, optionally filtered by reference, direction,
+// counterparty, currency, amount and creation time. The to account of a
+// transfer to someone else is left out.
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg, err := req.transferFilters.params(authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	position := transferCursor{
+		Owner:   authPayload.Username,
+		Filters: req.transferFilters,
+	}
+	if req.Cursor != "" {
+		var cursor transferCursor
+		err := server.cursors.decode(req.Cursor, &cursor)
+		if err != nil || cursor.Owner != position.Owner || cursor.Filters != position.Filters {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+			return
+		}
+		position = cursor
+	}
+
+	// one extra row tells whether there is a next page
+	arg.AfterID = position.AfterID
+	arg.Limit = req.PageSize + 1
+	rows, err := server.store.ListOwnerTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listTransfersResponse{Transfers: make([]transferResponse, 0, len(rows))}
+	for _, row := range rows {
+		transfer := newTransferResponse(row.Transfer, row.FromCurrency, row.ToCurrency)
+		if row.ToOwner != authPayload.Username {
+			transfer.ToAccountID = 0
+		}
+		rsp.Transfers = append(rsp.Transfers, transfer)
+	}
+	if len(rows) > int(req.PageSize) {
+		rsp.Transfers = rsp.Transfers[:req.PageSize]
+		position.AfterID = rsp.Transfers[req.PageSize-1].ID
+
+		rsp.NextCursor, err = server.cursors.encode(position)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, status, err := server.checkAccount(ctx, accountID, currency)
+	if err != nil {
+		ctx.JSON(status, errorResponse(err))
 		return account, false
 	}
 
+	return account, true
+}
+
+// checkAccount is validAccount for callers that write the error response
+// themselves, along with the status it should have.
+func (server *Server) checkAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, int, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, http.StatusNotFound, err
+		}
+		return account, http.StatusInternalServerError, err
+	}
+
 	if account.Currency != currency {
 		err := fmt.Errorf("account {%d} currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		return account, http.StatusBadRequest, err
+	}
+
+	return account, http.StatusOK, nil
+}
+
+// requestMetadata checks the metadata of a transfer, which must be a JSON
+// object of at most maxMetadataSize bytes. Missing metadata is left nil.
+func requestMetadata(ctx *gin.Context, metadata json.RawMessage) (json.RawMessage, bool) {
+	metadata, err := parseMetadata(metadata)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return account, false
+		return nil, false
 	}
 
-	return account, true
+	return metadata, true
+}
+
+// parseMetadata is requestMetadata without the error response.
+func parseMetadata(metadata json.RawMessage) (json.RawMessage, error) {
+	if len(metadata) == 0 || string(metadata) == "null" {
+		return nil, nil
+	}
+
+	if len(metadata) > maxMetadataSize {
+		return nil, fmt.Errorf("metadata must be at most %d bytes", maxMetadataSize)
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(metadata, &object); err != nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+
+	return metadata, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// idempotencyKey returns the idempotency key sent with a request of username,
+// or nil when the client did not send one.
+func idempotencyKey(ctx *gin.Context, username string, req interface{}) (*db.CreateIdempotencyKeyParams, bool) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return nil, true
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return nil, false
+	}
+
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+
+	return &db.CreateIdempotencyKeyParams{
+		Key:         key,
+		Username:    username,
+		RequestHash: requestHash,
+	}, true
+}
+
+// hashRequest returns a digest of the request body, used to detect an
+// idempotency key being reused for a different request.
+func hashRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
 }
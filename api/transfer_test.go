@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	db "simple_bank/db/models"
@@ -32,6 +33,7 @@ func TestCreateTransferAPI(t *testing.T) {
 	account3.Currency = util.EUR
 
 	amount := int64(10)
+	idempotencyKey := util.RandomString(32)
 
 	testCases := []struct {
 		name          string
@@ -49,7 +51,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				arg := db.TransferTxParams{
@@ -74,6 +76,32 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "DisabledCurrency",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.JPY,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				// accounts opened before JPY was disabled can still transfer
+				jpyAccount1, jpyAccount2 := account1, account2
+				jpyAccount1.Currency, jpyAccount2.Currency = util.JPY, util.JPY
+
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(jpyAccount1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Once().Return(jpyAccount2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name: "UnauthorizedUser",
 			requestBody: gin.H{
@@ -83,7 +111,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -131,7 +159,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -158,7 +186,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -186,7 +214,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -213,7 +241,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -241,7 +269,64 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, mock.Anything).
+					Maybe()
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Maybe()
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DecimalAmount",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.10",
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				}
+
+				storeMock.
+					On("GetAccount", mock.Anything, account1.ID).
+					Once().
+					Return(account1, nil)
+				storeMock.
+					On("GetAccount", mock.Anything, account2.ID).
+					Once().
+					Return(account2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, arg).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyDecimals",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.101",
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -264,7 +349,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        "Invalid Currency",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -287,7 +372,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -301,6 +386,288 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "ToSystemAccount",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				systemAccount := account2
+				systemAccount.Owner = db.SystemUsername
+
+				storeMock.
+					On("GetAccount", mock.Anything, account1.ID).
+					Once().
+					Return(account1, nil)
+				storeMock.
+					On("GetAccount", mock.Anything, account2.ID).
+					Once().
+					Return(systemAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account1.ID).
+					Once().
+					Return(account1, nil)
+				storeMock.
+					On("GetAccount", mock.Anything, account2.ID).
+					Once().
+					Return(account2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, fmt.Errorf("%w: account %d", db.ErrInsufficientFunds, account1.ID))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "IdempotencyKey",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+				request.Header.Set(idempotencyKeyHeader, idempotencyKey)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				requestHash, err := hashRequest(transferRequest{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        json.RawMessage(fmt.Sprint(amount)),
+					Currency:      util.USD,
+				})
+				require.NoError(t, err)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					Idempotency: &db.CreateIdempotencyKeyParams{
+						Key:         idempotencyKey,
+						Username:    user1.Username,
+						RequestHash: requestHash,
+					},
+				}
+
+				storeMock.
+					On("GetAccount", mock.Anything, account1.ID).
+					Once().
+					Return(account1, nil)
+				storeMock.
+					On("GetAccount", mock.Anything, account2.ID).
+					Once().
+					Return(account2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, arg).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "IdempotencyKeyTooLong",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+				request.Header.Set(idempotencyKeyHeader, util.RandomString(maxIdempotencyKeyLength+1))
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, mock.Anything).
+					Maybe().
+					Return(account1, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Maybe()
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "IdempotencyKeyReused",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+				request.Header.Set(idempotencyKeyHeader, idempotencyKey)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.
+					On("GetAccount", mock.Anything, account1.ID).
+					Once().
+					Return(account1, nil)
+				storeMock.
+					On("GetAccount", mock.Anything, account2.ID).
+					Once().
+					Return(account2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{}, db.ErrIdempotencyKeyReused)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "WithDetails",
+			requestBody: gin.H{
+				"from_account_id":    account1.ID,
+				"to_account_id":      account2.ID,
+				"amount":             amount,
+				"currency":           util.USD,
+				"description":        "rent for march",
+				"external_reference": "INV-2022-03",
+				"metadata":           gin.H{"invoice": 3},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				reference := "INV-2022-03"
+				arg := db.TransferTxParams{
+					FromAccountID:     account1.ID,
+					ToAccountID:       account2.ID,
+					Amount:            amount,
+					Description:       "rent for march",
+					ExternalReference: &reference,
+					Metadata:          json.RawMessage(`{"invoice":3}`),
+				}
+
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Once().Return(account2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, arg).
+					Return(db.TransferTxResult{
+						Transfer: db.Transfer{
+							Description:       arg.Description,
+							ExternalReference: arg.ExternalReference,
+							Metadata:          arg.Metadata,
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferTxResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "rent for march", rsp.Transfer.Description)
+				require.Equal(t, "INV-2022-03", *rsp.Transfer.ExternalReference)
+				require.JSONEq(t, `{"invoice":3}`, string(rsp.Transfer.Metadata))
+			},
+		},
+		{
+			name: "WithFee",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Once().Return(account2, nil)
+				storeMock.
+					On("TransferTx", mock.Anything, mock.Anything).
+					Return(db.TransferTxResult{
+						Fee: &db.FeeBreakdown{
+							Currency:   util.USD,
+							Kind:       db.FeeFlat,
+							Calculated: 25,
+							Amount:     25,
+						},
+						FeeEntries: []db.Entry{
+							{AccountID: account1.ID, Amount: -25},
+							{AccountID: account2.ID + 1, Amount: 25},
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferTxResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.Fee)
+				require.Equal(t, db.FeeFlat, rsp.Fee.Kind)
+				require.Equal(t, util.NewMoney(25, util.USD), util.Money(rsp.Fee.Amount))
+				require.Len(t, rsp.FeeEntries, 2)
+			},
+		},
+		{
+			name: "MetadataNotAnObject",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"metadata":        []int{1, 2},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {
+				storeMock.On("GetAccount", mock.Anything, account1.ID).Once().Return(account1, nil)
+				storeMock.On("GetAccount", mock.Anything, account2.ID).Once().Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DescriptionTooLong",
+			requestBody: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"description":     util.RandomString(256),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(storeMock *mocks.Store) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "TransferTxError",
 			requestBody: gin.H{
@@ -310,7 +677,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(storeMock *mocks.Store) {
 				storeMock.
@@ -353,3 +720,306 @@ func TestCreateTransferAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestListTransfersByReferenceAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+
+	reference := "INV-2022-03"
+	pageSize := 5
+	rows := make([]db.ListOwnerTransfersRow, pageSize+1)
+	for i := range rows {
+		rows[i] = db.ListOwnerTransfersRow{
+			Transfer: db.Transfer{
+				ID:                int64(i + 1),
+				FromAccountID:     account.ID,
+				ToAccountID:       util.RandomInt(1, 1000),
+				Amount:            10,
+				ToAmount:          10,
+				ExternalReference: &reference,
+			},
+			FromCurrency: util.USD,
+			ToCurrency:   util.USD,
+			ToOwner:      util.RandomOwner(),
+		}
+	}
+	rows[1].ToOwner = user.Username
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListOwnerTransfers", mock.Anything, db.ListOwnerTransfersParams{
+			Owner:             user.Username,
+			ExternalReference: &reference,
+			AfterID:           0,
+			Limit:             int32(pageSize + 1),
+		}).
+		Return(rows, nil)
+	storeMock.
+		On("ListOwnerTransfers", mock.Anything, db.ListOwnerTransfersParams{
+			Owner:             user.Username,
+			ExternalReference: &reference,
+			AfterID:           int64(pageSize),
+			Limit:             int32(pageSize + 1),
+		}).
+		Return(rows[pageSize:], nil)
+
+	server := newTestServer(t, storeMock)
+
+	list := func(reference string, cursor string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		url := fmt.Sprintf("/transfers?page_size=%d&external_reference=%s&cursor=%s", pageSize, reference, cursor)
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := list(reference, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page1 listTransfersResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page1))
+	require.Len(t, page1.Transfers, pageSize)
+	require.Equal(t, reference, *page1.Transfers[0].ExternalReference)
+	require.Equal(t, util.NewMoney(10, util.USD), util.Money(page1.Transfers[0].Amount))
+	require.NotEmpty(t, page1.NextCursor)
+
+	// only the accounts of the user are shown as to accounts
+	require.Zero(t, page1.Transfers[0].ToAccountID)
+	require.Equal(t, rows[1].Transfer.ToAccountID, page1.Transfers[1].ToAccountID)
+
+	recorder = list(reference, page1.NextCursor)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page2 listTransfersResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &page2))
+	require.Len(t, page2.Transfers, 1)
+	require.Empty(t, page2.NextCursor)
+
+	// a cursor only pages through the listing it was issued for
+	recorder = list("INV-2022-04", page1.NextCursor)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestListTransfersFiltersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	since := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		checkParams   func(t *testing.T, arg db.ListOwnerTransfersParams)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "AllFilters",
+			query: "direction=incoming&counterparty_account_id=42&currency=USD&min_amount=10.50&max_amount=20&since=2022-03-01T00:00:00Z&until=2022-04-01T00:00:00Z",
+			checkParams: func(t *testing.T, arg db.ListOwnerTransfersParams) {
+				require.Equal(t, user.Username, arg.Owner)
+				require.Nil(t, arg.ExternalReference)
+				require.Equal(t, db.TransferIncoming, *arg.Direction)
+				require.Equal(t, int64(42), *arg.CounterpartyAccountID)
+				require.Equal(t, util.USD, *arg.Currency)
+				require.Equal(t, int64(1050), *arg.MinAmount)
+				require.Equal(t, int64(2000), *arg.MaxAmount)
+				require.True(t, since.Equal(*arg.Since))
+				require.True(t, until.Equal(*arg.Until))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "NoFilters",
+			query: "",
+			checkParams: func(t *testing.T, arg db.ListOwnerTransfersParams) {
+				require.Equal(t, db.ListOwnerTransfersParams{Owner: user.Username, Limit: 6}, arg)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDirection",
+			query: "direction=sideways",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "AmountWithoutCurrency",
+			query: "min_amount=10",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidAmount",
+			query: "currency=USD&min_amount=10.505",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "EmptyAmountRange",
+			query: "currency=USD&min_amount=20&max_amount=10",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidTime",
+			query: "since=yesterday",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "EmptyTimeRange",
+			query: "since=2022-04-01T00:00:00Z&until=2022-03-01T00:00:00Z",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			if tc.checkParams != nil {
+				storeMock.
+					On("ListOwnerTransfers", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) {
+						tc.checkParams(t, args.Get(1).(db.ListOwnerTransfersParams))
+					}).
+					Return([]db.ListOwnerTransfersRow{}, nil)
+			}
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := "/transfers?page_size=5&" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	fromAccount := randomAccount(sender.Username)
+	fromAccount.Currency = util.USD
+	toAccount := randomAccount(recipient.Username)
+	toAccount.Currency = util.USD
+
+	transfer := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        5000,
+		ToAmount:      5000,
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(storeMock *mocks.Store)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Sender",
+			username: sender.Username,
+			role:     util.DepositorRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, transfer.ID, rsp.ID)
+				require.Equal(t, util.NewMoney(5000, util.USD), util.Money(rsp.Amount))
+				// the account of the recipient is not shown to the sender
+				require.Zero(t, rsp.ToAccountID)
+			},
+		},
+		{
+			name:     "Recipient",
+			username: recipient.Username,
+			role:     util.DepositorRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, toAccount.ID, rsp.ToAccountID)
+			},
+		},
+		{
+			name:     "Banker",
+			username: other.Username,
+			role:     util.BankerRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Unauthorized",
+			username: other.Username,
+			role:     util.DepositorRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.On("GetTransfer", mock.Anything, transfer.ID).Return(transfer, nil)
+			storeMock.On("GetAccount", mock.Anything, fromAccount.ID).Return(fromAccount, nil)
+			storeMock.On("GetAccount", mock.Anything, toAccount.ID).Return(toAccount, nil)
+
+			server := newTestServer(t, storeMock)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d", transfer.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferAPINotFound(t *testing.T) {
+	user, _ := randomUser(t)
+
+	storeMock := mocks.NewStore(t)
+	storeMock.On("GetTransfer", mock.Anything, int64(7)).Return(db.Transfer{}, sql.ErrNoRows)
+
+	server := newTestServer(t, storeMock)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/transfers/7", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
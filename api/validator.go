@@ -6,9 +6,19 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// validCurrency accepts any registered currency, so that accounts keep
+// working after their currency is disabled.
 var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	if currency, ok := fieldLevel.Field().Interface().(string); ok {
 		return util.IsSupportedCurrency(currency)
 	}
 	return false
 }
+
+// validEnabledCurrency only accepts currencies new accounts may be opened in.
+var validEnabledCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if currency, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsEnabledCurrency(currency)
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package approval
+
+import (
+	"context"
+	"log"
+	db "simple_bank/db/models"
+	"time"
+)
+
+const defaultExpiryInterval = time.Minute
+
+// Expirer marks the transfers that waited for approval past their expiry as
+// expired. Approvals check the expiry themselves, so this only keeps the
+// status of the pending transfers accurate.
+type Expirer struct {
+	store db.Store
+	now   func() time.Time
+}
+
+func NewExpirer(store db.Store) *Expirer {
+	return &Expirer{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// RunOnce expires the pending transfers due now and returns how many there
+// were.
+func (e *Expirer) RunOnce(ctx context.Context) (int64, error) {
+	return e.store.ExpirePendingTransfers(ctx, e.now().UTC())
+}
+
+// Run calls RunOnce immediately and then every interval until ctx is done.
+func (e *Expirer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultExpiryInterval
+	}
+
+	e.runOnce(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.runOnce(ctx)
+		}
+	}
+}
+
+func (e *Expirer) runOnce(ctx context.Context) {
+	n, err := e.RunOnce(ctx)
+	if err != nil {
+		log.Println("cannot expire pending transfers:", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("expired %d pending transfers", n)
+	}
+}
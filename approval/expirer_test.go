@@ -0,0 +1,34 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"simple_bank/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOnce(t *testing.T) {
+	now := time.Date(2023, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	store := mocks.NewStore(t)
+	store.On("ExpirePendingTransfers", context.Background(), now).Return(int64(3), nil).Once()
+
+	expirer := NewExpirer(store)
+	expirer.now = func() time.Time { return now }
+
+	n, err := expirer.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+}
+
+func TestRunOnceError(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.On("ExpirePendingTransfers", context.Background(), mock.Anything).Return(int64(0), errors.New("connection reset")).Once()
+
+	_, err := NewExpirer(store).RunOnce(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,9 @@
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_limit_non_negative" CHECK ("overdraft_limit" >= 0);
+
+-- NOT VALID keeps accounts that already went negative before this migration
+-- loadable, while every new write is checked.
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft" CHECK ("balance" >= -"overdraft_limit") NOT VALID;
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far below zero the balance may go';
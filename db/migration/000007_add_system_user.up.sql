@@ -0,0 +1,11 @@
+-- The system user owns the per-currency cash accounts that balance deposits
+-- and withdrawals. Its empty password hash means it can never log in.
+INSERT INTO "users" (
+  "username",
+  "hashed_password",
+  "full_name",
+  "email",
+  "role"
+) VALUES (
+  'system', '', 'System', 'system@simple-bank.internal', 'system'
+);
@@ -0,0 +1,60 @@
+CREATE TABLE "exchange_rates" (
+  "base_currency" varchar NOT NULL,
+  "quote_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("base_currency", "quote_currency")
+);
+
+ALTER TABLE "exchange_rates" ADD CONSTRAINT "rate_positive" CHECK ("rate" > 0);
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'mid rate: price of one base unit in quote units';
+
+CREATE TABLE "exchange_quotes" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "base_currency" varchar NOT NULL,
+  "quote_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "spread" numeric NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "exchange_quotes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+COMMENT ON COLUMN "exchange_quotes"."rate" IS 'customer rate, with the spread already applied';
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_spread" numeric;
+
+ALTER TABLE "transfers" ADD COLUMN "quote_id" uuid;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("quote_id") REFERENCES "exchange_quotes" ("id");
+
+-- a quote fixes the rate of a single transfer
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_quote_id_key" UNIQUE ("quote_id");
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the currency of to_account_id';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'null for transfers within one currency';
+
+-- The fx user owns the per-currency position accounts that balance
+-- cross-currency transfers, so the entries of each currency still sum up to
+-- zero. Like the system user it can never log in.
+INSERT INTO "users" (
+  "username",
+  "hashed_password",
+  "full_name",
+  "email",
+  "role"
+) VALUES (
+  'fx', '', 'Foreign Exchange', 'fx@simple-bank.internal', 'system'
+);
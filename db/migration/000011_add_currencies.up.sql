@@ -0,0 +1,25 @@
+CREATE TABLE "currencies" (
+  "code" varchar(3) PRIMARY KEY,
+  "numeric_code" varchar(3) NOT NULL,
+  "minor_units" integer NOT NULL,
+  "symbol" varchar NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT false
+);
+
+ALTER TABLE "currencies" ADD CONSTRAINT "minor_units_range" CHECK ("minor_units" BETWEEN 0 AND 4);
+
+COMMENT ON COLUMN "currencies"."minor_units" IS 'ISO 4217 exponent: decimals between the major unit and ledger amounts';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'whether new accounts may be opened in this currency';
+
+INSERT INTO "currencies" ("code", "numeric_code", "minor_units", "symbol", "enabled") VALUES
+  ('USD', '840', 2, '$', true),
+  ('EUR', '978', 2, '€', true),
+  ('CAD', '124', 2, 'CA$', true),
+  ('GBP', '826', 2, '£', false),
+  ('CHF', '756', 2, 'CHF', false),
+  ('JPY', '392', 0, '¥', false),
+  ('KWD', '414', 3, 'KD', false),
+  ('BHD', '048', 3, 'BD', false);
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+DELETE FROM "entries" WHERE "transfer_id" IN (
+  SELECT "id" FROM "transfers" WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'interest')
+);
+
+DELETE FROM "transfers" WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'interest');
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'interest');
+
+DELETE FROM "accounts" WHERE "owner" = 'interest';
+
+DELETE FROM "users" WHERE "username" = 'interest';
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "product";
+
+DROP TABLE IF EXISTS "account_products";
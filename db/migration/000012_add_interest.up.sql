@@ -0,0 +1,58 @@
+CREATE TABLE "account_products" (
+  "code" varchar PRIMARY KEY,
+  "interest_rate" numeric NOT NULL DEFAULT 0,
+  "day_count" varchar NOT NULL DEFAULT 'ACT/365'
+);
+
+ALTER TABLE "account_products" ADD CONSTRAINT "interest_rate_non_negative" CHECK ("interest_rate" >= 0);
+
+ALTER TABLE "account_products" ADD CONSTRAINT "day_count_supported" CHECK ("day_count" IN ('ACT/365', 'ACT/360', 'ACT/ACT', '30/360'));
+
+COMMENT ON COLUMN "account_products"."interest_rate" IS 'annual rate paid on positive balances, e.g. 0.02 for 2%';
+
+COMMENT ON COLUMN "account_products"."day_count" IS 'convention turning one day into a fraction of the year';
+
+INSERT INTO "account_products" ("code", "interest_rate", "day_count") VALUES
+  ('checking', 0, 'ACT/365'),
+  ('savings', 0.02, 'ACT/365');
+
+ALTER TABLE "accounts" ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "interest_rate" numeric NOT NULL,
+  "day_count" varchar NOT NULL,
+  "amount" numeric NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "interest_accruals" ("account_id") WHERE "transfer_id" IS NULL;
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'balance at the end of accrual_date';
+
+COMMENT ON COLUMN "interest_accruals"."amount" IS 'unrounded interest in minor units';
+
+COMMENT ON COLUMN "interest_accruals"."transfer_id" IS 'the monthly posting that paid this accrual, null until posted';
+
+-- The interest user owns the per-currency interest-expense accounts that pay
+-- the interest posted to savings accounts. Like the system user it can never
+-- log in.
+INSERT INTO "users" (
+  "username",
+  "hashed_password",
+  "full_name",
+  "email",
+  "role"
+) VALUES (
+  'interest', '', 'Interest Expense', 'interest@simple-bank.internal', 'system'
+);
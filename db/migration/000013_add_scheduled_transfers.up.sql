@@ -0,0 +1,63 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "schedule" varchar NOT NULL,
+  "start_at" timestamptz NOT NULL,
+  "end_at" timestamptz,
+  "next_run_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "locked_until" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_status_valid" CHECK ("status" IN ('active', 'paused', 'completed', 'cancelled'));
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("next_run_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "scheduled_transfers"."schedule" IS 'cron expression, @daily style descriptor or @every <duration>';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'the occurrence to execute next, kept while it is retried';
+
+COMMENT ON COLUMN "scheduled_transfers"."attempts" IS 'failed attempts of the occurrence at next_run_at';
+
+COMMENT ON COLUMN "scheduled_transfers"."locked_until" IS 'claimed by a scheduler, or backing off after a failure, until then';
+
+CREATE TABLE "scheduled_transfer_executions" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "scheduled_for" timestamptz NOT NULL,
+  "attempt" integer NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfer_executions" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_executions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_executions" ADD CONSTRAINT "execution_status_valid" CHECK ("status" IN ('succeeded', 'failed', 'retrying'));
+
+CREATE INDEX ON "scheduled_transfer_executions" ("scheduled_transfer_id");
+
+-- an occurrence is paid at most once, whichever replica executes it
+CREATE UNIQUE INDEX "scheduled_transfer_executions_succeeded_key" ON "scheduled_transfer_executions" ("scheduled_transfer_id", "scheduled_for") WHERE "status" = 'succeeded';
@@ -0,0 +1,15 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "external_reference" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+CREATE INDEX ON "transfers" ("external_reference");
+
+COMMENT ON COLUMN "transfers"."description" IS 'memo set by the sender';
+
+COMMENT ON COLUMN "transfers"."external_reference" IS 'reference of the payment in the systems of the client, e.g. an invoice number';
+
+ALTER TABLE "entries" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "entries"."description" IS 'copied from the transfer, so statements show what a payment was for';
@@ -0,0 +1,62 @@
+ALTER TABLE "accounts" ADD COLUMN "approval_threshold" bigint;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "approval_threshold_positive" CHECK ("approval_threshold" > 0);
+
+COMMENT ON COLUMN "accounts"."approval_threshold" IS 'transfers above it wait for an approver, none when null';
+
+CREATE TABLE "account_approvers" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+ALTER TABLE "account_approvers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_approvers" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "account_approvers" ("username");
+
+CREATE TABLE "pending_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "requested_by" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "external_reference" varchar,
+  "metadata" jsonb NOT NULL DEFAULT '{}',
+  "status" varchar NOT NULL DEFAULT 'pending_approval',
+  "decided_by" varchar,
+  "decided_at" timestamptz,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("decided_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_status_valid" CHECK ("status" IN ('pending_approval', 'approved', 'rejected', 'expired'));
+
+CREATE INDEX ON "pending_transfers" ("from_account_id");
+
+CREATE INDEX ON "pending_transfers" ("requested_by");
+
+CREATE INDEX ON "pending_transfers" ("expires_at") WHERE "status" = 'pending_approval';
+
+COMMENT ON COLUMN "pending_transfers"."decided_by" IS 'the approver who approved or rejected the transfer';
+
+COMMENT ON COLUMN "pending_transfers"."transfer_id" IS 'the transfer executed on approval';
@@ -0,0 +1,25 @@
+CREATE TABLE "risk_decisions" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "decision" varchar NOT NULL,
+  "reasons" jsonb NOT NULL DEFAULT '[]',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_decisions" ADD CONSTRAINT "risk_decision_valid" CHECK ("decision" IN ('allow', 'review', 'deny'));
+
+CREATE INDEX ON "risk_decisions" ("username");
+
+CREATE INDEX ON "risk_decisions" ("created_at") WHERE "decision" <> 'allow';
+
+COMMENT ON COLUMN "risk_decisions"."reasons" IS 'the rules that matched, with their code, decision and message';
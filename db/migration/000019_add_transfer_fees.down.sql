@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "transfer_fees";
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'fees');
+
+DELETE FROM "accounts" WHERE "owner" = 'fees';
+
+DELETE FROM "users" WHERE "username" = 'fees';
@@ -0,0 +1,47 @@
+CREATE TABLE "transfer_fees" (
+  "currency" varchar NOT NULL,
+  "product" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "amount" bigint NOT NULL DEFAULT 0,
+  "rate" numeric NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("currency", "product")
+);
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+ALTER TABLE "transfer_fees" ADD CONSTRAINT "transfer_fee_kind_valid" CHECK ("kind" IN ('flat', 'percentage'));
+
+ALTER TABLE "transfer_fees" ADD CONSTRAINT "transfer_fee_amount_non_negative" CHECK ("amount" >= 0);
+
+ALTER TABLE "transfer_fees" ADD CONSTRAINT "transfer_fee_rate_non_negative" CHECK ("rate" >= 0);
+
+ALTER TABLE "transfer_fees" ADD CONSTRAINT "transfer_fee_min_non_negative" CHECK ("min_fee" >= 0);
+
+ALTER TABLE "transfer_fees" ADD CONSTRAINT "transfer_fee_max_above_min" CHECK ("max_fee" >= "min_fee");
+
+COMMENT ON TABLE "transfer_fees" IS 'fee charged to the sender of a transfer, by currency and product of the from account; no row means no fee';
+
+COMMENT ON COLUMN "transfer_fees"."amount" IS 'flat fee in minor units, used when kind is flat';
+
+COMMENT ON COLUMN "transfer_fees"."rate" IS 'share of the amount charged when kind is percentage, e.g. 0.01 for 1%';
+
+COMMENT ON COLUMN "transfer_fees"."min_fee" IS 'lower bound of the fee in minor units';
+
+COMMENT ON COLUMN "transfer_fees"."max_fee" IS 'upper bound of the fee in minor units, null for none';
+
+-- The fees user owns the per-currency revenue accounts that collect transfer
+-- fees. Like the system user it can never log in.
+INSERT INTO "users" (
+  "username",
+  "hashed_password",
+  "full_name",
+  "email",
+  "role"
+) VALUES (
+  'fees', '', 'Fee Revenue', 'fees@simple-bank.internal', 'system'
+);
@@ -0,0 +1,7 @@
+ALTER TABLE "interest_accruals" ADD COLUMN "carry_over" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "interest_accruals" DROP CONSTRAINT "interest_accruals_pkey";
+
+ALTER TABLE "interest_accruals" ADD PRIMARY KEY ("account_id", "accrual_date", "carry_over");
+
+COMMENT ON COLUMN "interest_accruals"."carry_over" IS 'true for the fraction of a minor unit left over by a posting, dated on the last day it paid';
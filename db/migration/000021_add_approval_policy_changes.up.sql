@@ -0,0 +1,25 @@
+CREATE TABLE "approval_policy_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "requested_by" varchar NOT NULL,
+  "approval_threshold" bigint,
+  "approvers" varchar[] NOT NULL DEFAULT '{}',
+  "status" varchar NOT NULL,
+  "decided_by" varchar,
+  "decided_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "approval_policy_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "approval_policy_changes" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "approval_policy_changes" ADD FOREIGN KEY ("decided_by") REFERENCES "users" ("username");
+
+ALTER TABLE "approval_policy_changes" ADD CONSTRAINT "policy_change_status_valid" CHECK ("status" IN ('pending_approval', 'applied', 'rejected'));
+
+CREATE INDEX ON "approval_policy_changes" ("account_id");
+
+COMMENT ON TABLE "approval_policy_changes" IS 'every change of the approval policy of an account, applied or waiting for an approver';
+
+COMMENT ON COLUMN "approval_policy_changes"."decided_by" IS 'the approver who confirmed or rejected the change, null when it did not need one';
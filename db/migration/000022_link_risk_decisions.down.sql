@@ -0,0 +1,3 @@
+ALTER TABLE "risk_decisions" DROP COLUMN IF EXISTS "pending_transfer_id";
+
+ALTER TABLE "risk_decisions" DROP COLUMN IF EXISTS "transfer_id";
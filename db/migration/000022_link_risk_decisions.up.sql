@@ -0,0 +1,11 @@
+ALTER TABLE "risk_decisions" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "risk_decisions" ADD COLUMN "pending_transfer_id" bigint;
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("pending_transfer_id") REFERENCES "pending_transfers" ("id");
+
+COMMENT ON COLUMN "risk_decisions"."transfer_id" IS 'the transfer made after the decision, null when it was denied or failed';
+
+COMMENT ON COLUMN "risk_decisions"."pending_transfer_id" IS 'the transfer held for approval after the decision, if any';
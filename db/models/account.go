@@ -7,7 +7,7 @@ const addAccountBalance = `
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at
 `
 
 type AddAccountBalanceParams struct {
@@ -23,6 +23,9 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&account.Owner,
 		&account.Balance,
 		&account.Currency,
+		&account.OverdraftLimit,
+		&account.Product,
+		&account.ApprovalThreshold,
 		&account.CreatedAt,
 	)
 	return account, err
@@ -30,7 +33,7 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 
 // getAccount
 const getAccount = `
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -42,13 +45,16 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&account.Owner,
 		&account.Balance,
 		&account.Currency,
+		&account.OverdraftLimit,
+		&account.Product,
+		&account.ApprovalThreshold,
 		&account.CreatedAt,
 	)
 	return account, err
 }
 
 const getAccountForUpdate = `
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -61,6 +67,9 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&account.Owner,
 		&account.Balance,
 		&account.Currency,
+		&account.OverdraftLimit,
+		&account.Product,
+		&account.ApprovalThreshold,
 		&account.CreatedAt,
 	)
 	return account, err
@@ -68,7 +77,7 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 
 // listAccounts
 const listAccounts = `
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2
@@ -95,6 +104,55 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&account.Owner,
 			&account.Balance,
 			&account.Currency,
+			&account.OverdraftLimit,
+			&account.Product,
+			&account.ApprovalThreshold,
+			&account.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// listAccountsAfter
+const listAccountsAfter = `
+SELECT id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at FROM accounts
+WHERE owner = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountsAfterParams struct {
+	Owner   string `json:"owner"`
+	AfterID int64  `json:"after_id"`
+	Limit   int32  `json:"limit"`
+}
+
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsAfter, arg.Owner, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	accounts := []Account{}
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(
+			&account.ID,
+			&account.Owner,
+			&account.Balance,
+			&account.Currency,
+			&account.OverdraftLimit,
+			&account.Product,
+			&account.ApprovalThreshold,
 			&account.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -115,26 +173,31 @@ const createAccount = `
 INSERT INTO accounts (
 	owner,
 	balance,
-	currency
+	currency,
+	product
 ) VALUES (
-	$1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+	$1, $2, $3, $4
+) RETURNING id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at
 `
 
 type CreateAccountParams struct {
 	Owner    string `json:"owner"`
 	Balance  int64  `json:"balance"`
 	Currency string `json:"currency"`
+	Product  string `json:"product"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency, arg.Product)
 	var newAccount Account
 	err := row.Scan(
 		&newAccount.ID,
 		&newAccount.Owner,
 		&newAccount.Balance,
 		&newAccount.Currency,
+		&newAccount.OverdraftLimit,
+		&newAccount.Product,
+		&newAccount.ApprovalThreshold,
 		&newAccount.CreatedAt,
 	)
 	return newAccount, err
@@ -145,7 +208,7 @@ const updateAccount = `
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at
 `
 
 type UpdateAccountParams struct {
@@ -161,6 +224,9 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&updatedAccount.Owner,
 		&updatedAccount.Balance,
 		&updatedAccount.Currency,
+		&updatedAccount.OverdraftLimit,
+		&updatedAccount.Product,
+		&updatedAccount.ApprovalThreshold,
 		&updatedAccount.CreatedAt,
 	)
 	return updatedAccount, err
@@ -176,3 +242,112 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 	_, err := q.db.ExecContext(ctx, deleteAccount, id)
 	return err
 }
+
+// updateAccountOverdraftLimit
+const updateAccountOverdraftLimit = `
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	ID             int64 `json:"id"`
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var updatedAccount Account
+	err := row.Scan(
+		&updatedAccount.ID,
+		&updatedAccount.Owner,
+		&updatedAccount.Balance,
+		&updatedAccount.Currency,
+		&updatedAccount.OverdraftLimit,
+		&updatedAccount.Product,
+		&updatedAccount.ApprovalThreshold,
+		&updatedAccount.CreatedAt,
+	)
+	return updatedAccount, err
+}
+
+// getAccountByOwnerAndCurrency
+const getAccountByOwnerAndCurrency = `
+SELECT id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetAccountByOwnerAndCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByOwnerAndCurrency, arg.Owner, arg.Currency)
+	var account Account
+	err := row.Scan(
+		&account.ID,
+		&account.Owner,
+		&account.Balance,
+		&account.Currency,
+		&account.OverdraftLimit,
+		&account.Product,
+		&account.ApprovalThreshold,
+		&account.CreatedAt,
+	)
+	return account, err
+}
+
+// createSystemAccount
+const createSystemAccount = `
+INSERT INTO accounts (
+	owner,
+	balance,
+	currency,
+	overdraft_limit
+) VALUES (
+	$1, 0, $2, 9223372036854775807
+) ON CONFLICT (owner, currency) DO NOTHING
+`
+
+type CreateSystemAccountParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+// CreateSystemAccount creates a ledger account without a lower balance bound.
+// It is a no-op when the owner already has an account in that currency.
+func (q *Queries) CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) error {
+	_, err := q.db.ExecContext(ctx, createSystemAccount, arg.Owner, arg.Currency)
+	return err
+}
+
+// updateAccountApprovalThreshold
+const updateAccountApprovalThreshold = `
+UPDATE accounts
+SET approval_threshold = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, overdraft_limit, product, approval_threshold, created_at
+`
+
+type UpdateAccountApprovalThresholdParams struct {
+	ID                int64  `json:"id"`
+	ApprovalThreshold *int64 `json:"approval_threshold"`
+}
+
+func (q *Queries) UpdateAccountApprovalThreshold(ctx context.Context, arg UpdateAccountApprovalThresholdParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountApprovalThreshold, arg.ID, arg.ApprovalThreshold)
+	var updatedAccount Account
+	err := row.Scan(
+		&updatedAccount.ID,
+		&updatedAccount.Owner,
+		&updatedAccount.Balance,
+		&updatedAccount.Currency,
+		&updatedAccount.OverdraftLimit,
+		&updatedAccount.Product,
+		&updatedAccount.ApprovalThreshold,
+		&updatedAccount.CreatedAt,
+	)
+	return updatedAccount, err
+}
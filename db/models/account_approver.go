@@ -0,0 +1,86 @@
+package db
+
+import "context"
+
+// createAccountApprover
+const createAccountApprover = `
+INSERT INTO account_approvers (
+	account_id,
+	username
+) VALUES (
+	$1, $2
+) RETURNING account_id, username, created_at
+`
+
+type CreateAccountApproverParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) CreateAccountApprover(ctx context.Context, arg CreateAccountApproverParams) (AccountApprover, error) {
+	row := q.db.QueryRowContext(ctx, createAccountApprover, arg.AccountID, arg.Username)
+	var i AccountApprover
+	err := row.Scan(&i.AccountID, &i.Username, &i.CreatedAt)
+	return i, err
+}
+
+// getAccountApprover
+const getAccountApprover = `
+SELECT account_id, username, created_at FROM account_approvers
+WHERE account_id = $1 AND username = $2 LIMIT 1
+`
+
+type GetAccountApproverParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+// GetAccountApprover returns sql.ErrNoRows when the user is not an approver
+// of the account.
+func (q *Queries) GetAccountApprover(ctx context.Context, arg GetAccountApproverParams) (AccountApprover, error) {
+	row := q.db.QueryRowContext(ctx, getAccountApprover, arg.AccountID, arg.Username)
+	var i AccountApprover
+	err := row.Scan(&i.AccountID, &i.Username, &i.CreatedAt)
+	return i, err
+}
+
+// listAccountApprovers
+const listAccountApprovers = `
+SELECT account_id, username, created_at FROM account_approvers
+WHERE account_id = $1
+ORDER BY username
+`
+
+func (q *Queries) ListAccountApprovers(ctx context.Context, accountID int64) ([]AccountApprover, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountApprovers, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountApprover{}
+	for rows.Next() {
+		var i AccountApprover
+		if err := rows.Scan(&i.AccountID, &i.Username, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// deleteAccountApprovers
+const deleteAccountApprovers = `
+DELETE FROM account_approvers
+WHERE account_id = $1
+`
+
+func (q *Queries) DeleteAccountApprovers(ctx context.Context, accountID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteAccountApprovers, accountID)
+	return err
+}
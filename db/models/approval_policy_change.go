@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// Statuses of an approval policy change. Changes that loosen the policy wait
+// in pending_approval until an approver of the account confirms them.
+const (
+	ApprovalPolicyChangePendingApproval = "pending_approval"
+	ApprovalPolicyChangeApplied         = "applied"
+	ApprovalPolicyChangeRejected        = "rejected"
+)
+
+// createApprovalPolicyChange
+const createApprovalPolicyChange = `
+INSERT INTO approval_policy_changes (
+	account_id,
+	requested_by,
+	approval_threshold,
+	approvers,
+	status
+) VALUES (
+	$1, $2, $3, COALESCE($4::varchar[], '{}'), $5
+) RETURNING id, account_id, requested_by, approval_threshold, approvers, status, decided_by, decided_at, created_at
+`
+
+type CreateApprovalPolicyChangeParams struct {
+	AccountID         int64    `json:"account_id"`
+	RequestedBy       string   `json:"requested_by"`
+	ApprovalThreshold *int64   `json:"approval_threshold"`
+	Approvers         []string `json:"approvers"`
+	Status            string   `json:"status"`
+}
+
+func (q *Queries) CreateApprovalPolicyChange(ctx context.Context, arg CreateApprovalPolicyChangeParams) (ApprovalPolicyChange, error) {
+	row := q.db.QueryRowContext(ctx, createApprovalPolicyChange,
+		arg.AccountID,
+		arg.RequestedBy,
+		arg.ApprovalThreshold,
+		pq.Array(arg.Approvers),
+		arg.Status,
+	)
+	var i ApprovalPolicyChange
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.RequestedBy,
+		&i.ApprovalThreshold,
+		pq.Array(&i.Approvers),
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// getApprovalPolicyChange
+const getApprovalPolicyChange = `
+SELECT id, account_id, requested_by, approval_threshold, approvers, status, decided_by, decided_at, created_at FROM approval_policy_changes
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetApprovalPolicyChange(ctx context.Context, id int64) (ApprovalPolicyChange, error) {
+	row := q.db.QueryRowContext(ctx, getApprovalPolicyChange, id)
+	var i ApprovalPolicyChange
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.RequestedBy,
+		&i.ApprovalThreshold,
+		pq.Array(&i.Approvers),
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// getApprovalPolicyChangeForUpdate
+const getApprovalPolicyChangeForUpdate = `
+SELECT id, account_id, requested_by, approval_threshold, approvers, status, decided_by, decided_at, created_at FROM approval_policy_changes
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetApprovalPolicyChangeForUpdate(ctx context.Context, id int64) (ApprovalPolicyChange, error) {
+	row := q.db.QueryRowContext(ctx, getApprovalPolicyChangeForUpdate, id)
+	var i ApprovalPolicyChange
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.RequestedBy,
+		&i.ApprovalThreshold,
+		pq.Array(&i.Approvers),
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// listApprovalPolicyChangesAfter
+const listApprovalPolicyChangesAfter = `
+SELECT id, account_id, requested_by, approval_threshold, approvers, status, decided_by, decided_at, created_at FROM approval_policy_changes
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListApprovalPolicyChangesAfterParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+	Limit     int32 `json:"limit"`
+}
+
+// ListApprovalPolicyChangesAfter pages through the policy changes of an
+// account, oldest first.
+func (q *Queries) ListApprovalPolicyChangesAfter(ctx context.Context, arg ListApprovalPolicyChangesAfterParams) ([]ApprovalPolicyChange, error) {
+	rows, err := q.db.QueryContext(ctx, listApprovalPolicyChangesAfter, arg.AccountID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApprovalPolicyChange{}
+	for rows.Next() {
+		var i ApprovalPolicyChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.RequestedBy,
+			&i.ApprovalThreshold,
+			pq.Array(&i.Approvers),
+			&i.Status,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// decideApprovalPolicyChange
+const decideApprovalPolicyChange = `
+UPDATE approval_policy_changes
+SET
+	status = $2,
+	decided_by = $3,
+	decided_at = now()
+WHERE id = $1 AND status = 'pending_approval'
+RETURNING id, account_id, requested_by, approval_threshold, approvers, status, decided_by, decided_at, created_at
+`
+
+type DecideApprovalPolicyChangeParams struct {
+	ID        int64  `json:"id"`
+	Status    string `json:"status"`
+	DecidedBy string `json:"decided_by"`
+}
+
+// DecideApprovalPolicyChange returns sql.ErrNoRows when the change was
+// already applied or rejected.
+func (q *Queries) DecideApprovalPolicyChange(ctx context.Context, arg DecideApprovalPolicyChangeParams) (ApprovalPolicyChange, error) {
+	row := q.db.QueryRowContext(ctx, decideApprovalPolicyChange, arg.ID, arg.Status, arg.DecidedBy)
+	var i ApprovalPolicyChange
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.RequestedBy,
+		&i.ApprovalThreshold,
+		pq.Array(&i.Approvers),
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrPendingTransferDecided      = errors.New("pending transfer was already approved or rejected")
+	ErrPendingTransferExpired      = errors.New("pending transfer has expired")
+	ErrApprovalPolicyChangeDecided = errors.New("approval policy change was already applied or rejected")
+)
+
+type SetApprovalPolicyTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// ApprovalThreshold is the largest amount transferred without approval.
+	// Nil turns approvals off.
+	ApprovalThreshold *int64   `json:"approval_threshold"`
+	Approvers         []string `json:"approvers"`
+	// RequestedBy is recorded with the change.
+	RequestedBy string `json:"requested_by"`
+}
+
+type SetApprovalPolicyTxResult struct {
+	Account   Account              `json:"account"`
+	Approvers []AccountApprover    `json:"approvers"`
+	Change    ApprovalPolicyChange `json:"change"`
+}
+
+// SetApprovalPolicyTx replaces the approval threshold and the approvers of an
+// account in one transaction, and records the change as applied.
+func (store *SQLStore) SetApprovalPolicyTx(ctx context.Context, arg SetApprovalPolicyTxParams) (SetApprovalPolicyTxResult, error) {
+	var result SetApprovalPolicyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = setApprovalPolicy(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Change, err = q.CreateApprovalPolicyChange(ctx, CreateApprovalPolicyChangeParams{
+			AccountID:         arg.AccountID,
+			RequestedBy:       arg.RequestedBy,
+			ApprovalThreshold: arg.ApprovalThreshold,
+			Approvers:         arg.Approvers,
+			Status:            ApprovalPolicyChangeApplied,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type ApproveApprovalPolicyChangeTxParams struct {
+	ID         int64  `json:"id"`
+	ApprovedBy string `json:"approved_by"`
+}
+
+// ApproveApprovalPolicyChangeTx applies a policy change that was waiting for
+// an approver and records who approved it.
+func (store *SQLStore) ApproveApprovalPolicyChangeTx(ctx context.Context, arg ApproveApprovalPolicyChangeTxParams) (SetApprovalPolicyTxResult, error) {
+	var result SetApprovalPolicyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// locking the change keeps it from being applied twice
+		change, err := q.GetApprovalPolicyChangeForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if change.Status != ApprovalPolicyChangePendingApproval {
+			return fmt.Errorf("%w: change %d is %s", ErrApprovalPolicyChangeDecided, change.ID, change.Status)
+		}
+
+		result, err = setApprovalPolicy(ctx, q, SetApprovalPolicyTxParams{
+			AccountID:         change.AccountID,
+			ApprovalThreshold: change.ApprovalThreshold,
+			Approvers:         change.Approvers,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Change, err = q.DecideApprovalPolicyChange(ctx, DecideApprovalPolicyChangeParams{
+			ID:        change.ID,
+			Status:    ApprovalPolicyChangeApplied,
+			DecidedBy: arg.ApprovedBy,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+func setApprovalPolicy(ctx context.Context, q *Queries, arg SetApprovalPolicyTxParams) (SetApprovalPolicyTxResult, error) {
+	var result SetApprovalPolicyTxResult
+
+	var err error
+	result.Account, err = q.UpdateAccountApprovalThreshold(ctx, UpdateAccountApprovalThresholdParams{
+		ID:                arg.AccountID,
+		ApprovalThreshold: arg.ApprovalThreshold,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = q.DeleteAccountApprovers(ctx, arg.AccountID)
+	if err != nil {
+		return result, err
+	}
+
+	result.Approvers = make([]AccountApprover, 0, len(arg.Approvers))
+	for _, username := range arg.Approvers {
+		approver, err := q.CreateAccountApprover(ctx, CreateAccountApproverParams{
+			AccountID: arg.AccountID,
+			Username:  username,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Approvers = append(result.Approvers, approver)
+	}
+	return result, nil
+}
+
+type CreatePendingTransferTxParams struct {
+	CreatePendingTransferParams
+	// Idempotency, when set, makes a retried request return the pending
+	// transfer created by the first one.
+	Idempotency *CreateIdempotencyKeyParams `json:"-"`
+	// RiskDecisionID, when set, is the risk decision that screened the
+	// transfer. It is linked to the pending transfer.
+	RiskDecisionID *int64 `json:"-"`
+}
+
+// CreatePendingTransferTx stores a transfer waiting for approval. No money
+// moves until it is approved.
+func (store *SQLStore) CreatePendingTransferTx(ctx context.Context, arg CreatePendingTransferTxParams) (PendingTransfer, error) {
+	var pending PendingTransfer
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if arg.Idempotency != nil {
+			replayed, err := claimIdempotencyKey(ctx, q, *arg.Idempotency, &pending)
+			if err != nil || replayed {
+				return err
+			}
+		}
+
+		var err error
+		pending, err = q.CreatePendingTransfer(ctx, arg.CreatePendingTransferParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.RiskDecisionID != nil {
+			err = q.SetRiskDecisionPendingTransfer(ctx, SetRiskDecisionPendingTransferParams{
+				ID:                *arg.RiskDecisionID,
+				PendingTransferID: pending.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.Idempotency == nil {
+			return nil
+		}
+
+		return saveIdempotentResponse(ctx, q, *arg.Idempotency, pending)
+	})
+
+	return pending, err
+}
+
+type ApprovePendingTransferTxParams struct {
+	ID         int64  `json:"id"`
+	ApprovedBy string `json:"approved_by"`
+}
+
+type ApprovePendingTransferTxResult struct {
+	PendingTransfer PendingTransfer `json:"pending_transfer"`
+	TransferTxResult
+}
+
+// ApprovePendingTransferTx executes a pending transfer and records who
+// approved it. When the transfer fails, e.g. for insufficient funds, nothing
+// is recorded and the transfer stays pending until it expires.
+func (store *SQLStore) ApprovePendingTransferTx(ctx context.Context, arg ApprovePendingTransferTxParams) (ApprovePendingTransferTxResult, error) {
+	var result ApprovePendingTransferTxResult
+
+	var err error
+	result.TransferTxResult, err = store.transferTx(ctx, nil, func(q *Queries) (TransferTxResult, error) {
+		// locking the pending transfer keeps it from being approved twice
+		pending, err := q.GetPendingTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+
+		if pending.Status != PendingTransferPendingApproval {
+			return TransferTxResult{}, fmt.Errorf("%w: transfer %d is %s", ErrPendingTransferDecided, pending.ID, pending.Status)
+		}
+		if !time.Now().Before(pending.ExpiresAt) {
+			return TransferTxResult{}, fmt.Errorf("%w: transfer %d", ErrPendingTransferExpired, pending.ID)
+		}
+
+		transferResult, err := transfer(ctx, q, TransferTxParams{
+			FromAccountID:     pending.FromAccountID,
+			ToAccountID:       pending.ToAccountID,
+			Amount:            pending.Amount,
+			Description:       pending.Description,
+			ExternalReference: pending.ExternalReference,
+			Metadata:          pending.Metadata,
+		})
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+
+		result.PendingTransfer, err = q.DecidePendingTransfer(ctx, DecidePendingTransferParams{
+			ID:         pending.ID,
+			Status:     PendingTransferApproved,
+			DecidedBy:  arg.ApprovedBy,
+			TransferID: &transferResult.Transfer.ID,
+		})
+		return transferResult, err
+	})
+
+	return result, err
+}
@@ -0,0 +1,280 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPendingTransfer(t *testing.T, from Account, to Account, amount int64, expiresAt time.Time) PendingTransfer {
+	store := NewStore(testDB)
+
+	arg := CreatePendingTransferTxParams{
+		CreatePendingTransferParams: CreatePendingTransferParams{
+			RequestedBy:   from.Owner,
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+			Currency:      from.Currency,
+			Description:   "invoice",
+			ExpiresAt:     expiresAt,
+		},
+	}
+
+	pending, err := store.CreatePendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, pending.ID)
+	require.Equal(t, from.Owner, pending.RequestedBy)
+	require.Equal(t, from.ID, pending.FromAccountID)
+	require.Equal(t, to.ID, pending.ToAccountID)
+	require.Equal(t, amount, pending.Amount)
+	require.Equal(t, PendingTransferPendingApproval, pending.Status)
+	require.JSONEq(t, `{}`, string(pending.Metadata))
+	require.Nil(t, pending.DecidedBy)
+	require.Nil(t, pending.TransferID)
+	require.WithinDuration(t, expiresAt, pending.ExpiresAt, time.Second)
+	return pending
+}
+
+func TestSetApprovalPolicyTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	approver1 := createRandomUser(t)
+	approver2 := createRandomUser(t)
+
+	threshold := int64(500)
+	result, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         account.ID,
+		ApprovalThreshold: &threshold,
+		Approvers:         []string{approver1.Username, approver2.Username},
+		RequestedBy:       account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, threshold, *result.Account.ApprovalThreshold)
+	require.Len(t, result.Approvers, 2)
+	require.Equal(t, ApprovalPolicyChangeApplied, result.Change.Status)
+	require.Equal(t, account.Owner, result.Change.RequestedBy)
+	require.Equal(t, threshold, *result.Change.ApprovalThreshold)
+	require.Equal(t, []string{approver1.Username, approver2.Username}, result.Change.Approvers)
+	require.Nil(t, result.Change.DecidedBy)
+
+	_, err = testQueries.GetAccountApprover(context.Background(), GetAccountApproverParams{
+		AccountID: account.ID,
+		Username:  approver1.Username,
+	})
+	require.NoError(t, err)
+
+	// the approvers are replaced, not added to
+	result, err = store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         account.ID,
+		ApprovalThreshold: &threshold,
+		Approvers:         []string{approver2.Username},
+		RequestedBy:       account.Owner,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Approvers, 1)
+
+	approvers, err := testQueries.ListAccountApprovers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, approvers, 1)
+	require.Equal(t, approver2.Username, approvers[0].Username)
+
+	_, err = testQueries.GetAccountApprover(context.Background(), GetAccountApproverParams{
+		AccountID: account.ID,
+		Username:  approver1.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	result, err = store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:   account.ID,
+		RequestedBy: account.Owner,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Account.ApprovalThreshold)
+	require.Empty(t, result.Approvers)
+	require.Nil(t, result.Change.ApprovalThreshold)
+	require.Empty(t, result.Change.Approvers)
+
+	// every change is recorded
+	changes, err := testQueries.ListApprovalPolicyChangesAfter(context.Background(), ListApprovalPolicyChangesAfterParams{
+		AccountID: account.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+}
+
+func TestApproveApprovalPolicyChangeTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	approver := createRandomUser(t)
+
+	threshold := int64(500)
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         account.ID,
+		ApprovalThreshold: &threshold,
+		Approvers:         []string{approver.Username},
+		RequestedBy:       account.Owner,
+	})
+	require.NoError(t, err)
+
+	change, err := testQueries.CreateApprovalPolicyChange(context.Background(), CreateApprovalPolicyChangeParams{
+		AccountID:   account.ID,
+		RequestedBy: account.Owner,
+		Status:      ApprovalPolicyChangePendingApproval,
+	})
+	require.NoError(t, err)
+	require.Nil(t, change.ApprovalThreshold)
+	require.Empty(t, change.Approvers)
+
+	// nothing changes until the change is approved
+	got, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, threshold, *got.ApprovalThreshold)
+
+	result, err := store.ApproveApprovalPolicyChangeTx(context.Background(), ApproveApprovalPolicyChangeTxParams{
+		ID:         change.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Account.ApprovalThreshold)
+	require.Empty(t, result.Approvers)
+	require.Equal(t, ApprovalPolicyChangeApplied, result.Change.Status)
+	require.Equal(t, approver.Username, *result.Change.DecidedBy)
+	require.NotNil(t, result.Change.DecidedAt)
+
+	_, err = store.ApproveApprovalPolicyChangeTx(context.Background(), ApproveApprovalPolicyChangeTxParams{
+		ID:         change.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrApprovalPolicyChangeDecided)
+}
+
+func TestApprovePendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createFundedAccount(t, 1000)
+	to := createFundedAccount(t, 0)
+	approver := createRandomUser(t)
+
+	pending := createRandomPendingTransfer(t, from, to, 600, time.Now().Add(time.Hour))
+
+	result, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{
+		ID:         pending.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferApproved, result.PendingTransfer.Status)
+	require.Equal(t, approver.Username, *result.PendingTransfer.DecidedBy)
+	require.NotNil(t, result.PendingTransfer.DecidedAt)
+	require.Equal(t, result.Transfer.ID, *result.PendingTransfer.TransferID)
+	require.Equal(t, int64(600), result.Transfer.Amount)
+	require.Equal(t, pending.Description, result.Transfer.Description)
+	require.Equal(t, int64(400), result.FromAccount.Balance)
+	require.Equal(t, int64(600), result.ToAccount.Balance)
+
+	_, err = store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{
+		ID:         pending.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrPendingTransferDecided)
+}
+
+func TestApprovePendingTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	from := createFundedAccount(t, 100)
+	to := createFundedAccount(t, 0)
+	approver := createRandomUser(t)
+
+	pending := createRandomPendingTransfer(t, from, to, 600, time.Now().Add(time.Hour))
+
+	_, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{
+		ID:         pending.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the transfer can still be approved once the account is funded
+	got, err := testQueries.GetPendingTransfer(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferPendingApproval, got.Status)
+}
+
+func TestApprovePendingTransferTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	from := createFundedAccount(t, 1000)
+	to := createFundedAccount(t, 0)
+	approver := createRandomUser(t)
+
+	pending := createRandomPendingTransfer(t, from, to, 600, time.Now().Add(-time.Minute))
+
+	_, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{
+		ID:         pending.ID,
+		ApprovedBy: approver.Username,
+	})
+	require.ErrorIs(t, err, ErrPendingTransferExpired)
+}
+
+func TestExpirePendingTransfers(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	now := time.Now()
+	expired := createRandomPendingTransfer(t, from, to, 10, now.Add(-time.Minute))
+	live := createRandomPendingTransfer(t, from, to, 10, now.Add(time.Hour))
+
+	n, err := testQueries.ExpirePendingTransfers(context.Background(), now)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	got, err := testQueries.GetPendingTransfer(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferExpired, got.Status)
+
+	got, err = testQueries.GetPendingTransfer(context.Background(), live.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferPendingApproval, got.Status)
+
+	// expired transfers cannot be decided anymore
+	_, err = testQueries.DecidePendingTransfer(context.Background(), DecidePendingTransferParams{
+		ID:        expired.ID,
+		Status:    PendingTransferRejected,
+		DecidedBy: to.Owner,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListPendingTransfersAfter(t *testing.T) {
+	store := NewStore(testDB)
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	approver := createRandomUser(t)
+
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:   from.ID,
+		Approvers:   []string{approver.Username},
+		RequestedBy: from.Owner,
+	})
+	require.NoError(t, err)
+
+	pending := createRandomPendingTransfer(t, from, to, 10, time.Now().Add(time.Hour))
+
+	for _, username := range []string{from.Owner, approver.Username} {
+		transfers, err := testQueries.ListPendingTransfersAfter(context.Background(), ListPendingTransfersAfterParams{
+			Username: username,
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, pending.ID, transfers[0].ID)
+	}
+
+	transfers, err := testQueries.ListPendingTransfersAfter(context.Background(), ListPendingTransfersAfterParams{
+		Username: to.Owner,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"simple_bank/util"
+)
+
+var ErrEmptyBatch = errors.New("batch has no transfers")
+
+type BatchTransferTxParams struct {
+	Transfers []TransferTxParams `json:"transfers"`
+	// Idempotency, when set, records the result of the whole batch under the
+	// client supplied key.
+	Idempotency *CreateIdempotencyKeyParams `json:"-"`
+}
+
+// BatchTransferLeg is the outcome of one transfer of a batch.
+type BatchTransferLeg struct {
+	Transfer   Transfer      `json:"transfer"`
+	FromEntry  Entry         `json:"from_entry"`
+	ToEntry    Entry         `json:"to_entry"`
+	Fee        *FeeBreakdown `json:"fee,omitempty"`
+	FeeEntries []Entry       `json:"fee_entries,omitempty"`
+}
+
+type BatchTransferTxResult struct {
+	// Transfers are in the order of the request.
+	Transfers []BatchTransferLeg `json:"transfers"`
+	// Accounts are the state of every account of the batch once all of its
+	// transfers are applied, in id order.
+	Accounts []Account `json:"accounts"`
+}
+
+// BatchTransferTx performs every transfer of arg in one transaction, so
+// either all of them are applied or none is. The accounts involved are locked
+// in id order up front, which keeps batches with overlapping accounts from
+// deadlocking each other or single transfers. Funds are checked against the
+// net amount each account moves over the whole batch, fees included.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+	if len(arg.Transfers) == 0 {
+		return result, ErrEmptyBatch
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if arg.Idempotency != nil {
+			replayed, err := claimIdempotencyKey(ctx, q, *arg.Idempotency, &result)
+			if err != nil || replayed {
+				return err
+			}
+		}
+
+		var err error
+		result, err = batchTransfer(ctx, q, arg.Transfers)
+		if err != nil || arg.Idempotency == nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, *arg.Idempotency, result)
+	})
+
+	if isOverdraftViolation(err) {
+		err = fmt.Errorf("%w: %v", ErrInsufficientFunds, err)
+	}
+	return result, err
+}
+
+func batchTransfer(ctx context.Context, q *Queries, transfers []TransferTxParams) (result BatchTransferTxResult, err error) {
+	ids := make([]int64, 0, 2*len(transfers))
+	for _, transfer := range transfers {
+		ids = append(ids, transfer.FromAccountID, transfer.ToAccountID)
+	}
+
+	locked, err := lockAccountSet(ctx, q, ids...)
+	if err != nil {
+		return
+	}
+
+	// net moves the balance of every account of the batch by, fees included
+	net := make(map[int64]util.Money)
+	move := func(accountID int64, amount int64) error {
+		account := locked[accountID]
+		moved, ok := net[accountID]
+		if !ok {
+			moved = util.NewMoney(0, account.Currency)
+		}
+
+		moved, err := moved.Add(util.NewMoney(amount, account.Currency))
+		if err != nil {
+			return fmt.Errorf("account %d: %w", accountID, err)
+		}
+		net[accountID] = moved
+		return nil
+	}
+
+	fees := make([]*FeeBreakdown, len(transfers))
+	for i, transfer := range transfers {
+		fees[i], err = transferFee(ctx, q, locked[transfer.FromAccountID], transfer.Amount)
+		if err != nil {
+			return
+		}
+
+		if fees[i] != nil {
+			if err = move(transfer.FromAccountID, -fees[i].Amount); err != nil {
+				return
+			}
+		}
+		if err = move(transfer.FromAccountID, -transfer.Amount); err != nil {
+			return
+		}
+		if err = move(transfer.ToAccountID, transfer.Amount); err != nil {
+			return
+		}
+	}
+
+	amounts := make(map[int64]int64, len(net))
+	for id, moved := range net {
+		if err = checkBalance(locked[id], moved.Amount); err != nil {
+			return
+		}
+		amounts[id] = moved.Amount
+	}
+
+	// the revenue accounts are credited after the accounts of the batch, as
+	// in single transfers
+	revenue := make(map[int64]int64)
+	result.Transfers = make([]BatchTransferLeg, len(transfers))
+	for i, transfer := range transfers {
+		leg := &result.Transfers[i]
+
+		leg.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID:     transfer.FromAccountID,
+			ToAccountID:       transfer.ToAccountID,
+			Amount:            transfer.Amount,
+			ToAmount:          transfer.Amount,
+			Description:       transfer.Description,
+			ExternalReference: transfer.ExternalReference,
+			Metadata:          transfer.Metadata,
+		})
+		if err != nil {
+			return
+		}
+
+		if err = linkRiskDecision(ctx, q, transfer.RiskDecisionID, leg.Transfer.ID); err != nil {
+			return
+		}
+
+		leg.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   transfer.FromAccountID,
+			Amount:      -transfer.Amount,
+			TransferID:  &leg.Transfer.ID,
+			Description: transfer.Description,
+		})
+		if err != nil {
+			return
+		}
+
+		leg.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   transfer.ToAccountID,
+			Amount:      transfer.Amount,
+			TransferID:  &leg.Transfer.ID,
+			Description: transfer.Description,
+		})
+		if err != nil {
+			return
+		}
+
+		if fees[i] != nil {
+			var revenueAccountID int64
+			leg.Fee = fees[i]
+			leg.FeeEntries, revenueAccountID, err = postFee(ctx, q, leg.Transfer, *fees[i])
+			if err != nil {
+				return
+			}
+			revenue[revenueAccountID] += fees[i].Amount
+		}
+	}
+
+	accounts, err := addBalances(ctx, q, amounts)
+	if err != nil {
+		return
+	}
+
+	if _, err = addBalances(ctx, q, revenue); err != nil {
+		return
+	}
+
+	result.Accounts = make([]Account, 0, len(accounts))
+	for _, account := range accounts {
+		result.Accounts = append(result.Accounts, account)
+	}
+	sort.Slice(result.Accounts, func(i, j int) bool { return result.Accounts[i].ID < result.Accounts[j].ID })
+	return
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	payroll := createFundedAccount(t, 1000)
+	account1 := createFundedAccount(t, 0)
+	account2 := createFundedAccount(t, 0)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: payroll.ID, ToAccountID: account1.ID, Amount: 300},
+			{FromAccountID: payroll.ID, ToAccountID: account2.ID, Amount: 700},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Transfers, 2)
+
+	for i, leg := range result.Transfers {
+		require.NotZero(t, leg.Transfer.ID)
+		require.Equal(t, payroll.ID, leg.Transfer.FromAccountID)
+		require.Equal(t, -leg.Transfer.Amount, leg.FromEntry.Amount)
+		require.Equal(t, leg.Transfer.Amount, leg.ToEntry.Amount)
+		require.Equal(t, &leg.Transfer.ID, leg.ToEntry.TransferID)
+		if i > 0 {
+			require.Greater(t, leg.Transfer.ID, result.Transfers[i-1].Transfer.ID)
+		}
+	}
+
+	balances := make(map[int64]int64)
+	for _, account := range result.Accounts {
+		balances[account.ID] = account.Balance
+	}
+	require.Equal(t, map[int64]int64{payroll.ID: 0, account1.ID: 300, account2.ID: 700}, balances)
+}
+
+func TestBatchTransferTxRollsBack(t *testing.T) {
+	store := NewStore(testDB)
+
+	payroll := createFundedAccount(t, 1000)
+	account1 := createFundedAccount(t, 0)
+	account2 := createFundedAccount(t, 0)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: payroll.ID, ToAccountID: account1.ID, Amount: 300},
+			{FromAccountID: payroll.ID, ToAccountID: account2.ID, Amount: 701},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	for _, account := range []Account{payroll, account1, account2} {
+		got, err := store.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, got.Balance)
+	}
+}
+
+func TestBatchTransferTxDeadlock(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	account3 := createFundedAccount(t, 1000)
+
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		transfers := []TransferTxParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+			{FromAccountID: account2.ID, ToAccountID: account3.ID, Amount: 10},
+			{FromAccountID: account3.ID, ToAccountID: account1.ID, Amount: 10},
+		}
+		if i%2 == 1 {
+			transfers[0], transfers[2] = transfers[2], transfers[0]
+		}
+
+		go func() {
+			_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{Transfers: transfers})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for _, account := range []Account{account1, account2, account3} {
+		got, err := store.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, got.Balance)
+	}
+}
+
+func TestBatchTransferTxEmpty(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{})
+	require.ErrorIs(t, err, ErrEmptyBatch)
+}
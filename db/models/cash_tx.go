@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SystemUsername owns the per-currency cash accounts. Every deposit or
+// withdrawal posts an entry against the cash account of the same currency,
+// so the entries of the whole ledger always sum up to zero.
+const SystemUsername = "system"
+
+// IsSystemUsername reports whether username is one of the users owning the
+// internal accounts that balance the ledger.
+func IsSystemUsername(username string) bool {
+	switch username {
+	case SystemUsername, FXUsername, InterestUsername, FeesUsername:
+		return true
+	}
+	return false
+}
+
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+type CashTxResult struct {
+	Account   Account `json:"account"`
+	Entry     Entry   `json:"entry"`
+	CashEntry Entry   `json:"cash_entry"`
+}
+
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	return store.cashTx(ctx, arg.AccountID, arg.Amount)
+}
+
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	return store.cashTx(ctx, arg.AccountID, -arg.Amount)
+}
+
+func (store *SQLStore) cashTx(ctx context.Context, accountID int64, amount int64) (CashTxResult, error) {
+	var result CashTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		cashAccount, err := getCashAccount(ctx, q, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		locked, err := lockAccountSet(ctx, q, account.ID, cashAccount.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = checkBalance(locked[account.ID], amount); err != nil {
+			return err
+		}
+		if err = checkBalance(locked[cashAccount.ID], -amount); err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.CashEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: cashAccount.ID,
+			Amount:    -amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		if account.ID < cashAccount.ID {
+			result.Account, _, err = addMoney(ctx, q, account.ID, amount, cashAccount.ID, -amount)
+		} else {
+			_, result.Account, err = addMoney(ctx, q, cashAccount.ID, -amount, account.ID, amount)
+		}
+		return err
+	})
+
+	if isOverdraftViolation(err) {
+		err = fmt.Errorf("%w: %v", ErrInsufficientFunds, err)
+	}
+	return result, err
+}
+
+// getCashAccount returns the system cash account for currency, creating it
+// the first time the currency is used.
+func getCashAccount(ctx context.Context, q *Queries, currency string) (Account, error) {
+	return getSystemAccount(ctx, q, SystemUsername, currency)
+}
+
+// getSystemAccount returns the account of a system user in currency, creating
+// it the first time the currency is used.
+func getSystemAccount(ctx context.Context, q *Queries, owner string, currency string) (Account, error) {
+	arg := GetAccountByOwnerAndCurrencyParams{
+		Owner:    owner,
+		Currency: currency,
+	}
+
+	account, err := q.GetAccountByOwnerAndCurrency(ctx, arg)
+	if err != sql.ErrNoRows {
+		return account, err
+	}
+
+	err = q.CreateSystemAccount(ctx, CreateSystemAccountParams{
+		Owner:    owner,
+		Currency: currency,
+	})
+	if err != nil {
+		return Account{}, err
+	}
+	return q.GetAccountByOwnerAndCurrency(ctx, arg)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	cashAccount, err := getCashAccount(context.Background(), testQueries, account.Currency)
+	require.NoError(t, err)
+	require.Equal(t, SystemUsername, cashAccount.Owner)
+
+	n := 5
+	amount := int64(10)
+
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.DepositTx(context.Background(), CashTxParams{
+				AccountID: account.ID,
+				Amount:    amount,
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	result, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, result.Account.ID)
+	require.Equal(t, account.Balance+int64(n+1)*amount, result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, amount, result.Entry.Amount)
+	require.Equal(t, cashAccount.ID, result.CashEntry.AccountID)
+	require.Equal(t, -amount, result.CashEntry.Amount)
+	require.Zero(t, result.Entry.Amount+result.CashEntry.Amount)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account := createFundedAccount(t, 3*amount)
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.WithdrawTx(context.Background(), CashTxParams{
+				AccountID: account.ID,
+				Amount:    amount,
+			})
+			errs <- err
+		}()
+	}
+
+	failed := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err != nil {
+			require.True(t, errors.Is(err, ErrInsufficientFunds), err)
+			failed++
+		}
+	}
+	require.Equal(t, 2, failed)
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount.Balance)
+}
+
+func TestGetCashAccountCreatesOnce(t *testing.T) {
+	cashAccount1, err := getCashAccount(context.Background(), testQueries, "GBP")
+	require.NoError(t, err)
+
+	cashAccount2, err := getCashAccount(context.Background(), testQueries, "GBP")
+	require.NoError(t, err)
+	require.Equal(t, cashAccount1.ID, cashAccount2.ID)
+}
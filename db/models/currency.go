@@ -0,0 +1,39 @@
+package db
+
+import "context"
+
+// listCurrencies
+const listCurrencies = `
+SELECT code, numeric_code, minor_units, symbol, enabled FROM currencies
+ORDER BY code
+`
+
+func (q *Queries) ListCurrencies(ctx context.Context) ([]Currency, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrencies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currencies := []Currency{}
+	for rows.Next() {
+		var currency Currency
+		if err := rows.Scan(
+			&currency.Code,
+			&currency.NumericCode,
+			&currency.MinorUnits,
+			&currency.Symbol,
+			&currency.Enabled,
+		); err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return currencies, nil
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	byCode := make(map[string]Currency)
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	require.True(t, byCode["USD"].Enabled)
+	require.Equal(t, int32(2), byCode["USD"].MinorUnits)
+	require.Equal(t, int32(0), byCode["JPY"].MinorUnits)
+	require.Equal(t, int32(3), byCode["KWD"].MinorUnits)
+	require.Equal(t, "414", byCode["KWD"].NumericCode)
+}
@@ -1,36 +1,45 @@
 package db
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // createEntry
 const createEntry = `
 INSERT INTO entries (
 	account_id,
-	amount
+	amount,
+	transfer_id,
+	description
 ) VALUES (
-	$1, $2
-) RETURNING id, account_id, amount, created_at
+	$1, $2, $3, $4
+) RETURNING id, account_id, amount, transfer_id, description, created_at
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID   int64  `json:"account_id"`
+	Amount      int64  `json:"amount"`
+	TransferID  *int64 `json:"transfer_id"`
+	Description string `json:"description"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount, arg.TransferID, arg.Description)
 	var entry Entry
 	err := row.Scan(
 		&entry.ID,
 		&entry.AccountID,
 		&entry.Amount,
+		&entry.TransferID,
+		&entry.Description,
 		&entry.CreatedAt,
 	)
 	return entry, err
 }
 
 const getEntry = `
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, transfer_id, description, created_at FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -41,13 +50,15 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&entry.ID,
 		&entry.AccountID,
 		&entry.Amount,
+		&entry.TransferID,
+		&entry.Description,
 		&entry.CreatedAt,
 	)
 	return entry, err
 }
 
 const listEntries = `
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, transfer_id, description, created_at FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
@@ -74,6 +85,8 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&entry.ID,
 			&entry.AccountID,
 			&entry.Amount,
+			&entry.TransferID,
+			&entry.Description,
 			&entry.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -88,3 +101,185 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 	}
 	return entries, nil
 }
+
+// getStatementBalances
+const getStatementBalances = `
+SELECT
+	a.balance - COALESCE(SUM(e.amount) FILTER (WHERE e.created_at >= $2), 0)::bigint AS opening_balance,
+	a.balance - COALESCE(SUM(e.amount) FILTER (WHERE e.created_at >= $3), 0)::bigint AS closing_balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= $2
+WHERE a.id = $1
+GROUP BY a.id
+`
+
+type GetStatementBalancesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+type GetStatementBalancesRow struct {
+	OpeningBalance int64 `json:"opening_balance"`
+	ClosingBalance int64 `json:"closing_balance"`
+}
+
+// GetStatementBalances derives the balance of an account at the start and end
+// of [FromTime, ToTime) by subtracting later entries from the current balance.
+func (q *Queries) GetStatementBalances(ctx context.Context, arg GetStatementBalancesParams) (GetStatementBalancesRow, error) {
+	row := q.db.QueryRowContext(ctx, getStatementBalances, arg.AccountID, arg.FromTime, arg.ToTime)
+	var i GetStatementBalancesRow
+	err := row.Scan(&i.OpeningBalance, &i.ClosingBalance)
+	return i, err
+}
+
+// listStatementEntries
+const listStatementEntries = `
+WITH opening AS (
+	SELECT a.balance - COALESCE(SUM(e.amount), 0)::bigint AS balance
+	FROM accounts a
+	LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= $2
+	WHERE a.id = $1
+	GROUP BY a.id
+)
+SELECT
+	e.id, e.account_id, e.amount, e.transfer_id, e.description, e.created_at,
+	CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END AS counterparty_account_id,
+	((SELECT balance FROM opening) + SUM(e.amount) OVER (ORDER BY e.created_at, e.id))::bigint AS running_balance
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE e.account_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+ORDER BY e.created_at, e.id
+LIMIT $4
+OFFSET $5
+`
+
+type ListStatementEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+	Limit     int32     `json:"limit"`
+	Offset    int32     `json:"offset"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                    int64     `json:"id"`
+	AccountID             int64     `json:"account_id"`
+	Amount                int64     `json:"amount"`
+	TransferID            *int64    `json:"transfer_id"`
+	Description           string    `json:"description"`
+	CreatedAt             time.Time `json:"created_at"`
+	CounterpartyAccountID *int64    `json:"counterparty_account_id"`
+	RunningBalance        int64     `json:"running_balance"`
+}
+
+// ListStatementEntries returns the entries of an account in [FromTime, ToTime)
+// together with the account balance right after each entry.
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.TransferID,
+			&i.Description,
+			&i.CreatedAt,
+			&i.CounterpartyAccountID,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// listStatementEntriesAfter
+const listStatementEntriesAfter = `
+WITH opening AS (
+	SELECT a.balance - COALESCE(SUM(e.amount), 0)::bigint AS balance
+	FROM accounts a
+	LEFT JOIN entries e ON e.account_id = a.id AND (e.created_at, e.id) > ($3, $4)
+	WHERE a.id = $1
+	GROUP BY a.id
+)
+SELECT
+	e.id, e.account_id, e.amount, e.transfer_id, e.description, e.created_at,
+	CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END AS counterparty_account_id,
+	((SELECT balance FROM opening) + SUM(e.amount) OVER (ORDER BY e.created_at, e.id))::bigint AS running_balance
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE e.account_id = $1 AND (e.created_at, e.id) > ($3, $4) AND e.created_at < $2
+ORDER BY e.created_at, e.id
+LIMIT $5
+`
+
+type ListStatementEntriesAfterParams struct {
+	AccountID int64     `json:"account_id"`
+	ToTime    time.Time `json:"to_time"`
+	AfterTime time.Time `json:"after_time"`
+	AfterID   int64     `json:"after_id"`
+	Limit     int32     `json:"limit"`
+}
+
+// ListStatementEntriesAfter is the keyset variant of ListStatementEntries. It
+// returns the entries ordered after (AfterTime, AfterID) and before ToTime.
+// Passing the start of the period with AfterID 0 returns the first page.
+func (q *Queries) ListStatementEntriesAfter(ctx context.Context, arg ListStatementEntriesAfterParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEntriesAfter,
+		arg.AccountID,
+		arg.ToTime,
+		arg.AfterTime,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.TransferID,
+			&i.Description,
+			&i.CreatedAt,
+			&i.CounterpartyAccountID,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -65,3 +65,106 @@ func TestListEntries(t *testing.T) {
 	}
 
 }
+
+func TestListStatementEntries(t *testing.T) {
+	account := createRandomAccount(t)
+
+	n := 5
+	entries := make([]Entry, n)
+	for i := 0; i < n; i++ {
+		entries[i] = createRandomEntry(t, account)
+		_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+			ID:     account.ID,
+			Amount: entries[i].Amount,
+		})
+		require.NoError(t, err)
+	}
+
+	// the statement covers every entry except the first and the last one
+	from := entries[1].CreatedAt
+	to := entries[n-1].CreatedAt
+	opening := account.Balance + entries[0].Amount
+
+	balances, err := testQueries.GetStatementBalances(context.Background(), GetStatementBalancesParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    to,
+	})
+	require.NoError(t, err)
+	require.Equal(t, opening, balances.OpeningBalance)
+
+	rows, err := testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    to,
+		Limit:     int32(n),
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, n-2)
+
+	runningBalance := opening
+	for i, row := range rows {
+		entry := entries[i+1]
+		runningBalance += entry.Amount
+
+		require.Equal(t, entry.ID, row.ID)
+		require.Equal(t, entry.Amount, row.Amount)
+		require.Equal(t, runningBalance, row.RunningBalance)
+	}
+	require.Equal(t, runningBalance, balances.ClosingBalance)
+}
+
+func TestListStatementEntriesAfter(t *testing.T) {
+	account := createRandomAccount(t)
+	from := time.Now()
+
+	n := 5
+	for i := 0; i < n; i++ {
+		entry := createRandomEntry(t, account)
+		_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+			ID:     account.ID,
+			Amount: entry.Amount,
+		})
+		require.NoError(t, err)
+	}
+	to := time.Now().Add(time.Second)
+
+	all, err := testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    to,
+		Limit:     int32(n),
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, all, n)
+
+	// paging by cursor yields the same rows and running balances
+	var paged []ListStatementEntriesRow
+	arg := ListStatementEntriesAfterParams{
+		AccountID: account.ID,
+		ToTime:    to,
+		AfterTime: from,
+		AfterID:   0,
+		Limit:     2,
+	}
+	for {
+		page, err := testQueries.ListStatementEntriesAfter(context.Background(), arg)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		paged = append(paged, page...)
+
+		last := page[len(page)-1]
+		arg.AfterTime = last.CreatedAt
+		arg.AfterID = last.ID
+	}
+
+	require.Len(t, paged, n)
+	for i := range all {
+		require.Equal(t, all[i].ID, paged[i].ID)
+		require.Equal(t, all[i].RunningBalance, paged[i].RunningBalance)
+	}
+}
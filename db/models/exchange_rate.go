@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// upsertExchangeRate
+const upsertExchangeRate = `
+INSERT INTO exchange_rates (
+	base_currency,
+	quote_currency,
+	rate
+) VALUES (
+	$1, $2, $3
+) ON CONFLICT (base_currency, quote_currency) DO UPDATE
+SET rate = EXCLUDED.rate, updated_at = now()
+RETURNING base_currency, quote_currency, rate, updated_at
+`
+
+type UpsertExchangeRateParams struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	Rate          string `json:"rate"`
+}
+
+func (q *Queries) UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, upsertExchangeRate, arg.BaseCurrency, arg.QuoteCurrency, arg.Rate)
+	var exchangeRate ExchangeRate
+	err := row.Scan(
+		&exchangeRate.BaseCurrency,
+		&exchangeRate.QuoteCurrency,
+		&exchangeRate.Rate,
+		&exchangeRate.UpdatedAt,
+	)
+	return exchangeRate, err
+}
+
+// getExchangeRate
+const getExchangeRate = `
+SELECT base_currency, quote_currency, rate, updated_at FROM exchange_rates
+WHERE base_currency = $1 AND quote_currency = $2 LIMIT 1
+`
+
+type GetExchangeRateParams struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+}
+
+func (q *Queries) GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, getExchangeRate, arg.BaseCurrency, arg.QuoteCurrency)
+	var exchangeRate ExchangeRate
+	err := row.Scan(
+		&exchangeRate.BaseCurrency,
+		&exchangeRate.QuoteCurrency,
+		&exchangeRate.Rate,
+		&exchangeRate.UpdatedAt,
+	)
+	return exchangeRate, err
+}
+
+// createExchangeQuote
+const createExchangeQuote = `
+INSERT INTO exchange_quotes (
+	id,
+	username,
+	base_currency,
+	quote_currency,
+	rate,
+	spread,
+	expires_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+) RETURNING id, username, base_currency, quote_currency, rate, spread, expires_at, created_at
+`
+
+type CreateExchangeQuoteParams struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	Spread        string    `json:"spread"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateExchangeQuote(ctx context.Context, arg CreateExchangeQuoteParams) (ExchangeQuote, error) {
+	row := q.db.QueryRowContext(ctx, createExchangeQuote,
+		arg.ID,
+		arg.Username,
+		arg.BaseCurrency,
+		arg.QuoteCurrency,
+		arg.Rate,
+		arg.Spread,
+		arg.ExpiresAt,
+	)
+	var quote ExchangeQuote
+	err := row.Scan(
+		&quote.ID,
+		&quote.Username,
+		&quote.BaseCurrency,
+		&quote.QuoteCurrency,
+		&quote.Rate,
+		&quote.Spread,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+	)
+	return quote, err
+}
+
+// getExchangeQuote
+const getExchangeQuote = `
+SELECT id, username, base_currency, quote_currency, rate, spread, expires_at, created_at FROM exchange_quotes
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetExchangeQuote(ctx context.Context, id uuid.UUID) (ExchangeQuote, error) {
+	row := q.db.QueryRowContext(ctx, getExchangeQuote, id)
+	var quote ExchangeQuote
+	err := row.Scan(
+		&quote.ID,
+		&quote.Username,
+		&quote.BaseCurrency,
+		&quote.QuoteCurrency,
+		&quote.Rate,
+		&quote.Spread,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+	)
+	return quote, err
+}
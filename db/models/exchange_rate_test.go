@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertExchangeRate(t *testing.T) {
+	arg := UpsertExchangeRateParams{
+		BaseCurrency:  "AAA",
+		QuoteCurrency: "BBB",
+		Rate:          "1.25",
+	}
+
+	rate1, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BaseCurrency, rate1.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, rate1.QuoteCurrency)
+	require.Equal(t, arg.Rate, rate1.Rate)
+
+	arg.Rate = "1.5"
+	rate2, err := testQueries.UpsertExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Rate, rate2.Rate)
+	require.False(t, rate2.UpdatedAt.Before(rate1.UpdatedAt))
+
+	rate3, err := testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		BaseCurrency:  arg.BaseCurrency,
+		QuoteCurrency: arg.QuoteCurrency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate2.Rate, rate3.Rate)
+}
+
+func TestGetExchangeRateNotFound(t *testing.T) {
+	_, err := testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		BaseCurrency:  "AAA",
+		QuoteCurrency: "ZZZ",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func createRandomExchangeQuote(t *testing.T, user User, base string, quote string) ExchangeQuote {
+	arg := CreateExchangeQuoteParams{
+		ID:            uuid.New(),
+		Username:      user.Username,
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          "1.1",
+		Spread:        "0.005",
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	exchangeQuote, err := testQueries.CreateExchangeQuote(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, exchangeQuote.ID)
+	require.Equal(t, arg.Username, exchangeQuote.Username)
+	require.Equal(t, arg.BaseCurrency, exchangeQuote.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, exchangeQuote.QuoteCurrency)
+	require.Equal(t, arg.Rate, exchangeQuote.Rate)
+	require.Equal(t, arg.Spread, exchangeQuote.Spread)
+	require.WithinDuration(t, arg.ExpiresAt, exchangeQuote.ExpiresAt, time.Second)
+	require.NotZero(t, exchangeQuote.CreatedAt)
+
+	return exchangeQuote
+}
+
+func TestGetExchangeQuote(t *testing.T) {
+	user := createRandomUser(t)
+	quote1 := createRandomExchangeQuote(t, user, "USD", "EUR")
+
+	quote2, err := testQueries.GetExchangeQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.ID, quote2.ID)
+	require.Equal(t, quote1.Rate, quote2.Rate)
+	require.WithinDuration(t, quote1.ExpiresAt, quote2.ExpiresAt, time.Second)
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"simple_bank/util"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// FXUsername owns the per-currency position accounts. A cross-currency
+// transfer debits the sender into the position account of its currency and
+// credits the recipient out of the position account of the other currency, so
+// the entries of each currency still sum up to zero.
+const FXUsername = "fx"
+
+var (
+	ErrQuoteAlreadyUsed = errors.New("exchange quote was already used")
+	ErrQuoteExpired     = errors.New("exchange quote has expired")
+)
+
+type ExchangeTransferTxParams struct {
+	TransferTxParams
+	// ToAmount is credited to the recipient in the currency of its account.
+	ToAmount       int64     `json:"to_amount"`
+	ExchangeRate   string    `json:"exchange_rate"`
+	ExchangeSpread string    `json:"exchange_spread"`
+	QuoteID        uuid.UUID `json:"quote_id"`
+}
+
+func (store *SQLStore) ExchangeTransferTx(ctx context.Context, arg ExchangeTransferTxParams) (TransferTxResult, error) {
+	result, err := store.transferTx(ctx, arg.Idempotency, func(q *Queries) (TransferTxResult, error) {
+		return exchangeTransfer(ctx, q, arg)
+	})
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "transfers_quote_id_key" {
+		err = ErrQuoteAlreadyUsed
+	}
+	return result, err
+}
+
+// exchangeTransfer moves money between two accounts of different currencies
+// using q, which must be bound to an open transaction. The fee of the from
+// account is charged on top, in its currency, as for any other transfer.
+func exchangeTransfer(ctx context.Context, q *Queries, arg ExchangeTransferTxParams) (result TransferTxResult, err error) {
+	// the quote may have expired since the caller loaded it
+	quote, err := q.GetExchangeQuote(ctx, arg.QuoteID)
+	if err != nil {
+		return
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		err = ErrQuoteExpired
+		return
+	}
+
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return
+	}
+	toAccount, err := q.GetAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return
+	}
+
+	fromPosition, err := getSystemAccount(ctx, q, FXUsername, fromAccount.Currency)
+	if err != nil {
+		return
+	}
+	toPosition, err := getSystemAccount(ctx, q, FXUsername, toAccount.Currency)
+	if err != nil {
+		return
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return
+	}
+
+	locked, err := lockAccountSet(ctx, q, fromAccount.ID, toAccount.ID, fromPosition.ID, toPosition.ID)
+	if err != nil {
+		return
+	}
+
+	debit := util.NewMoney(arg.Amount, fromAccount.Currency)
+	if fee != nil {
+		debit, err = debit.Add(util.NewMoney(fee.Amount, fee.Currency))
+		if err != nil {
+			return
+		}
+	}
+
+	if err = checkBalance(locked[fromAccount.ID], -debit.Amount); err != nil {
+		return
+	}
+	if err = checkBalance(locked[toAccount.ID], arg.ToAmount); err != nil {
+		return
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:     arg.FromAccountID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		ToAmount:          arg.ToAmount,
+		ExchangeRate:      &arg.ExchangeRate,
+		ExchangeSpread:    &arg.ExchangeSpread,
+		QuoteID:           &arg.QuoteID,
+		Description:       arg.Description,
+		ExternalReference: arg.ExternalReference,
+		Metadata:          arg.Metadata,
+	})
+	if err != nil {
+		return
+	}
+
+	postings := []CreateEntryParams{
+		{AccountID: fromAccount.ID, Amount: -arg.Amount},
+		{AccountID: toAccount.ID, Amount: arg.ToAmount},
+		{AccountID: fromPosition.ID, Amount: arg.Amount},
+		{AccountID: toPosition.ID, Amount: -arg.ToAmount},
+	}
+
+	entries := make([]Entry, len(postings))
+	amounts := make(map[int64]int64)
+	for i, posting := range postings {
+		posting.TransferID = &result.Transfer.ID
+		posting.Description = arg.Description
+		entries[i], err = q.CreateEntry(ctx, posting)
+		if err != nil {
+			return
+		}
+		amounts[posting.AccountID] += posting.Amount
+	}
+	result.FromEntry, result.ToEntry, result.ExchangeEntries = entries[0], entries[1], entries[2:]
+
+	var revenueAccountID int64
+	if fee != nil {
+		result.Fee = fee
+		result.FeeEntries, revenueAccountID, err = postFee(ctx, q, result.Transfer, *fee)
+		if err != nil {
+			return
+		}
+		amounts[fromAccount.ID] -= fee.Amount
+	}
+
+	accounts, err := addBalances(ctx, q, amounts)
+	if err != nil {
+		return
+	}
+	result.FromAccount = accounts[fromAccount.ID]
+	result.ToAccount = accounts[toAccount.ID]
+
+	// as in postTransfer, the revenue account is updated last
+	if fee != nil {
+		_, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			Amount: fee.Amount,
+			ID:     revenueAccountID,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	err = linkRiskDecision(ctx, q, arg.RiskDecisionID, result.Transfer.ID)
+	return
+}
+
+func (store *SQLStore) UpsertExchangeRatesTx(ctx context.Context, arg []UpsertExchangeRateParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		for _, rate := range arg {
+			if _, err := q.UpsertExchangeRate(ctx, rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockAccountSet locks every account in id order, so concurrent transactions
+// touching overlapping sets of accounts cannot deadlock.
+func lockAccountSet(ctx context.Context, q *Queries, accountIDs ...int64) (map[int64]Account, error) {
+	ids := make([]int64, len(accountIDs))
+	copy(ids, accountIDs)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := make(map[int64]Account, len(ids))
+	for _, id := range ids {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
+
+// addBalances adds amounts to the balance of each account, in id order.
+func addBalances(ctx context.Context, q *Queries, amounts map[int64]int64) (map[int64]Account, error) {
+	ids := make([]int64, 0, len(amounts))
+	for id := range amounts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := make(map[int64]Account, len(ids))
+	for _, id := range ids {
+		account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			Amount: amounts[id],
+			ID:     id,
+		})
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
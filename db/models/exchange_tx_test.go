@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"simple_bank/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createFundedAccountInCurrency(t *testing.T, currency string, balance int64) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: currency,
+		Product:  ProductChecking,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestExchangeTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccountInCurrency(t, util.USD, 1000)
+	toAccount := createFundedAccountInCurrency(t, util.EUR, 0)
+	quote := createRandomExchangeQuote(t, createRandomUser(t), util.USD, util.EUR)
+
+	fromPosition, err := getSystemAccount(context.Background(), testQueries, FXUsername, util.USD)
+	require.NoError(t, err)
+	toPosition, err := getSystemAccount(context.Background(), testQueries, FXUsername, util.EUR)
+	require.NoError(t, err)
+
+	arg := ExchangeTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        100,
+		},
+		ToAmount:       90,
+		ExchangeRate:   quote.Rate,
+		ExchangeSpread: quote.Spread,
+		QuoteID:        quote.ID,
+	}
+
+	result, err := store.ExchangeTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	transfer := result.Transfer
+	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.ToAmount, transfer.ToAmount)
+	require.Equal(t, quote.Rate, *transfer.ExchangeRate)
+	require.Equal(t, quote.Spread, *transfer.ExchangeSpread)
+	require.Equal(t, quote.ID, *transfer.QuoteID)
+
+	require.Equal(t, -arg.Amount, result.FromEntry.Amount)
+	require.Equal(t, arg.ToAmount, result.ToEntry.Amount)
+	require.Len(t, result.ExchangeEntries, 2)
+	require.Equal(t, fromPosition.ID, result.ExchangeEntries[0].AccountID)
+	require.Equal(t, arg.Amount, result.ExchangeEntries[0].Amount)
+	require.Equal(t, toPosition.ID, result.ExchangeEntries[1].AccountID)
+	require.Equal(t, -arg.ToAmount, result.ExchangeEntries[1].Amount)
+
+	// entries of each currency sum up to zero
+	require.Zero(t, result.FromEntry.Amount+result.ExchangeEntries[0].Amount)
+	require.Zero(t, result.ToEntry.Amount+result.ExchangeEntries[1].Amount)
+
+	require.Equal(t, fromAccount.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, toAccount.Balance+arg.ToAmount, result.ToAccount.Balance)
+
+	// a quote fixes the rate of a single transfer
+	_, err = store.ExchangeTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteAlreadyUsed)
+}
+
+func TestExchangeTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccountInCurrency(t, util.USD, 50)
+	toAccount := createFundedAccountInCurrency(t, util.CAD, 0)
+	quote := createRandomExchangeQuote(t, createRandomUser(t), util.USD, util.CAD)
+
+	_, err := store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        100,
+		},
+		ToAmount:       125,
+		ExchangeRate:   quote.Rate,
+		ExchangeSpread: quote.Spread,
+		QuoteID:        quote.ID,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := store.GetAccount(context.Background(), toAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount.Balance)
+}
+
+func TestExchangeTransferTxExpiredQuote(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccountInCurrency(t, util.USD, 1000)
+	toAccount := createFundedAccountInCurrency(t, util.EUR, 0)
+	quote, err := testQueries.CreateExchangeQuote(context.Background(), CreateExchangeQuoteParams{
+		ID:            uuid.New(),
+		Username:      createRandomUser(t).Username,
+		BaseCurrency:  util.USD,
+		QuoteCurrency: util.EUR,
+		Rate:          "0.9",
+		Spread:        "0.005",
+		ExpiresAt:     time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	_, err = store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        100,
+		},
+		ToAmount:       90,
+		ExchangeRate:   quote.Rate,
+		ExchangeSpread: quote.Spread,
+		QuoteID:        quote.ID,
+	})
+	require.ErrorIs(t, err, ErrQuoteExpired)
+
+	updatedAccount, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, updatedAccount.Balance)
+}
+
+func TestUpsertExchangeRatesTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	err := store.UpsertExchangeRatesTx(context.Background(), []UpsertExchangeRateParams{
+		{BaseCurrency: "CCC", QuoteCurrency: "DDD", Rate: "2"},
+		{BaseCurrency: "DDD", QuoteCurrency: "CCC", Rate: "0.5"},
+	})
+	require.NoError(t, err)
+
+	rate, err := store.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		BaseCurrency:  "DDD",
+		QuoteCurrency: "CCC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0.5", rate.Rate)
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// createIdempotencyKey
+const createIdempotencyKey = `
+INSERT INTO idempotency_keys (
+	key,
+	username,
+	request_hash
+) VALUES (
+	$1, $2, $3
+) ON CONFLICT (username, key) DO NOTHING
+RETURNING key, username, request_hash, response, created_at
+`
+
+type CreateIdempotencyKeyParams struct {
+	Key         string `json:"key"`
+	Username    string `json:"username"`
+	RequestHash string `json:"request_hash"`
+}
+
+// CreateIdempotencyKey returns sql.ErrNoRows when the user already used the key.
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, createIdempotencyKey, arg.Key, arg.Username, arg.RequestHash)
+	var idempotencyKey IdempotencyKey
+	err := row.Scan(
+		&idempotencyKey.Key,
+		&idempotencyKey.Username,
+		&idempotencyKey.RequestHash,
+		&idempotencyKey.Response,
+		&idempotencyKey.CreatedAt,
+	)
+	return idempotencyKey, err
+}
+
+// getIdempotencyKey
+const getIdempotencyKey = `
+SELECT key, username, request_hash, response, created_at FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1
+`
+
+type GetIdempotencyKeyParams struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Username, arg.Key)
+	var idempotencyKey IdempotencyKey
+	err := row.Scan(
+		&idempotencyKey.Key,
+		&idempotencyKey.Username,
+		&idempotencyKey.RequestHash,
+		&idempotencyKey.Response,
+		&idempotencyKey.CreatedAt,
+	)
+	return idempotencyKey, err
+}
+
+// updateIdempotencyKeyResponse
+const updateIdempotencyKeyResponse = `
+UPDATE idempotency_keys
+SET response = $3
+WHERE username = $1 AND key = $2
+RETURNING key, username, request_hash, response, created_at
+`
+
+type UpdateIdempotencyKeyResponseParams struct {
+	Username string          `json:"username"`
+	Key      string          `json:"key"`
+	Response json.RawMessage `json:"response"`
+}
+
+func (q *Queries) UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, updateIdempotencyKeyResponse, arg.Username, arg.Key, []byte(arg.Response))
+	var idempotencyKey IdempotencyKey
+	err := row.Scan(
+		&idempotencyKey.Key,
+		&idempotencyKey.Username,
+		&idempotencyKey.RequestHash,
+		&idempotencyKey.Response,
+		&idempotencyKey.CreatedAt,
+	)
+	return idempotencyKey, err
+}
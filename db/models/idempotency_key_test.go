@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdempotencyKey(t *testing.T, user User) IdempotencyKey {
+	arg := CreateIdempotencyKeyParams{
+		Key:         util.RandomString(16),
+		Username:    user.Username,
+		RequestHash: util.RandomString(64),
+	}
+
+	idempotencyKey, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, idempotencyKey)
+
+	require.Equal(t, arg.Key, idempotencyKey.Key)
+	require.Equal(t, arg.Username, idempotencyKey.Username)
+	require.Equal(t, arg.RequestHash, idempotencyKey.RequestHash)
+	require.JSONEq(t, "{}", string(idempotencyKey.Response))
+	require.NotZero(t, idempotencyKey.CreatedAt)
+
+	return idempotencyKey
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	idempotencyKey := createRandomIdempotencyKey(t, user)
+
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Key:         idempotencyKey.Key,
+		Username:    idempotencyKey.Username,
+		RequestHash: util.RandomString(64),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestGetIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	idempotencyKey1 := createRandomIdempotencyKey(t, user)
+
+	idempotencyKey2, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: idempotencyKey1.Username,
+		Key:      idempotencyKey1.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, idempotencyKey1.Key, idempotencyKey2.Key)
+	require.Equal(t, idempotencyKey1.Username, idempotencyKey2.Username)
+	require.Equal(t, idempotencyKey1.RequestHash, idempotencyKey2.RequestHash)
+	require.WithinDuration(t, idempotencyKey1.CreatedAt, idempotencyKey2.CreatedAt, time.Second)
+}
+
+func TestUpdateIdempotencyKeyResponse(t *testing.T) {
+	user := createRandomUser(t)
+	idempotencyKey1 := createRandomIdempotencyKey(t, user)
+
+	arg := UpdateIdempotencyKeyResponseParams{
+		Username: idempotencyKey1.Username,
+		Key:      idempotencyKey1.Key,
+		Response: json.RawMessage(`{"amount": 10}`),
+	}
+
+	idempotencyKey2, err := testQueries.UpdateIdempotencyKeyResponse(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, idempotencyKey1.Key, idempotencyKey2.Key)
+	require.JSONEq(t, string(arg.Response), string(idempotencyKey2.Response))
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Account products seeded by the migrations. Only savings pays interest by
+// default; rates live in the account_products table.
+const (
+	ProductChecking = "checking"
+	ProductSavings  = "savings"
+)
+
+// listInterestBearingBalances
+const listInterestBearingBalances = `
+SELECT
+	a.id,
+	a.currency,
+	a.balance - COALESCE(SUM(e.amount), 0)::bigint AS balance,
+	p.interest_rate,
+	p.day_count
+FROM accounts a
+JOIN account_products p ON p.code = a.product
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= $2
+WHERE p.interest_rate > 0
+	AND a.created_at < $2
+	AND NOT EXISTS (
+		SELECT 1 FROM interest_accruals i
+		WHERE i.account_id = a.id AND i.accrual_date = $1 AND NOT i.carry_over
+	)
+GROUP BY a.id, p.code
+ORDER BY a.id
+`
+
+type ListInterestBearingBalancesParams struct {
+	AccrualDate time.Time `json:"accrual_date"`
+	// EndTime is the end of AccrualDate. Balances are taken at that instant.
+	EndTime time.Time `json:"end_time"`
+}
+
+type ListInterestBearingBalancesRow struct {
+	AccountID    int64  `json:"account_id"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	InterestRate string `json:"interest_rate"`
+	DayCount     string `json:"day_count"`
+}
+
+// ListInterestBearingBalances returns the end of day balance of every account
+// whose product pays interest and which has not accrued AccrualDate yet.
+func (q *Queries) ListInterestBearingBalances(ctx context.Context, arg ListInterestBearingBalancesParams) ([]ListInterestBearingBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInterestBearingBalances, arg.AccrualDate, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ListInterestBearingBalancesRow{}
+	for rows.Next() {
+		var i ListInterestBearingBalancesRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.Balance,
+			&i.InterestRate,
+			&i.DayCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// createInterestAccrual
+const createInterestAccrual = `
+INSERT INTO interest_accruals (
+	account_id,
+	accrual_date,
+	balance,
+	interest_rate,
+	day_count,
+	amount
+) VALUES (
+	$1, $2, $3, $4, $5, $6
+) ON CONFLICT (account_id, accrual_date, carry_over) DO NOTHING
+RETURNING account_id, accrual_date, balance, interest_rate, day_count, amount, transfer_id, carry_over, created_at
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID    int64     `json:"account_id"`
+	AccrualDate  time.Time `json:"accrual_date"`
+	Balance      int64     `json:"balance"`
+	InterestRate string    `json:"interest_rate"`
+	DayCount     string    `json:"day_count"`
+	Amount       string    `json:"amount"`
+}
+
+// CreateInterestAccrual records the interest of one account for one day. It
+// returns sql.ErrNoRows when that day was already accrued.
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	row := q.db.QueryRowContext(ctx, createInterestAccrual,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.InterestRate,
+		arg.DayCount,
+		arg.Amount,
+	)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.AccountID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.InterestRate,
+		&i.DayCount,
+		&i.Amount,
+		&i.TransferID,
+		&i.CarryOver,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// getInterestAccrual
+const getInterestAccrual = `
+SELECT account_id, accrual_date, balance, interest_rate, day_count, amount, transfer_id, carry_over, created_at FROM interest_accruals
+WHERE account_id = $1 AND accrual_date = $2 AND NOT carry_over LIMIT 1
+`
+
+type GetInterestAccrualParams struct {
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+}
+
+func (q *Queries) GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error) {
+	row := q.db.QueryRowContext(ctx, getInterestAccrual, arg.AccountID, arg.AccrualDate)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.AccountID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.InterestRate,
+		&i.DayCount,
+		&i.Amount,
+		&i.TransferID,
+		&i.CarryOver,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// listUnpostedInterestAccounts
+const listUnpostedInterestAccounts = `
+SELECT DISTINCT account_id FROM interest_accruals
+WHERE transfer_id IS NULL AND accrual_date < $1
+ORDER BY account_id
+`
+
+// ListUnpostedInterestAccounts returns the accounts with interest accrued
+// before the given date that was not posted yet.
+func (q *Queries) ListUnpostedInterestAccounts(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listUnpostedInterestAccounts, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// getUnpostedInterest
+const getUnpostedInterest = `
+SELECT COALESCE(FLOOR(SUM(amount)), 0)::bigint FROM interest_accruals
+WHERE account_id = $1 AND transfer_id IS NULL AND accrual_date < $2
+`
+
+type GetUnpostedInterestParams struct {
+	AccountID int64     `json:"account_id"`
+	Before    time.Time `json:"before"`
+}
+
+// GetUnpostedInterest sums the unposted accruals of an account, rounded down
+// to whole minor units.
+func (q *Queries) GetUnpostedInterest(ctx context.Context, arg GetUnpostedInterestParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUnpostedInterest, arg.AccountID, arg.Before)
+	var amount int64
+	err := row.Scan(&amount)
+	return amount, err
+}
+
+// markInterestPosted
+const markInterestPosted = `
+UPDATE interest_accruals
+SET transfer_id = $3
+WHERE account_id = $1 AND transfer_id IS NULL AND accrual_date < $2
+`
+
+type MarkInterestPostedParams struct {
+	AccountID  int64     `json:"account_id"`
+	Before     time.Time `json:"before"`
+	TransferID int64     `json:"transfer_id"`
+}
+
+func (q *Queries) MarkInterestPosted(ctx context.Context, arg MarkInterestPostedParams) error {
+	_, err := q.db.ExecContext(ctx, markInterestPosted, arg.AccountID, arg.Before, arg.TransferID)
+	return err
+}
+
+// createInterestCarryOver
+const createInterestCarryOver = `
+INSERT INTO interest_accruals (
+	account_id,
+	accrual_date,
+	balance,
+	interest_rate,
+	day_count,
+	amount,
+	carry_over
+)
+SELECT account_id, MAX(accrual_date), 0, 0, MAX(day_count), SUM(amount) - FLOOR(SUM(amount)), true
+FROM interest_accruals
+WHERE transfer_id = $1
+GROUP BY account_id
+HAVING SUM(amount) > FLOOR(SUM(amount))
+ON CONFLICT (account_id, accrual_date, carry_over) DO UPDATE
+SET amount = EXCLUDED.amount, transfer_id = NULL
+`
+
+// CreateInterestCarryOver records the fraction of a minor unit that a posting
+// rounded down as a new unposted accrual, dated on the last day the posting
+// paid, so the next posting pays it. A carry-over already on that day was
+// paid by the same posting and is replaced.
+func (q *Queries) CreateInterestCarryOver(ctx context.Context, transferID int64) error {
+	_, err := q.db.ExecContext(ctx, createInterestCarryOver, transferID)
+	return err
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"simple_bank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createSavingsAccount(t *testing.T, balance int64) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: util.USD,
+		Product:  ProductSavings,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ProductSavings, account.Product)
+	return account
+}
+
+func accrueInterest(t *testing.T, account Account, date time.Time, amount string) InterestAccrual {
+	arg := CreateInterestAccrualParams{
+		AccountID:    account.ID,
+		AccrualDate:  date,
+		Balance:      account.Balance,
+		InterestRate: "0.02",
+		DayCount:     "ACT/365",
+		Amount:       amount,
+	}
+
+	accrual, err := testQueries.CreateInterestAccrual(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, accrual.AccountID)
+	require.True(t, arg.AccrualDate.Equal(accrual.AccrualDate))
+	require.Equal(t, arg.Balance, accrual.Balance)
+	require.Nil(t, accrual.TransferID)
+	return accrual
+}
+
+func TestListInterestBearingBalances(t *testing.T) {
+	savings := createSavingsAccount(t, 1000)
+	checking := createRandomAccount(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	arg := ListInterestBearingBalancesParams{
+		AccrualDate: today,
+		EndTime:     time.Now().Add(time.Minute),
+	}
+
+	rows, err := testQueries.ListInterestBearingBalances(context.Background(), arg)
+	require.NoError(t, err)
+
+	found := false
+	for _, row := range rows {
+		require.NotEqual(t, checking.ID, row.AccountID)
+		if row.AccountID == savings.ID {
+			found = true
+			require.Equal(t, int64(1000), row.Balance)
+			require.Equal(t, "0.02", row.InterestRate)
+			require.Equal(t, "ACT/365", row.DayCount)
+		}
+	}
+	require.True(t, found)
+
+	// once accrued, the day is skipped
+	accrueInterest(t, savings, today, "0.0547945205")
+
+	rows, err = testQueries.ListInterestBearingBalances(context.Background(), arg)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NotEqual(t, savings.ID, row.AccountID)
+	}
+}
+
+func TestCreateInterestAccrualIdempotent(t *testing.T) {
+	account := createSavingsAccount(t, 1000)
+	date := time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	accrueInterest(t, account, date, "0.5")
+
+	_, err := testQueries.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:    account.ID,
+		AccrualDate:  date,
+		Balance:      account.Balance,
+		InterestRate: "0.02",
+		DayCount:     "ACT/365",
+		Amount:       "0.5",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	accrual, err := testQueries.GetInterestAccrual(context.Background(), GetInterestAccrualParams{
+		AccountID:   account.ID,
+		AccrualDate: date,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0.5", accrual.Amount)
+}
+
+func TestPostInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createSavingsAccount(t, 1000)
+	march := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 3; day++ {
+		accrueInterest(t, account, march.AddDate(0, 0, day), "0.7")
+	}
+	april := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	accrueInterest(t, account, april, "0.7")
+
+	expenseAccount, err := getSystemAccount(context.Background(), testQueries, InterestUsername, util.USD)
+	require.NoError(t, err)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    april,
+	})
+	require.NoError(t, err)
+
+	// 3 * 0.7 rounded down
+	require.Equal(t, int64(2), result.Transfer.Amount)
+	require.Equal(t, expenseAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(-2), result.FromEntry.Amount)
+	require.Equal(t, int64(2), result.ToEntry.Amount)
+	require.Equal(t, account.Balance+2, result.ToAccount.Balance)
+	require.Equal(t, expenseAccount.Balance-2, result.FromAccount.Balance)
+
+	for day := 0; day < 3; day++ {
+		accrual, err := testQueries.GetInterestAccrual(context.Background(), GetInterestAccrualParams{
+			AccountID:   account.ID,
+			AccrualDate: march.AddDate(0, 0, day),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, accrual.TransferID)
+		require.Equal(t, result.Transfer.ID, *accrual.TransferID)
+	}
+
+	accrual, err := testQueries.GetInterestAccrual(context.Background(), GetInterestAccrualParams{
+		AccountID:   account.ID,
+		AccrualDate: april,
+	})
+	require.NoError(t, err)
+	require.Nil(t, accrual.TransferID)
+
+	// posting the same month again pays nothing
+	again, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    april,
+	})
+	require.NoError(t, err)
+	require.Zero(t, again.Transfer.ID)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+2, updatedAccount.Balance)
+}
+
+func TestPostInterestTxCarriesOverFractions(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createSavingsAccount(t, 10)
+	date := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	accrueInterest(t, account, date, "0.0005479452")
+
+	before := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    before,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.ID)
+
+	accountIDs, err := testQueries.ListUnpostedInterestAccounts(context.Background(), before)
+	require.NoError(t, err)
+	require.Contains(t, accountIDs, account.ID)
+}
+
+func TestPostInterestTxCarriesOverRemainder(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createSavingsAccount(t, 1000)
+	march := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 3; day++ {
+		accrueInterest(t, account, march.AddDate(0, 0, day), "0.7")
+	}
+	april := march.AddDate(0, 1, 0)
+	for day := 0; day < 2; day++ {
+		accrueInterest(t, account, april.AddDate(0, 0, day), "0.45")
+	}
+	may := april.AddDate(0, 1, 0)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    april,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Transfer.Amount)
+
+	// the 0.1 left over by March makes April's 0.9 a whole unit
+	result, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    may,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Transfer.Amount)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+3, updatedAccount.Balance)
+
+	accountIDs, err := testQueries.ListUnpostedInterestAccounts(context.Background(), may)
+	require.NoError(t, err)
+	require.NotContains(t, accountIDs, account.ID)
+}
+
+func TestPostInterestTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createSavingsAccount(t, 1000)
+	date := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	accrueInterest(t, account, date, "5")
+	before := date.AddDate(0, 1, 0)
+
+	n := 5
+	results := make(chan TransferTxResult)
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+				AccountID: account.ID,
+				Before:    before,
+			})
+			errs <- err
+			results <- result
+		}()
+	}
+
+	posted := 0
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		if result := <-results; result.Transfer.ID != 0 {
+			posted++
+		}
+	}
+	require.Equal(t, 1, posted)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+5, updatedAccount.Balance)
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// InterestUsername owns the per-currency interest-expense accounts. Posting
+// interest moves it from the expense account of the currency to the savings
+// account, so the entries of the ledger still sum up to zero.
+const InterestUsername = "interest"
+
+type PostInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Before is the first day not included in the posting, usually the first
+	// day of the next month.
+	Before time.Time `json:"before"`
+}
+
+// PostInterestTx pays the interest accrued by an account before arg.Before
+// and marks those accruals as posted. Fractions of a minor unit are rounded
+// down and the remainder is carried over to the next posting as a new
+// accrual. When less than one minor unit is due, nothing is posted and the
+// accruals are left for the next posting, so the returned result is empty.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		expenseAccount, err := getSystemAccount(ctx, q, InterestUsername, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		// Locking the account first serializes concurrent postings, so the
+		// accruals summed below cannot be paid twice.
+		_, err = lockAccountSet(ctx, q, account.ID, expenseAccount.ID)
+		if err != nil {
+			return err
+		}
+
+		amount, err := q.GetUnpostedInterest(ctx, GetUnpostedInterestParams{
+			AccountID: account.ID,
+			Before:    arg.Before,
+		})
+		if err != nil || amount <= 0 {
+			return err
+		}
+
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID: expenseAccount.ID,
+			ToAccountID:   account.ID,
+			Amount:        amount,
+			ToAmount:      amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  expenseAccount.ID,
+			Amount:     -amount,
+			TransferID: &result.Transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  account.ID,
+			Amount:     amount,
+			TransferID: &result.Transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		accounts, err := addBalances(ctx, q, map[int64]int64{
+			expenseAccount.ID: -amount,
+			account.ID:        amount,
+		})
+		if err != nil {
+			return err
+		}
+		result.FromAccount = accounts[expenseAccount.ID]
+		result.ToAccount = accounts[account.ID]
+
+		err = q.MarkInterestPosted(ctx, MarkInterestPostedParams{
+			AccountID:  account.ID,
+			Before:     arg.Before,
+			TransferID: result.Transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.CreateInterestCarryOver(ctx, result.Transfer.ID)
+	})
+
+	return result, err
+}
@@ -1,28 +1,221 @@
 package db
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
+	ID                int64     `json:"id"`
+	Owner             string    `json:"owner"`
+	Balance           int64     `json:"balance"`
+	Currency          string    `json:"currency"`
+	OverdraftLimit    int64     `json:"overdraft_limit"`
+	Product           string    `json:"product"`
+	ApprovalThreshold *int64    `json:"approval_threshold"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type AccountApprover struct {
+	AccountID int64     `json:"account_id"`
+	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type AccountProduct struct {
+	Code         string `json:"code"`
+	InterestRate string `json:"interest_rate"`
+	DayCount     string `json:"day_count"`
+}
+
+type ApprovalPolicyChange struct {
+	ID                int64      `json:"id"`
+	AccountID         int64      `json:"account_id"`
+	RequestedBy       string     `json:"requested_by"`
+	ApprovalThreshold *int64     `json:"approval_threshold"`
+	Approvers         []string   `json:"approvers"`
+	Status            string     `json:"status"`
+	DecidedBy         *string    `json:"decided_by"`
+	DecidedAt         *time.Time `json:"decided_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type Currency struct {
+	Code        string `json:"code"`
+	NumericCode string `json:"numeric_code"`
+	MinorUnits  int32  `json:"minor_units"`
+	Symbol      string `json:"symbol"`
+	Enabled     bool   `json:"enabled"`
+}
+
 type Entry struct {
-	ID        int64     `json:"id"`
-	AccountID int64     `json:"account_id"`
-	Amount    int64     `json:"amount"`
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	Amount      int64     `json:"amount"`
+	TransferID  *int64    `json:"transfer_id"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ExchangeQuote struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	Spread        string    `json:"spread"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type InterestAccrual struct {
+	AccountID    int64     `json:"account_id"`
+	AccrualDate  time.Time `json:"accrual_date"`
+	Balance      int64     `json:"balance"`
+	InterestRate string    `json:"interest_rate"`
+	DayCount     string    `json:"day_count"`
+	Amount       string    `json:"amount"`
+	TransferID   *int64    `json:"transfer_id"`
+	CarryOver    bool      `json:"carry_over"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Key         string          `json:"key"`
+	Username    string          `json:"username"`
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID          int64     `json:"id"`
+	Requester   string    `json:"requester"`
+	Payer       string    `json:"payer"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	TransferID  *int64    `json:"transfer_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type PendingTransfer struct {
+	ID                int64           `json:"id"`
+	RequestedBy       string          `json:"requested_by"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Currency          string          `json:"currency"`
+	Description       string          `json:"description"`
+	ExternalReference *string         `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	Status            string          `json:"status"`
+	DecidedBy         *string         `json:"decided_by"`
+	DecidedAt         *time.Time      `json:"decided_at"`
+	TransferID        *int64          `json:"transfer_id"`
+	ExpiresAt         time.Time       `json:"expires_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+type RiskDecision struct {
+	ID                int64           `json:"id"`
+	Username          string          `json:"username"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Currency          string          `json:"currency"`
+	Decision          string          `json:"decision"`
+	Reasons           json.RawMessage `json:"reasons"`
+	CreatedAt         time.Time       `json:"created_at"`
+	TransferID        *int64          `json:"transfer_id"`
+	PendingTransferID *int64          `json:"pending_transfer_id"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type ScheduledTransfer struct {
+	ID            int64      `json:"id"`
+	Owner         string     `json:"owner"`
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency"`
+	Schedule      string     `json:"schedule"`
+	StartAt       time.Time  `json:"start_at"`
+	EndAt         *time.Time `json:"end_at"`
+	NextRunAt     time.Time  `json:"next_run_at"`
+	Status        string     `json:"status"`
+	Attempts      int32      `json:"attempts"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type ScheduledTransferExecution struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Attempt             int32     `json:"attempt"`
+	Status              string    `json:"status"`
+	TransferID          *int64    `json:"transfer_id"`
+	Error               *string   `json:"error"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Transfer struct {
-	ID            int64     `json:"id"`
-	FromAccountID int64     `json:"from_account_id"`
-	ToAccountID   int64     `json:"to_account_id"`
-	Amount        int64     `json:"amount"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID                int64           `json:"id"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	ToAmount          int64           `json:"to_amount"`
+	ExchangeRate      *string         `json:"exchange_rate"`
+	ExchangeSpread    *string         `json:"exchange_spread"`
+	QuoteID           *uuid.UUID      `json:"quote_id"`
+	ReversalOf        *int64          `json:"reversal_of"`
+	Description       string          `json:"description"`
+	ExternalReference *string         `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+type TransferFee struct {
+	Currency  string    `json:"currency"`
+	Product   string    `json:"product"`
+	Kind      string    `json:"kind"`
+	Amount    int64     `json:"amount"`
+	Rate      string    `json:"rate"`
+	MinFee    int64     `json:"min_fee"`
+	MaxFee    *int64    `json:"max_fee"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -30,6 +223,7 @@ type User struct {
 	HashedPassword    string    `json:"hashed_password"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Statuses of a payment request. Pending requests read as expired once past
+// their expiry, without anything updating them.
+const (
+	PaymentRequestPending   = "pending"
+	PaymentRequestAccepted  = "accepted"
+	PaymentRequestDeclined  = "declined"
+	PaymentRequestCancelled = "cancelled"
+	PaymentRequestExpired   = "expired"
+)
+
+// createPaymentRequest
+const createPaymentRequest = `
+INSERT INTO payment_requests (
+	requester,
+	payer,
+	to_account_id,
+	amount,
+	currency,
+	description,
+	expires_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+) RETURNING id, requester, payer, to_account_id, amount, currency, description, CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END AS status, transfer_id, expires_at, created_at, updated_at
+`
+
+type CreatePaymentRequestParams struct {
+	Requester   string    `json:"requester"`
+	Payer       string    `json:"payer"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error) {
+	row := q.db.QueryRowContext(ctx, createPaymentRequest,
+		arg.Requester,
+		arg.Payer,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Description,
+		arg.ExpiresAt,
+	)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Requester,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// getPaymentRequest
+const getPaymentRequest = `
+SELECT id, requester, payer, to_account_id, amount, currency, description, CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END AS status, transfer_id, expires_at, created_at, updated_at FROM payment_requests
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentRequest, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Requester,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// getPaymentRequestForUpdate
+const getPaymentRequestForUpdate = `
+SELECT id, requester, payer, to_account_id, amount, currency, description, CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END AS status, transfer_id, expires_at, created_at, updated_at FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentRequestForUpdate, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Requester,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// listPaymentRequestsAfter
+const listPaymentRequestsAfter = `
+SELECT id, requester, payer, to_account_id, amount, currency, description, CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END AS status, transfer_id, expires_at, created_at, updated_at FROM payment_requests
+WHERE
+	($1::varchar IS NULL OR requester = $1) AND
+	($2::varchar IS NULL OR payer = $2) AND
+	($3::varchar IS NULL OR CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END = $3) AND
+	id > $4
+ORDER BY id
+LIMIT $5
+`
+
+type ListPaymentRequestsAfterParams struct {
+	// Requester and Payer, when set, only keep the requests sent or received
+	// by that user.
+	Requester *string `json:"requester"`
+	Payer     *string `json:"payer"`
+	Status    *string `json:"status"`
+	AfterID   int64   `json:"after_id"`
+	Limit     int32   `json:"limit"`
+}
+
+func (q *Queries) ListPaymentRequestsAfter(ctx context.Context, arg ListPaymentRequestsAfterParams) ([]PaymentRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listPaymentRequestsAfter,
+		arg.Requester,
+		arg.Payer,
+		arg.Status,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentRequest{}
+	for rows.Next() {
+		var i PaymentRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Requester,
+			&i.Payer,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Description,
+			&i.Status,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// closePaymentRequest
+const closePaymentRequest = `
+UPDATE payment_requests
+SET
+	status = $2,
+	transfer_id = $3,
+	updated_at = now()
+WHERE id = $1 AND status = 'pending' AND expires_at > now()
+RETURNING id, requester, payer, to_account_id, amount, currency, description, CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END AS status, transfer_id, expires_at, created_at, updated_at
+`
+
+type ClosePaymentRequestParams struct {
+	ID         int64  `json:"id"`
+	Status     string `json:"status"`
+	TransferID *int64 `json:"transfer_id"`
+}
+
+// ClosePaymentRequest accepts, declines or cancels a payment request. It
+// returns sql.ErrNoRows when the request is no longer pending.
+func (q *Queries) ClosePaymentRequest(ctx context.Context, arg ClosePaymentRequestParams) (PaymentRequest, error) {
+	row := q.db.QueryRowContext(ctx, closePaymentRequest, arg.ID, arg.Status, arg.TransferID)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Requester,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentRequest(t *testing.T, to Account, payer User, amount int64, expiresAt time.Time) PaymentRequest {
+	arg := CreatePaymentRequestParams{
+		Requester:   to.Owner,
+		Payer:       payer.Username,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		Currency:    to.Currency,
+		Description: "dinner",
+		ExpiresAt:   expiresAt,
+	}
+
+	request, err := testQueries.CreatePaymentRequest(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, request.ID)
+	require.Equal(t, arg.Requester, request.Requester)
+	require.Equal(t, arg.Payer, request.Payer)
+	require.Equal(t, arg.ToAccountID, request.ToAccountID)
+	require.Equal(t, arg.Amount, request.Amount)
+	require.Equal(t, arg.Currency, request.Currency)
+	require.Equal(t, arg.Description, request.Description)
+	require.Nil(t, request.TransferID)
+	require.WithinDuration(t, expiresAt, request.ExpiresAt, time.Second)
+	return request
+}
+
+func TestCreatePaymentRequest(t *testing.T) {
+	to := createRandomAccount(t)
+	request := createRandomPaymentRequest(t, to, createRandomUser(t), 10, time.Now().Add(time.Hour))
+	require.Equal(t, PaymentRequestPending, request.Status)
+
+	got, err := testQueries.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, request.ID, got.ID)
+	require.Equal(t, PaymentRequestPending, got.Status)
+}
+
+func TestPaymentRequestExpires(t *testing.T) {
+	to := createRandomAccount(t)
+	request := createRandomPaymentRequest(t, to, createRandomUser(t), 10, time.Now().Add(-time.Minute))
+	require.Equal(t, PaymentRequestExpired, request.Status)
+
+	_, err := testQueries.ClosePaymentRequest(context.Background(), ClosePaymentRequestParams{
+		ID:     request.ID,
+		Status: PaymentRequestDeclined,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestClosePaymentRequest(t *testing.T) {
+	to := createRandomAccount(t)
+	request := createRandomPaymentRequest(t, to, createRandomUser(t), 10, time.Now().Add(time.Hour))
+
+	cancelled, err := testQueries.ClosePaymentRequest(context.Background(), ClosePaymentRequestParams{
+		ID:     request.ID,
+		Status: PaymentRequestCancelled,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestCancelled, cancelled.Status)
+
+	// a closed request cannot be closed again
+	_, err = testQueries.ClosePaymentRequest(context.Background(), ClosePaymentRequestParams{
+		ID:     request.ID,
+		Status: PaymentRequestDeclined,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListPaymentRequestsAfter(t *testing.T) {
+	to := createRandomAccount(t)
+	payer := createRandomUser(t)
+	live := createRandomPaymentRequest(t, to, payer, 10, time.Now().Add(time.Hour))
+	expired := createRandomPaymentRequest(t, to, payer, 10, time.Now().Add(-time.Minute))
+
+	outgoing, err := testQueries.ListPaymentRequestsAfter(context.Background(), ListPaymentRequestsAfterParams{
+		Requester: &to.Owner,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, outgoing, 2)
+	require.Equal(t, live.ID, outgoing[0].ID)
+	require.Equal(t, expired.ID, outgoing[1].ID)
+
+	status := PaymentRequestExpired
+	incoming, err := testQueries.ListPaymentRequestsAfter(context.Background(), ListPaymentRequestsAfterParams{
+		Payer:  &payer.Username,
+		Status: &status,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	require.Equal(t, expired.ID, incoming[0].ID)
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+	from := createFundedAccountInCurrency(t, util.USD, 1000)
+	to := createFundedAccountInCurrency(t, util.USD, 0)
+	payer, err := testQueries.GetUser(context.Background(), from.Owner)
+	require.NoError(t, err)
+
+	request := createRandomPaymentRequest(t, to, payer, 600, time.Now().Add(time.Hour))
+
+	result, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: from.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestAccepted, result.PaymentRequest.Status)
+	require.Equal(t, result.Transfer.ID, *result.PaymentRequest.TransferID)
+	require.Equal(t, int64(600), result.Transfer.Amount)
+	require.Equal(t, request.Description, result.Transfer.Description)
+	require.Equal(t, int64(400), result.FromAccount.Balance)
+	require.Equal(t, int64(600), result.ToAccount.Balance)
+
+	_, err = store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: from.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestClosed)
+}
+
+func TestAcceptPaymentRequestTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	from := createFundedAccountInCurrency(t, util.USD, 100)
+	to := createFundedAccountInCurrency(t, util.USD, 0)
+	payer, err := testQueries.GetUser(context.Background(), from.Owner)
+	require.NoError(t, err)
+
+	request := createRandomPaymentRequest(t, to, payer, 600, time.Now().Add(time.Hour))
+
+	_, err = store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: from.ID,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	got, err := testQueries.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestPending, got.Status)
+}
+
+func TestAcceptPaymentRequestTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	from := createFundedAccountInCurrency(t, util.USD, 1000)
+	to := createFundedAccountInCurrency(t, util.USD, 0)
+	payer, err := testQueries.GetUser(context.Background(), from.Owner)
+	require.NoError(t, err)
+
+	request := createRandomPaymentRequest(t, to, payer, 600, time.Now().Add(-time.Minute))
+
+	_, err = store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: from.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestExpired)
+}
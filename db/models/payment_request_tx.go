@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPaymentRequestClosed  = errors.New("payment request was already accepted, declined or cancelled")
+	ErrPaymentRequestExpired = errors.New("payment request has expired")
+)
+
+type AcceptPaymentRequestTxParams struct {
+	ID int64 `json:"id"`
+	// FromAccountID is the account of the payer in the currency of the
+	// request.
+	FromAccountID int64 `json:"from_account_id"`
+	// RiskDecisionID, when set, is the risk decision that screened the
+	// payment. It is linked to the transfer.
+	RiskDecisionID *int64 `json:"-"`
+}
+
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest `json:"payment_request"`
+	TransferTxResult
+}
+
+// AcceptPaymentRequestTx pays a payment request and marks it accepted. When
+// the transfer fails, e.g. for insufficient funds, the request stays pending.
+func (store *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	var err error
+	result.TransferTxResult, err = store.transferTx(ctx, nil, func(q *Queries) (TransferTxResult, error) {
+		// locking the request keeps it from being paid twice
+		request, err := q.GetPaymentRequestForUpdate(ctx, arg.ID)
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+
+		switch request.Status {
+		case PaymentRequestPending:
+		case PaymentRequestExpired:
+			return TransferTxResult{}, fmt.Errorf("%w: request %d", ErrPaymentRequestExpired, request.ID)
+		default:
+			return TransferTxResult{}, fmt.Errorf("%w: request %d is %s", ErrPaymentRequestClosed, request.ID, request.Status)
+		}
+
+		transferResult, err := transfer(ctx, q, TransferTxParams{
+			FromAccountID:  arg.FromAccountID,
+			ToAccountID:    request.ToAccountID,
+			Amount:         request.Amount,
+			Description:    request.Description,
+			RiskDecisionID: arg.RiskDecisionID,
+		})
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+
+		result.PaymentRequest, err = q.ClosePaymentRequest(ctx, ClosePaymentRequestParams{
+			ID:         request.ID,
+			Status:     PaymentRequestAccepted,
+			TransferID: &transferResult.Transfer.ID,
+		})
+		if err == sql.ErrNoRows {
+			// the request is locked and was pending, so it expired meanwhile
+			err = fmt.Errorf("%w: request %d", ErrPaymentRequestExpired, request.ID)
+		}
+		return transferResult, err
+	})
+
+	return result, err
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Statuses of a pending transfer. Only pending_approval ones can still be
+// approved or rejected.
+const (
+	PendingTransferPendingApproval = "pending_approval"
+	PendingTransferApproved        = "approved"
+	PendingTransferRejected        = "rejected"
+	PendingTransferExpired         = "expired"
+)
+
+// createPendingTransfer
+const createPendingTransfer = `
+INSERT INTO pending_transfers (
+	requested_by,
+	from_account_id,
+	to_account_id,
+	amount,
+	currency,
+	description,
+	external_reference,
+	metadata,
+	expires_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7, COALESCE($8::jsonb, '{}'), $9
+) RETURNING id, requested_by, from_account_id, to_account_id, amount, currency, description, external_reference, metadata, status, decided_by, decided_at, transfer_id, expires_at, created_at
+`
+
+type CreatePendingTransferParams struct {
+	RequestedBy       string          `json:"requested_by"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Currency          string          `json:"currency"`
+	Description       string          `json:"description"`
+	ExternalReference *string         `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	ExpiresAt         time.Time       `json:"expires_at"`
+}
+
+func (q *Queries) CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (PendingTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createPendingTransfer,
+		arg.RequestedBy,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+		arg.ExpiresAt,
+	)
+	var i PendingTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// getPendingTransfer
+const getPendingTransfer = `
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, description, external_reference, metadata, status, decided_by, decided_at, transfer_id, expires_at, created_at FROM pending_transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getPendingTransfer, id)
+	var i PendingTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// getPendingTransferForUpdate
+const getPendingTransferForUpdate = `
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, description, external_reference, metadata, status, decided_by, decided_at, transfer_id, expires_at, created_at FROM pending_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPendingTransferForUpdate(ctx context.Context, id int64) (PendingTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getPendingTransferForUpdate, id)
+	var i PendingTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// listPendingTransfersAfter
+const listPendingTransfersAfter = `
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, description, external_reference, metadata, status, decided_by, decided_at, transfer_id, expires_at, created_at FROM pending_transfers
+WHERE
+	status = 'pending_approval' AND
+	(
+		requested_by = $1 OR
+		from_account_id IN (SELECT account_id FROM account_approvers WHERE username = $1)
+	) AND
+	id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListPendingTransfersAfterParams struct {
+	Username string `json:"username"`
+	AfterID  int64  `json:"after_id"`
+	Limit    int32  `json:"limit"`
+}
+
+// ListPendingTransfersAfter returns the transfers waiting for approval that
+// the user requested or may approve.
+func (q *Queries) ListPendingTransfersAfter(ctx context.Context, arg ListPendingTransfersAfterParams) ([]PendingTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingTransfersAfter, arg.Username, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PendingTransfer{}
+	for rows.Next() {
+		var i PendingTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestedBy,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+			&i.Status,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// decidePendingTransfer
+const decidePendingTransfer = `
+UPDATE pending_transfers
+SET
+	status = $2,
+	decided_by = $3,
+	decided_at = now(),
+	transfer_id = $4
+WHERE id = $1 AND status = 'pending_approval'
+RETURNING id, requested_by, from_account_id, to_account_id, amount, currency, description, external_reference, metadata, status, decided_by, decided_at, transfer_id, expires_at, created_at
+`
+
+type DecidePendingTransferParams struct {
+	ID         int64  `json:"id"`
+	Status     string `json:"status"`
+	DecidedBy  string `json:"decided_by"`
+	TransferID *int64 `json:"transfer_id"`
+}
+
+// DecidePendingTransfer approves or rejects a pending transfer. It returns
+// sql.ErrNoRows when the transfer was already decided or expired.
+func (q *Queries) DecidePendingTransfer(ctx context.Context, arg DecidePendingTransferParams) (PendingTransfer, error) {
+	row := q.db.QueryRowContext(ctx, decidePendingTransfer,
+		arg.ID,
+		arg.Status,
+		arg.DecidedBy,
+		arg.TransferID,
+	)
+	var i PendingTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// expirePendingTransfers
+const expirePendingTransfers = `
+UPDATE pending_transfers
+SET
+	status = 'expired',
+	decided_at = $1
+WHERE status = 'pending_approval' AND expires_at <= $1
+`
+
+// ExpirePendingTransfers marks the transfers still waiting for approval at
+// now as expired, and returns how many there were.
+func (q *Queries) ExpirePendingTransfers(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expirePendingTransfers, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,23 +1,98 @@
 package db
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateSystemAccount(ctx context.Context, arg CreateSystemAccountParams) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountApprovalThreshold(ctx context.Context, arg UpdateAccountApprovalThresholdParams) (Account, error)
+	CreateAccountApprover(ctx context.Context, arg CreateAccountApproverParams) (AccountApprover, error)
+	GetAccountApprover(ctx context.Context, arg GetAccountApproverParams) (AccountApprover, error)
+	ListAccountApprovers(ctx context.Context, accountID int64) ([]AccountApprover, error)
+	DeleteAccountApprovers(ctx context.Context, accountID int64) error
+	CreateApprovalPolicyChange(ctx context.Context, arg CreateApprovalPolicyChangeParams) (ApprovalPolicyChange, error)
+	GetApprovalPolicyChange(ctx context.Context, id int64) (ApprovalPolicyChange, error)
+	GetApprovalPolicyChangeForUpdate(ctx context.Context, id int64) (ApprovalPolicyChange, error)
+	ListApprovalPolicyChangesAfter(ctx context.Context, arg ListApprovalPolicyChangesAfterParams) ([]ApprovalPolicyChange, error)
+	DecideApprovalPolicyChange(ctx context.Context, arg DecideApprovalPolicyChangeParams) (ApprovalPolicyChange, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	ListInterestBearingBalances(ctx context.Context, arg ListInterestBearingBalancesParams) ([]ListInterestBearingBalancesRow, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error)
+	GetInterestAccrual(ctx context.Context, arg GetInterestAccrualParams) (InterestAccrual, error)
+	ListUnpostedInterestAccounts(ctx context.Context, before time.Time) ([]int64, error)
+	GetUnpostedInterest(ctx context.Context, arg GetUnpostedInterestParams) (int64, error)
+	MarkInterestPosted(ctx context.Context, arg MarkInterestPostedParams) error
+	CreateInterestCarryOver(ctx context.Context, transferID int64) error
+	ListCurrencies(ctx context.Context) ([]Currency, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	GetStatementBalances(ctx context.Context, arg GetStatementBalancesParams) (GetStatementBalancesRow, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListStatementEntriesAfter(ctx context.Context, arg ListStatementEntriesAfterParams) ([]ListStatementEntriesRow, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetReversedAmount(ctx context.Context, transferID int64) (int64, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]ListOwnerTransfersRow, error)
+	CountOwnerTransfersSince(ctx context.Context, arg CountOwnerTransfersSinceParams) (int64, error)
+	CountOwnerTransfersTo(ctx context.Context, arg CountOwnerTransfersToParams) (int64, error)
+	GetTransferFee(ctx context.Context, arg GetTransferFeeParams) (TransferFee, error)
+	ListTransferFees(ctx context.Context) ([]TransferFee, error)
+	UpsertTransferFee(ctx context.Context, arg UpsertTransferFeeParams) (TransferFee, error)
+	DeleteTransferFee(ctx context.Context, arg DeleteTransferFeeParams) error
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	CreateExchangeQuote(ctx context.Context, arg CreateExchangeQuoteParams) (ExchangeQuote, error)
+	GetExchangeQuote(ctx context.Context, id uuid.UUID) (ExchangeQuote, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	ListPaymentRequestsAfter(ctx context.Context, arg ListPaymentRequestsAfterParams) ([]PaymentRequest, error)
+	ClosePaymentRequest(ctx context.Context, arg ClosePaymentRequestParams) (PaymentRequest, error)
+	CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (PendingTransfer, error)
+	GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+	GetPendingTransferForUpdate(ctx context.Context, id int64) (PendingTransfer, error)
+	ListPendingTransfersAfter(ctx context.Context, arg ListPendingTransfersAfterParams) ([]PendingTransfer, error)
+	DecidePendingTransfer(ctx context.Context, arg DecidePendingTransferParams) (PendingTransfer, error)
+	ExpirePendingTransfers(ctx context.Context, now time.Time) (int64, error)
+	CreateRiskDecision(ctx context.Context, arg CreateRiskDecisionParams) (RiskDecision, error)
+	GetRiskDecision(ctx context.Context, id int64) (RiskDecision, error)
+	SetRiskDecisionTransfer(ctx context.Context, arg SetRiskDecisionTransferParams) error
+	SetRiskDecisionPendingTransfer(ctx context.Context, arg SetRiskDecisionPendingTransferParams) error
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	ListActiveRevokedTokens(ctx context.Context) ([]RevokedToken, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	ListScheduledTransfersAfter(ctx context.Context, arg ListScheduledTransfersAfterParams) ([]ScheduledTransfer, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	ClaimDueScheduledTransfers(ctx context.Context, arg ClaimDueScheduledTransfersParams) ([]ScheduledTransfer, error)
+	UpdateScheduledTransferRun(ctx context.Context, arg UpdateScheduledTransferRunParams) (ScheduledTransfer, error)
+	CreateScheduledTransferExecution(ctx context.Context, arg CreateScheduledTransferExecutionParams) (ScheduledTransferExecution, error)
+	ListScheduledTransferExecutionsAfter(ctx context.Context, arg ListScheduledTransferExecutionsAfterParams) ([]ScheduledTransferExecution, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
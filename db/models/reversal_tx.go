@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTransferAlreadyReversed = errors.New("transfer was already fully reversed")
+	ErrReversalExceedsTransfer = errors.New("reversal exceeds the amount left to refund")
+	ErrTransferNotReversible   = errors.New("transfer cannot be reversed")
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount is refunded to the sender of the original transfer. Zero refunds
+	// whatever was not refunded yet.
+	Amount int64 `json:"amount"`
+	// Description defaults to one naming the original transfer.
+	Description string                      `json:"description"`
+	Idempotency *CreateIdempotencyKeyParams `json:"-"`
+}
+
+// ReverseTransferTx moves money back from the recipient of a transfer to its
+// sender, and links the new transfer to the original one. A transfer can be
+// refunded in several parts, but never for more than its amount in total.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	return store.transferTx(ctx, arg.Idempotency, func(q *Queries) (TransferTxResult, error) {
+		return reverseTransfer(ctx, q, arg)
+	})
+}
+
+func reverseTransfer(ctx context.Context, q *Queries, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	// locking the original transfer serializes concurrent refunds of it
+	original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	if original.ReversalOf != nil {
+		return TransferTxResult{}, fmt.Errorf("%w: transfer %d is itself a reversal", ErrTransferNotReversible, original.ID)
+	}
+	if original.QuoteID != nil {
+		return TransferTxResult{}, fmt.Errorf("%w: transfer %d is a cross-currency transfer", ErrTransferNotReversible, original.ID)
+	}
+
+	reversed, err := q.GetReversedAmount(ctx, original.ID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	remaining := original.Amount - reversed
+	if remaining <= 0 {
+		return TransferTxResult{}, fmt.Errorf("%w: transfer %d", ErrTransferAlreadyReversed, original.ID)
+	}
+
+	amount := arg.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return TransferTxResult{}, fmt.Errorf("%w: %d requested, %d left on transfer %d",
+			ErrReversalExceedsTransfer, amount, remaining, original.ID)
+	}
+
+	description := arg.Description
+	if description == "" {
+		description = fmt.Sprintf("Reversal of transfer %d", original.ID)
+	}
+
+	return postTransfer(ctx, q, nil, CreateTransferParams{
+		FromAccountID: original.ToAccountID,
+		ToAccountID:   original.FromAccountID,
+		Amount:        amount,
+		ToAmount:      amount,
+		ReversalOf:    &original.ID,
+		Description:   description,
+	})
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// createRevokedToken
+const createRevokedToken = `
+INSERT INTO revoked_tokens (
+	id,
+	username,
+	expires_at
+) VALUES (
+	$1, $2, $3
+) ON CONFLICT (id) DO NOTHING
+`
+
+type CreateRevokedTokenParams struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateRevokedToken records a revocation. Revoking a token again, e.g. when
+// logging out twice with the same refresh token, is not an error.
+func (q *Queries) CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRevokedToken, arg.ID, arg.Username, arg.ExpiresAt)
+	return err
+}
+
+// listActiveRevokedTokens
+const listActiveRevokedTokens = `
+SELECT id, username, expires_at, created_at FROM revoked_tokens
+WHERE expires_at > now()
+ORDER BY expires_at
+`
+
+func (q *Queries) ListActiveRevokedTokens(ctx context.Context) ([]RevokedToken, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveRevokedTokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	revokedTokens := []RevokedToken{}
+	for rows.Next() {
+		var revokedToken RevokedToken
+		if err := rows.Scan(
+			&revokedToken.ID,
+			&revokedToken.Username,
+			&revokedToken.ExpiresAt,
+			&revokedToken.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revokedTokens = append(revokedTokens, revokedToken)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revokedTokens, nil
+}
+
+// deleteExpiredRevokedTokens
+const deleteExpiredRevokedTokens = `
+DELETE FROM revoked_tokens
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredRevokedTokens)
+	return err
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRevokedToken(t *testing.T, user User, expiresAt time.Time) CreateRevokedTokenParams {
+	arg := CreateRevokedTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: expiresAt,
+	}
+
+	err := testQueries.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+	return arg
+}
+
+func listActiveRevokedTokenIDs(t *testing.T) map[uuid.UUID]RevokedToken {
+	revokedTokens, err := testQueries.ListActiveRevokedTokens(context.Background())
+	require.NoError(t, err)
+
+	ids := make(map[uuid.UUID]RevokedToken)
+	for _, revokedToken := range revokedTokens {
+		require.True(t, revokedToken.ExpiresAt.After(time.Now()))
+		ids[revokedToken.ID] = revokedToken
+	}
+	return ids
+}
+
+func TestCreateRevokedToken(t *testing.T) {
+	user := createRandomUser(t)
+	arg := createRandomRevokedToken(t, user, time.Now().Add(time.Minute))
+
+	revokedToken, ok := listActiveRevokedTokenIDs(t)[arg.ID]
+	require.True(t, ok)
+	require.Equal(t, arg.Username, revokedToken.Username)
+	require.WithinDuration(t, arg.ExpiresAt, revokedToken.ExpiresAt, time.Second)
+	require.NotZero(t, revokedToken.CreatedAt)
+}
+
+func TestCreateRevokedTokenTwice(t *testing.T) {
+	user := createRandomUser(t)
+	arg := createRandomRevokedToken(t, user, time.Now().Add(time.Minute))
+
+	err := testQueries.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.Contains(t, listActiveRevokedTokenIDs(t), arg.ID)
+}
+
+func TestListActiveRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+	active := createRandomRevokedToken(t, user, time.Now().Add(time.Minute))
+	expired := createRandomRevokedToken(t, user, time.Now().Add(-time.Minute))
+
+	ids := listActiveRevokedTokenIDs(t)
+	require.Contains(t, ids, active.ID)
+	require.NotContains(t, ids, expired.ID)
+}
+
+func TestDeleteExpiredRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+	active := createRandomRevokedToken(t, user, time.Now().Add(time.Minute))
+	expired := createRandomRevokedToken(t, user, time.Now().Add(-time.Minute))
+
+	err := testQueries.DeleteExpiredRevokedTokens(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, listActiveRevokedTokenIDs(t), active.ID)
+
+	var count int
+	err = testDB.QueryRowContext(context.Background(),
+		"SELECT count(*) FROM revoked_tokens WHERE id = $1", expired.ID).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
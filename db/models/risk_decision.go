@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Decisions of the risk rules on a transfer. Reviewed transfers go through
+// and are left for an analyst to look at, linked from their decision; denied
+// ones are refused.
+const (
+	RiskAllow  = "allow"
+	RiskReview = "review"
+	RiskDeny   = "deny"
+)
+
+// createRiskDecision
+const createRiskDecision = `
+INSERT INTO risk_decisions (
+	username,
+	from_account_id,
+	to_account_id,
+	amount,
+	currency,
+	decision,
+	reasons
+) VALUES (
+	$1, $2, $3, $4, $5, $6, COALESCE($7::jsonb, '[]')
+) RETURNING id, username, from_account_id, to_account_id, amount, currency, decision, reasons, created_at, transfer_id, pending_transfer_id
+`
+
+type CreateRiskDecisionParams struct {
+	Username      string          `json:"username"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Currency      string          `json:"currency"`
+	Decision      string          `json:"decision"`
+	Reasons       json.RawMessage `json:"reasons"`
+}
+
+func (q *Queries) CreateRiskDecision(ctx context.Context, arg CreateRiskDecisionParams) (RiskDecision, error) {
+	row := q.db.QueryRowContext(ctx, createRiskDecision,
+		arg.Username,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Decision,
+		arg.Reasons,
+	)
+	var i RiskDecision
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Decision,
+		&i.Reasons,
+		&i.CreatedAt,
+		&i.TransferID,
+		&i.PendingTransferID,
+	)
+	return i, err
+}
+
+// getRiskDecision
+const getRiskDecision = `
+SELECT id, username, from_account_id, to_account_id, amount, currency, decision, reasons, created_at, transfer_id, pending_transfer_id FROM risk_decisions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetRiskDecision(ctx context.Context, id int64) (RiskDecision, error) {
+	row := q.db.QueryRowContext(ctx, getRiskDecision, id)
+	var i RiskDecision
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Decision,
+		&i.Reasons,
+		&i.CreatedAt,
+		&i.TransferID,
+		&i.PendingTransferID,
+	)
+	return i, err
+}
+
+// setRiskDecisionTransfer
+const setRiskDecisionTransfer = `
+UPDATE risk_decisions
+SET transfer_id = $2
+WHERE id = $1
+`
+
+type SetRiskDecisionTransferParams struct {
+	ID         int64 `json:"id"`
+	TransferID int64 `json:"transfer_id"`
+}
+
+func (q *Queries) SetRiskDecisionTransfer(ctx context.Context, arg SetRiskDecisionTransferParams) error {
+	_, err := q.db.ExecContext(ctx, setRiskDecisionTransfer, arg.ID, arg.TransferID)
+	return err
+}
+
+// setRiskDecisionPendingTransfer
+const setRiskDecisionPendingTransfer = `
+UPDATE risk_decisions
+SET pending_transfer_id = $2
+WHERE id = $1
+`
+
+type SetRiskDecisionPendingTransferParams struct {
+	ID                int64 `json:"id"`
+	PendingTransferID int64 `json:"pending_transfer_id"`
+}
+
+func (q *Queries) SetRiskDecisionPendingTransfer(ctx context.Context, arg SetRiskDecisionPendingTransferParams) error {
+	_, err := q.db.ExecContext(ctx, setRiskDecisionPendingTransfer, arg.ID, arg.PendingTransferID)
+	return err
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRiskDecision(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	arg := CreateRiskDecisionParams{
+		Username:      from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        100,
+		Currency:      from.Currency,
+		Decision:      RiskDeny,
+		Reasons:       json.RawMessage(`[{"rule": "blocklist", "code": "blocklisted", "decision": "deny", "message": "recipient is blocklisted"}]`),
+	}
+
+	decision, err := testQueries.CreateRiskDecision(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, decision.ID)
+	require.Equal(t, arg.Username, decision.Username)
+	require.Equal(t, arg.Decision, decision.Decision)
+	require.JSONEq(t, string(arg.Reasons), string(decision.Reasons))
+	require.NotZero(t, decision.CreatedAt)
+
+	// reasons default to an empty list
+	arg.Decision = RiskAllow
+	arg.Reasons = nil
+	decision, err = testQueries.CreateRiskDecision(context.Background(), arg)
+	require.NoError(t, err)
+	require.JSONEq(t, `[]`, string(decision.Reasons))
+}
+
+func TestTransferTxLinksRiskDecision(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createFundedAccount(t, 100)
+	to := createRandomAccount(t)
+
+	decision, err := testQueries.CreateRiskDecision(context.Background(), CreateRiskDecisionParams{
+		Username:      from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Currency:      from.Currency,
+		Decision:      RiskReview,
+	})
+	require.NoError(t, err)
+	require.Nil(t, decision.TransferID)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:  from.ID,
+		ToAccountID:    to.ID,
+		Amount:         10,
+		RiskDecisionID: &decision.ID,
+	})
+	require.NoError(t, err)
+
+	decision, err = testQueries.GetRiskDecision(context.Background(), decision.ID)
+	require.NoError(t, err)
+	require.NotNil(t, decision.TransferID)
+	require.Equal(t, result.Transfer.ID, *decision.TransferID)
+}
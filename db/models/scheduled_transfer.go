@@ -0,0 +1,419 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Statuses of a scheduled transfer. Only active ones are executed; completed
+// ones went past their end date.
+const (
+	ScheduledTransferActive    = "active"
+	ScheduledTransferPaused    = "paused"
+	ScheduledTransferCompleted = "completed"
+	ScheduledTransferCancelled = "cancelled"
+)
+
+// Outcomes of an execution attempt.
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionFailed    = "failed"
+	ExecutionRetrying  = "retrying"
+)
+
+// createScheduledTransfer
+const createScheduledTransfer = `
+INSERT INTO scheduled_transfers (
+	owner,
+	from_account_id,
+	to_account_id,
+	amount,
+	currency,
+	schedule,
+	start_at,
+	end_at,
+	next_run_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, owner, from_account_id, to_account_id, amount, currency, schedule, start_at, end_at, next_run_at, status, attempts, locked_until, created_at, updated_at
+`
+
+type CreateScheduledTransferParams struct {
+	Owner         string     `json:"owner"`
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency"`
+	Schedule      string     `json:"schedule"`
+	StartAt       time.Time  `json:"start_at"`
+	EndAt         *time.Time `json:"end_at"`
+	NextRunAt     time.Time  `json:"next_run_at"`
+}
+
+func (q *Queries) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledTransfer,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Schedule,
+		arg.StartAt,
+		arg.EndAt,
+		arg.NextRunAt,
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Schedule,
+		&i.StartAt,
+		&i.EndAt,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Attempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// getScheduledTransfer
+const getScheduledTransfer = `
+SELECT id, owner, from_account_id, to_account_id, amount, currency, schedule, start_at, end_at, next_run_at, status, attempts, locked_until, created_at, updated_at FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledTransfer, id)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Schedule,
+		&i.StartAt,
+		&i.EndAt,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Attempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// listScheduledTransfersAfter
+const listScheduledTransfersAfter = `
+SELECT id, owner, from_account_id, to_account_id, amount, currency, schedule, start_at, end_at, next_run_at, status, attempts, locked_until, created_at, updated_at FROM scheduled_transfers
+WHERE owner = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListScheduledTransfersAfterParams struct {
+	Owner   string `json:"owner"`
+	AfterID int64  `json:"after_id"`
+	Limit   int32  `json:"limit"`
+}
+
+func (q *Queries) ListScheduledTransfersAfter(ctx context.Context, arg ListScheduledTransfersAfterParams) ([]ScheduledTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTransfersAfter, arg.Owner, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransfer{}
+	for rows.Next() {
+		var i ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Schedule,
+			&i.StartAt,
+			&i.EndAt,
+			&i.NextRunAt,
+			&i.Status,
+			&i.Attempts,
+			&i.LockedUntil,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// updateScheduledTransfer
+const updateScheduledTransfer = `
+UPDATE scheduled_transfers
+SET amount = $2, schedule = $3, end_at = $4, next_run_at = $5, status = $6, attempts = 0, locked_until = NULL, updated_at = now()
+WHERE id = $1
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, schedule, start_at, end_at, next_run_at, status, attempts, locked_until, created_at, updated_at
+`
+
+type UpdateScheduledTransferParams struct {
+	ID        int64      `json:"id"`
+	Amount    int64      `json:"amount"`
+	Schedule  string     `json:"schedule"`
+	EndAt     *time.Time `json:"end_at"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	Status    string     `json:"status"`
+}
+
+// UpdateScheduledTransfer changes what a user may edit, and forgets about
+// failed attempts of the current occurrence.
+func (q *Queries) UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledTransfer,
+		arg.ID,
+		arg.Amount,
+		arg.Schedule,
+		arg.EndAt,
+		arg.NextRunAt,
+		arg.Status,
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Schedule,
+		&i.StartAt,
+		&i.EndAt,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Attempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// claimDueScheduledTransfers
+const claimDueScheduledTransfers = `
+UPDATE scheduled_transfers
+SET locked_until = $2
+WHERE id IN (
+	SELECT id FROM scheduled_transfers
+	WHERE status = 'active'
+		AND next_run_at <= $1
+		AND (locked_until IS NULL OR locked_until <= $1)
+	ORDER BY next_run_at
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, schedule, start_at, end_at, next_run_at, status, attempts, locked_until, created_at, updated_at
+`
+
+type ClaimDueScheduledTransfersParams struct {
+	Now         time.Time `json:"now"`
+	LockedUntil time.Time `json:"locked_until"`
+	Limit       int32     `json:"limit"`
+}
+
+// ClaimDueScheduledTransfers leases the active transfers due at arg.Now until
+// arg.LockedUntil. Rows claimed by another replica are skipped, so each due
+// transfer is handed to a single scheduler.
+func (q *Queries) ClaimDueScheduledTransfers(ctx context.Context, arg ClaimDueScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueScheduledTransfers, arg.Now, arg.LockedUntil, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransfer{}
+	for rows.Next() {
+		var i ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Schedule,
+			&i.StartAt,
+			&i.EndAt,
+			&i.NextRunAt,
+			&i.Status,
+			&i.Attempts,
+			&i.LockedUntil,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// updateScheduledTransferRun
+const updateScheduledTransferRun = `
+UPDATE scheduled_transfers
+SET next_run_at = $2, status = $3, attempts = $4, locked_until = $5, updated_at = now()
+WHERE id = $1 AND status = 'active'
+RETURNING id, owner, from_account_id, to_account_id, amount, currency, schedule, start_at, end_at, next_run_at, status, attempts, locked_until, created_at, updated_at
+`
+
+type UpdateScheduledTransferRunParams struct {
+	ID          int64      `json:"id"`
+	NextRunAt   time.Time  `json:"next_run_at"`
+	Status      string     `json:"status"`
+	Attempts    int32      `json:"attempts"`
+	LockedUntil *time.Time `json:"locked_until"`
+}
+
+// UpdateScheduledTransferRun moves an active transfer to its next occurrence
+// or retry. It returns sql.ErrNoRows when the transfer was paused or cancelled
+// in the meantime, which the user's change takes precedence over.
+func (q *Queries) UpdateScheduledTransferRun(ctx context.Context, arg UpdateScheduledTransferRunParams) (ScheduledTransfer, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledTransferRun,
+		arg.ID,
+		arg.NextRunAt,
+		arg.Status,
+		arg.Attempts,
+		arg.LockedUntil,
+	)
+	var i ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Schedule,
+		&i.StartAt,
+		&i.EndAt,
+		&i.NextRunAt,
+		&i.Status,
+		&i.Attempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// createScheduledTransferExecution
+const createScheduledTransferExecution = `
+INSERT INTO scheduled_transfer_executions (
+	scheduled_transfer_id,
+	scheduled_for,
+	attempt,
+	status,
+	transfer_id,
+	error
+) VALUES (
+	$1, $2, $3, $4, $5, $6
+) RETURNING id, scheduled_transfer_id, scheduled_for, attempt, status, transfer_id, error, created_at
+`
+
+type CreateScheduledTransferExecutionParams struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Attempt             int32     `json:"attempt"`
+	Status              string    `json:"status"`
+	TransferID          *int64    `json:"transfer_id"`
+	Error               *string   `json:"error"`
+}
+
+func (q *Queries) CreateScheduledTransferExecution(ctx context.Context, arg CreateScheduledTransferExecutionParams) (ScheduledTransferExecution, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledTransferExecution,
+		arg.ScheduledTransferID,
+		arg.ScheduledFor,
+		arg.Attempt,
+		arg.Status,
+		arg.TransferID,
+		arg.Error,
+	)
+	var i ScheduledTransferExecution
+	err := row.Scan(
+		&i.ID,
+		&i.ScheduledTransferID,
+		&i.ScheduledFor,
+		&i.Attempt,
+		&i.Status,
+		&i.TransferID,
+		&i.Error,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// listScheduledTransferExecutionsAfter
+const listScheduledTransferExecutionsAfter = `
+SELECT id, scheduled_transfer_id, scheduled_for, attempt, status, transfer_id, error, created_at FROM scheduled_transfer_executions
+WHERE scheduled_transfer_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListScheduledTransferExecutionsAfterParams struct {
+	ScheduledTransferID int64 `json:"scheduled_transfer_id"`
+	AfterID             int64 `json:"after_id"`
+	Limit               int32 `json:"limit"`
+}
+
+func (q *Queries) ListScheduledTransferExecutionsAfter(ctx context.Context, arg ListScheduledTransferExecutionsAfterParams) ([]ScheduledTransferExecution, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTransferExecutionsAfter, arg.ScheduledTransferID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledTransferExecution{}
+	for rows.Next() {
+		var i ScheduledTransferExecution
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScheduledTransferID,
+			&i.ScheduledFor,
+			&i.Attempt,
+			&i.Status,
+			&i.TransferID,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
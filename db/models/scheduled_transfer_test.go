@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, nextRunAt time.Time) ScheduledTransfer {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	arg := CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Currency:      from.Currency,
+		Schedule:      "@daily",
+		StartAt:       nextRunAt,
+		NextRunAt:     nextRunAt,
+	}
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, scheduled.ID)
+	require.Equal(t, arg.Owner, scheduled.Owner)
+	require.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, arg.Amount, scheduled.Amount)
+	require.Equal(t, arg.Schedule, scheduled.Schedule)
+	require.WithinDuration(t, arg.NextRunAt, scheduled.NextRunAt, time.Second)
+	require.Nil(t, scheduled.EndAt)
+	require.Nil(t, scheduled.LockedUntil)
+	require.Equal(t, ScheduledTransferActive, scheduled.Status)
+	require.Zero(t, scheduled.Attempts)
+	return scheduled
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	scheduled := createRandomScheduledTransfer(t, time.Now().Add(time.Hour))
+
+	got, err := testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, got.ID)
+	require.Equal(t, scheduled.Owner, got.Owner)
+	require.WithinDuration(t, scheduled.NextRunAt, got.NextRunAt, time.Second)
+}
+
+func TestClaimDueScheduledTransfers(t *testing.T) {
+	now := time.Now()
+	due := createRandomScheduledTransfer(t, now.Add(-time.Minute))
+	notDue := createRandomScheduledTransfer(t, now.Add(time.Hour))
+
+	claim := func() map[int64]bool {
+		claimed, err := testQueries.ClaimDueScheduledTransfers(context.Background(), ClaimDueScheduledTransfersParams{
+			Now:         now,
+			LockedUntil: now.Add(5 * time.Minute),
+			Limit:       1000,
+		})
+		require.NoError(t, err)
+
+		ids := make(map[int64]bool)
+		for _, scheduled := range claimed {
+			ids[scheduled.ID] = true
+		}
+		return ids
+	}
+
+	claimed := claim()
+	require.True(t, claimed[due.ID])
+	require.False(t, claimed[notDue.ID])
+
+	// leased rows are not claimed again
+	require.False(t, claim()[due.ID])
+}
+
+func TestUpdateScheduledTransferRunSkipsInactive(t *testing.T) {
+	scheduled := createRandomScheduledTransfer(t, time.Now().Add(time.Hour))
+
+	paused, err := testQueries.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:        scheduled.ID,
+		Amount:    scheduled.Amount,
+		Schedule:  scheduled.Schedule,
+		NextRunAt: scheduled.NextRunAt,
+		Status:    ScheduledTransferPaused,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferPaused, paused.Status)
+
+	_, err = testQueries.UpdateScheduledTransferRun(context.Background(), UpdateScheduledTransferRunParams{
+		ID:        scheduled.ID,
+		NextRunAt: scheduled.NextRunAt.Add(24 * time.Hour),
+		Status:    ScheduledTransferActive,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordScheduledRunTx(t *testing.T) {
+	store := NewStore(testDB)
+	scheduled := createRandomScheduledTransfer(t, time.Now().Add(-time.Minute))
+	nextRunAt := scheduled.NextRunAt.Add(24 * time.Hour)
+
+	message := "connection reset"
+	arg := RecordScheduledRunTxParams{
+		Execution: CreateScheduledTransferExecutionParams{
+			ScheduledTransferID: scheduled.ID,
+			ScheduledFor:        scheduled.NextRunAt,
+			Attempt:             1,
+			Status:              ExecutionFailed,
+			Error:               &message,
+		},
+		Run: UpdateScheduledTransferRunParams{
+			ID:        scheduled.ID,
+			NextRunAt: nextRunAt,
+			Status:    ScheduledTransferActive,
+		},
+	}
+
+	execution, err := store.RecordScheduledRunTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, execution.ScheduledTransferID)
+	require.Equal(t, ExecutionFailed, execution.Status)
+	require.Equal(t, message, *execution.Error)
+	require.Nil(t, execution.TransferID)
+
+	got, err := testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, nextRunAt, got.NextRunAt, time.Second)
+
+	executions, err := testQueries.ListScheduledTransferExecutionsAfter(context.Background(), ListScheduledTransferExecutionsAfterParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               10,
+	})
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	require.Equal(t, execution.ID, executions[0].ID)
+}
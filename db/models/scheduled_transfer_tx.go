@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type RecordScheduledRunTxParams struct {
+	Execution CreateScheduledTransferExecutionParams `json:"execution"`
+	Run       UpdateScheduledTransferRunParams       `json:"run"`
+}
+
+// RecordScheduledRunTx logs the outcome of an execution attempt and moves the
+// scheduled transfer to its next occurrence or retry in one transaction. The
+// run is not updated when the transfer stopped being active meanwhile, but
+// the execution is still logged.
+func (store *SQLStore) RecordScheduledRunTx(ctx context.Context, arg RecordScheduledRunTxParams) (ScheduledTransferExecution, error) {
+	var execution ScheduledTransferExecution
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		execution, err = q.CreateScheduledTransferExecution(ctx, arg.Execution)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.UpdateScheduledTransferRun(ctx, arg.Run)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	})
+
+	return execution, err
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// createSession
+const createSession = `
+INSERT INTO sessions (
+	id,
+	username,
+	refresh_token,
+	user_agent,
+	client_ip,
+	is_blocked,
+	expires_at
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+`
+
+type CreateSessionParams struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.Username,
+		arg.RefreshToken,
+		arg.UserAgent,
+		arg.ClientIp,
+		arg.IsBlocked,
+		arg.ExpiresAt,
+	)
+	var session Session
+	err := row.Scan(
+		&session.ID,
+		&session.Username,
+		&session.RefreshToken,
+		&session.UserAgent,
+		&session.ClientIp,
+		&session.IsBlocked,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	return session, err
+}
+
+// getSession
+const getSession = `
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSession, id)
+	var session Session
+	err := row.Scan(
+		&session.ID,
+		&session.Username,
+		&session.RefreshToken,
+		&session.UserAgent,
+		&session.ClientIp,
+		&session.IsBlocked,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	return session, err
+}
@@ -3,12 +3,31 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+
+	"simple_bank/util"
+
+	"github.com/lib/pq"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	ExchangeTransferTx(ctx context.Context, arg ExchangeTransferTxParams) (TransferTxResult, error)
+	UpsertExchangeRatesTx(ctx context.Context, arg []UpsertExchangeRateParams) error
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (TransferTxResult, error)
+	RecordScheduledRunTx(ctx context.Context, arg RecordScheduledRunTxParams) (ScheduledTransferExecution, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	SetApprovalPolicyTx(ctx context.Context, arg SetApprovalPolicyTxParams) (SetApprovalPolicyTxResult, error)
+	ApproveApprovalPolicyChangeTx(ctx context.Context, arg ApproveApprovalPolicyChangeTxParams) (SetApprovalPolicyTxResult, error)
+	CreatePendingTransferTx(ctx context.Context, arg CreatePendingTransferTxParams) (PendingTransfer, error)
+	ApprovePendingTransferTx(ctx context.Context, arg ApprovePendingTransferTxParams) (ApprovePendingTransferTxResult, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
 }
 
 type SQLStore struct {
@@ -45,6 +64,17 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// Description is also copied to both entries, so statements show it.
+	Description       string          `json:"description"`
+	ExternalReference *string         `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	// Idempotency, when set, records the result under the client supplied key
+	// so that a retried request replays it instead of transferring twice.
+	Idempotency *CreateIdempotencyKeyParams `json:"-"`
+	// RiskDecisionID, when set, is the risk decision that screened the
+	// transfer. It is linked to the transfer, so reviewed transfers can be
+	// found from their decision.
+	RiskDecisionID *int64 `json:"-"`
 }
 
 type TransferTxResult struct {
@@ -53,51 +83,236 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// ExchangeEntries are the entries against the fx position accounts of a
+	// cross-currency transfer.
+	ExchangeEntries []Entry `json:"exchange_entries,omitempty"`
+	// Fee is the fee charged to the sender on top of the amount, if any, and
+	// FeeEntries the entries moving it to the fee revenue account.
+	Fee        *FeeBreakdown `json:"fee,omitempty"`
+	FeeEntries []Entry       `json:"fee_entries,omitempty"`
 }
 
 var txKey = struct{}{}
 
+var (
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+)
+
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	return store.transferTx(ctx, arg.Idempotency, func(q *Queries) (TransferTxResult, error) {
+		return transfer(ctx, q, arg)
+	})
+}
+
+// transferTx runs fn in a transaction, replaying the stored result instead
+// when the idempotency key was already used for the same request.
+func (store *SQLStore) transferTx(
+	ctx context.Context,
+	idempotency *CreateIdempotencyKeyParams,
+	fn func(q *Queries) (TransferTxResult, error),
+) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
+		if idempotency != nil {
+			replayed, err := claimIdempotencyKey(ctx, q, *idempotency, &result)
+			if err != nil || replayed {
+				return err
+			}
+		}
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
+		var err error
+		result, err = fn(q)
+		if err != nil || idempotency == nil {
 			return err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		return saveIdempotentResponse(ctx, q, *idempotency, result)
+	})
+
+	if isOverdraftViolation(err) {
+		err = fmt.Errorf("%w: %v", ErrInsufficientFunds, err)
+	}
+	return result, err
+}
+
+// transfer moves money between two accounts using q, which must be bound to
+// an open transaction, and charges the fee of the from account on top.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := postTransfer(ctx, q, fee, CreateTransferParams{
+		FromAccountID:     arg.FromAccountID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		ToAmount:          arg.Amount,
+		Description:       arg.Description,
+		ExternalReference: arg.ExternalReference,
+		Metadata:          arg.Metadata,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, linkRiskDecision(ctx, q, arg.RiskDecisionID, result.Transfer.ID)
+}
+
+// linkRiskDecision records transferID on the risk decision that screened it,
+// if any.
+func linkRiskDecision(ctx context.Context, q *Queries, decisionID *int64, transferID int64) error {
+	if decisionID == nil {
+		return nil
+	}
+
+	return q.SetRiskDecisionTransfer(ctx, SetRiskDecisionTransferParams{
+		ID:         *decisionID,
+		TransferID: transferID,
+	})
+}
+
+// postTransfer records arg and its entries between two accounts of the same
+// currency, using q, which must be bound to an open transaction. The fee, if
+// any, is debited from the from account as well.
+func postTransfer(ctx context.Context, q *Queries, fee *FeeBreakdown, arg CreateTransferParams) (result TransferTxResult, err error) {
+	locked, err := lockAccountSet(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return
+	}
+	fromAccount := locked[arg.FromAccountID]
+
+	debit := util.NewMoney(arg.Amount, fromAccount.Currency)
+	if fee != nil {
+		debit, err = debit.Add(util.NewMoney(fee.Amount, fee.Currency))
 		if err != nil {
-			return err
+			return
 		}
+	}
+
+	if err = checkBalance(fromAccount, -debit.Amount); err != nil {
+		return
+	}
+	if err = checkBalance(locked[arg.ToAccountID], arg.Amount); err != nil {
+		return
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return
+	}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.FromAccountID,
+		Amount:      -arg.Amount,
+		TransferID:  &result.Transfer.ID,
+		Description: arg.Description,
+	})
+	if err != nil {
+		return
+	}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.ToAccountID,
+		Amount:      arg.Amount,
+		TransferID:  &result.Transfer.ID,
+		Description: arg.Description,
+	})
+	if err != nil {
+		return
+	}
+
+	var revenueAccountID int64
+	if fee != nil {
+		result.Fee = fee
+		result.FeeEntries, revenueAccountID, err = postFee(ctx, q, result.Transfer, *fee)
 		if err != nil {
-			return err
+			return
 		}
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -debit.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -debit.Amount)
+	}
+	if err != nil || fee == nil {
+		return
+	}
 
+	// the revenue account is always updated last, after the accounts of the
+	// transfer, so that transfers sharing it cannot deadlock
+	_, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		Amount: fee.Amount,
+		ID:     revenueAccountID,
+	})
+	return
+}
+
+// claimIdempotencyKey reserves the key for the current transaction. A
+// concurrent request with the same key blocks until the first one commits.
+// When the key was already used for the same request, the stored response is
+// decoded into response and replayed is true.
+func claimIdempotencyKey(ctx context.Context, q *Queries, arg CreateIdempotencyKeyParams, response interface{}) (replayed bool, err error) {
+	_, err = q.CreateIdempotencyKey(ctx, arg)
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	idempotencyKey, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if idempotencyKey.RequestHash != arg.RequestHash {
+		return false, ErrIdempotencyKeyReused
+	}
+	return true, json.Unmarshal(idempotencyKey.Response, response)
+}
+
+func saveIdempotentResponse(ctx context.Context, q *Queries, arg CreateIdempotencyKeyParams, response interface{}) error {
+	data, err := json.Marshal(response)
+	if err != nil {
 		return err
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+		Response: data,
 	})
+	return err
+}
 
-	return result, err
+// checkBalance fails when adding amount to the balance of account would
+// overflow, with util.ErrAmountOverflow, or, for a debit, take it below its
+// overdraft limit, with ErrInsufficientFunds.
+func checkBalance(account Account, amount int64) error {
+	balance, err := util.NewMoney(account.Balance, account.Currency).Add(util.NewMoney(amount, account.Currency))
+	if err != nil {
+		return fmt.Errorf("account %d: %w", account.ID, err)
+	}
+
+	if amount < 0 && balance.Amount < -account.OverdraftLimit {
+		return fmt.Errorf("%w: account %d has balance %d and overdraft limit %d",
+			ErrInsufficientFunds, account.ID, account.Balance, account.OverdraftLimit)
+	}
+	return nil
+}
+
+func isOverdraftViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == "balance_within_overdraft"
 }
 
 func addMoney(
@@ -2,16 +2,32 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"simple_bank/util"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+func createFundedAccount(t *testing.T, balance int64) Account {
+	account := createRandomAccount(t)
+
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+
+	return account
+}
+
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
+	account1 := createFundedAccount(t, util.RandomInt(100, 1000))
 	account2 := createRandomAccount(t)
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
@@ -61,6 +77,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, account1.ID, fromEntry.AccountID)
 		require.Equal(t, -amount, fromEntry.Amount)
+		require.Equal(t, &transfer.ID, fromEntry.TransferID)
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
 
@@ -71,6 +88,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, toEntry)
 		require.Equal(t, account2.ID, toEntry.AccountID)
 		require.Equal(t, amount, toEntry.Amount)
+		require.Equal(t, &transfer.ID, toEntry.TransferID)
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
 
@@ -116,8 +134,8 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, util.RandomInt(100, 1000))
+	account2 := createFundedAccount(t, util.RandomInt(100, 1000))
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	n := 10
@@ -161,3 +179,179 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func runConcurrentTransfers(t *testing.T, store Store, arg TransferTxParams, n int) (succeeded int, failed int) {
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), arg)
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.True(t, errors.Is(err, ErrInsufficientFunds), err)
+		failed++
+	}
+	return
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := createFundedAccount(t, 5*amount)
+	account2 := createRandomAccount(t)
+
+	succeeded, failed := runConcurrentTransfers(t, store, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}, 10)
+	require.Equal(t, 5, succeeded)
+	require.Equal(t, 5, failed)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+5*amount, updatedAccount2.Balance)
+}
+
+func TestTransferTxOverdraftLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := createFundedAccount(t, 0)
+	account2 := createRandomAccount(t)
+
+	account1, err := store.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 3 * amount,
+	})
+	require.NoError(t, err)
+
+	succeeded, failed := runConcurrentTransfers(t, store, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}, 5)
+	require.Equal(t, 3, succeeded)
+	require.Equal(t, 2, failed)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, -account1.OverdraftLimit, updatedAccount1.Balance)
+}
+
+func TestTransferTxBalanceOverflow(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, math.MaxInt64-5)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, util.ErrAmountOverflow)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestAccountBalanceWithinOverdraftConstraint(t *testing.T) {
+	account := createFundedAccount(t, 0)
+
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -1,
+	})
+	require.Error(t, err)
+	require.True(t, isOverdraftViolation(err))
+}
+
+func TestTransferTxIdempotent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, util.RandomInt(100, 1000))
+	account2 := createRandomAccount(t)
+
+	n := 5
+	amount := int64(10)
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Idempotency: &CreateIdempotencyKeyParams{
+			Key:         util.RandomString(16),
+			Username:    account1.Owner,
+			RequestHash: util.RandomString(64),
+		},
+	}
+
+	errs := make(chan error)
+	results := make(chan TransferTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.TransferTx(context.Background(), arg)
+			errs <- err
+			results <- result
+		}()
+	}
+
+	var transferID int64
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		result := <-results
+
+		require.NotZero(t, result.Transfer.ID)
+		if transferID == 0 {
+			transferID = result.Transfer.ID
+		}
+		require.Equal(t, transferID, result.Transfer.ID)
+	}
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updatedAccount1.Balance)
+
+	// reusing the key for a different request is rejected
+	arg.Idempotency.RequestHash = util.RandomString(64)
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestTransferTxDetails(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	reference := util.RandomString(12)
+	arg := TransferTxParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            10,
+		Description:       "dinner",
+		ExternalReference: &reference,
+		Metadata:          []byte(`{"split": 3}`),
+	}
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Description, result.Transfer.Description)
+	require.Equal(t, reference, *result.Transfer.ExternalReference)
+	require.JSONEq(t, string(arg.Metadata), string(result.Transfer.Metadata))
+	require.Equal(t, arg.Description, result.FromEntry.Description)
+	require.Equal(t, arg.Description, result.ToEntry.Description)
+}
@@ -1,32 +1,74 @@
 package db
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // createTransfer
 const createTransfer = `
 INSERT INTO transfers (
 	from_account_id,
 	to_account_id,
-	amount
+	amount,
+	to_amount,
+	exchange_rate,
+	exchange_spread,
+	quote_id,
+	reversal_of,
+	description,
+	external_reference,
+	metadata
 ) VALUES (
-	$1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+	$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::jsonb, '{}')
+) RETURNING id, from_account_id, to_account_id, amount, to_amount, exchange_rate, exchange_spread, quote_id, reversal_of, description, external_reference, metadata, created_at
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	ToAmount          int64           `json:"to_amount"`
+	ExchangeRate      *string         `json:"exchange_rate"`
+	ExchangeSpread    *string         `json:"exchange_spread"`
+	QuoteID           *uuid.UUID      `json:"quote_id"`
+	ReversalOf        *int64          `json:"reversal_of"`
+	Description       string          `json:"description"`
+	ExternalReference *string         `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ExchangeSpread,
+		arg.QuoteID,
+		arg.ReversalOf,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+	)
 	var transfer Transfer
 	err := row.Scan(
 		&transfer.ID,
 		&transfer.FromAccountID,
 		&transfer.ToAccountID,
 		&transfer.Amount,
+		&transfer.ToAmount,
+		&transfer.ExchangeRate,
+		&transfer.ExchangeSpread,
+		&transfer.QuoteID,
+		&transfer.ReversalOf,
+		&transfer.Description,
+		&transfer.ExternalReference,
+		&transfer.Metadata,
 		&transfer.CreatedAt,
 	)
 	return transfer, err
@@ -34,7 +76,7 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 
 // getTransfer
 const getTransfer = `
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, to_amount, exchange_rate, exchange_spread, quote_id, reversal_of, description, external_reference, metadata, created_at FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -46,30 +88,80 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&transfer.FromAccountID,
 		&transfer.ToAccountID,
 		&transfer.Amount,
+		&transfer.ToAmount,
+		&transfer.ExchangeRate,
+		&transfer.ExchangeSpread,
+		&transfer.QuoteID,
+		&transfer.ReversalOf,
+		&transfer.Description,
+		&transfer.ExternalReference,
+		&transfer.Metadata,
+		&transfer.CreatedAt,
+	)
+	return transfer, err
+}
+
+// getTransferForUpdate
+const getTransferForUpdate = `
+SELECT id, from_account_id, to_account_id, amount, to_amount, exchange_rate, exchange_spread, quote_id, reversal_of, description, external_reference, metadata, created_at FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var transfer Transfer
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.FromAccountID,
+		&transfer.ToAccountID,
+		&transfer.Amount,
+		&transfer.ToAmount,
+		&transfer.ExchangeRate,
+		&transfer.ExchangeSpread,
+		&transfer.QuoteID,
+		&transfer.ReversalOf,
+		&transfer.Description,
+		&transfer.ExternalReference,
+		&transfer.Metadata,
 		&transfer.CreatedAt,
 	)
 	return transfer, err
 }
 
+// getReversedAmount
+const getReversedAmount = `
+SELECT COALESCE(SUM(amount), 0)::bigint FROM transfers
+WHERE reversal_of = $1
+`
+
+// GetReversedAmount returns how much of a transfer was already refunded.
+func (q *Queries) GetReversedAmount(ctx context.Context, transferID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getReversedAmount, transferID)
+	var amount int64
+	err := row.Scan(&amount)
+	return amount, err
+}
+
 const listTransfers = `
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, to_amount, exchange_rate, exchange_spread, quote_id, reversal_of, description, external_reference, metadata, created_at FROM transfers
 WHERE
 	from_account_id = $1 OR
-	to_account_id = $2
+	to_account_id = $1
 ORDER BY id
-LIMIT $3
-OFFSET $4
+LIMIT $2
+OFFSET $3
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
 }
 
+// ListTransfers returns the transfers sent or received by an account.
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers, arg.FromAccountID, arg.ToAccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listTransfers, arg.AccountID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +175,14 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&transfer.FromAccountID,
 			&transfer.ToAccountID,
 			&transfer.Amount,
+			&transfer.ToAmount,
+			&transfer.ExchangeRate,
+			&transfer.ExchangeSpread,
+			&transfer.QuoteID,
+			&transfer.ReversalOf,
+			&transfer.Description,
+			&transfer.ExternalReference,
+			&transfer.Metadata,
 			&transfer.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -97,3 +197,170 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return transfers, nil
 }
+
+// listOwnerTransfers
+const listOwnerTransfers = `
+SELECT
+	t.id, t.from_account_id, t.to_account_id, t.amount, t.to_amount, t.exchange_rate, t.exchange_spread, t.quote_id, t.reversal_of, t.description, t.external_reference, t.metadata, t.created_at,
+	fa.currency AS from_currency,
+	ta.currency AS to_currency,
+	ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE
+	(fa.owner = $1 OR ta.owner = $1) AND
+	($2::varchar IS NULL OR t.external_reference = $2) AND
+	($3::varchar IS NULL OR
+		($3 = 'outgoing' AND fa.owner = $1) OR
+		($3 = 'incoming' AND ta.owner = $1)) AND
+	($4::bigint IS NULL OR
+		(fa.owner = $1 AND t.to_account_id = $4) OR
+		(ta.owner = $1 AND t.from_account_id = $4)) AND
+	($5::varchar IS NULL OR fa.currency = $5 OR ta.currency = $5) AND
+	($6::bigint IS NULL OR
+		(fa.currency = $5 AND t.amount >= $6) OR
+		(ta.currency = $5 AND t.to_amount >= $6)) AND
+	($7::bigint IS NULL OR
+		(fa.currency = $5 AND t.amount <= $7) OR
+		(ta.currency = $5 AND t.to_amount <= $7)) AND
+	($8::timestamptz IS NULL OR t.created_at >= $8) AND
+	($9::timestamptz IS NULL OR t.created_at < $9) AND
+	t.id > $10
+ORDER BY t.id
+LIMIT $11
+`
+
+// Directions of a transfer as seen by an owner. A transfer between two
+// accounts of the same owner is both.
+const (
+	TransferIncoming = "incoming"
+	TransferOutgoing = "outgoing"
+)
+
+type ListOwnerTransfersParams struct {
+	Owner string `json:"owner"`
+	// ExternalReference, when set, only keeps the transfers with that
+	// reference.
+	ExternalReference *string `json:"external_reference"`
+	// Direction, when set, only keeps the transfers sent (outgoing) or
+	// received (incoming) by the accounts of the owner.
+	Direction *string `json:"direction"`
+	// CounterpartyAccountID, when set, only keeps the transfers with that
+	// account on the other side.
+	CounterpartyAccountID *int64 `json:"counterparty_account_id"`
+	// Currency, when set, only keeps the transfers with either side in that
+	// currency. MinAmount and MaxAmount are compared with the amount in
+	// Currency, so they only apply with it.
+	Currency  *string    `json:"currency"`
+	MinAmount *int64     `json:"min_amount"`
+	MaxAmount *int64     `json:"max_amount"`
+	Since     *time.Time `json:"since"`
+	Until     *time.Time `json:"until"`
+	AfterID   int64      `json:"after_id"`
+	Limit     int32      `json:"limit"`
+}
+
+type ListOwnerTransfersRow struct {
+	Transfer     Transfer `json:"transfer"`
+	FromCurrency string   `json:"from_currency"`
+	ToCurrency   string   `json:"to_currency"`
+	ToOwner      string   `json:"to_owner"`
+}
+
+// ListOwnerTransfers returns the transfers sent or received by the accounts
+// of an owner, in id order.
+func (q *Queries) ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]ListOwnerTransfersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOwnerTransfers,
+		arg.Owner,
+		arg.ExternalReference,
+		arg.Direction,
+		arg.CounterpartyAccountID,
+		arg.Currency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Since,
+		arg.Until,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ListOwnerTransfersRow{}
+	for rows.Next() {
+		var i ListOwnerTransfersRow
+		if err := rows.Scan(
+			&i.Transfer.ID,
+			&i.Transfer.FromAccountID,
+			&i.Transfer.ToAccountID,
+			&i.Transfer.Amount,
+			&i.Transfer.ToAmount,
+			&i.Transfer.ExchangeRate,
+			&i.Transfer.ExchangeSpread,
+			&i.Transfer.QuoteID,
+			&i.Transfer.ReversalOf,
+			&i.Transfer.Description,
+			&i.Transfer.ExternalReference,
+			&i.Transfer.Metadata,
+			&i.Transfer.CreatedAt,
+			&i.FromCurrency,
+			&i.ToCurrency,
+			&i.ToOwner,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// countOwnerTransfersSince
+const countOwnerTransfersSince = `
+SELECT count(*) FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+WHERE fa.owner = $1 AND t.created_at >= $2
+`
+
+type CountOwnerTransfersSinceParams struct {
+	Owner string    `json:"owner"`
+	Since time.Time `json:"since"`
+}
+
+// CountOwnerTransfersSince returns how many transfers the accounts of an owner
+// sent since a given time.
+func (q *Queries) CountOwnerTransfersSince(ctx context.Context, arg CountOwnerTransfersSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOwnerTransfersSince, arg.Owner, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+// countOwnerTransfersTo
+const countOwnerTransfersTo = `
+SELECT count(*) FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+WHERE fa.owner = $1 AND t.to_account_id = $2
+`
+
+type CountOwnerTransfersToParams struct {
+	Owner       string `json:"owner"`
+	ToAccountID int64  `json:"to_account_id"`
+}
+
+// CountOwnerTransfersTo returns how many transfers the accounts of an owner
+// sent to an account.
+func (q *Queries) CountOwnerTransfersTo(ctx context.Context, arg CountOwnerTransfersToParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOwnerTransfersTo, arg.Owner, arg.ToAccountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
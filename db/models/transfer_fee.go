@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+)
+
+// Kinds of transfer fee. Both are bounded by the min and max fee of the
+// schedule.
+const (
+	FeeFlat       = "flat"
+	FeePercentage = "percentage"
+)
+
+// getTransferFee
+const getTransferFee = `
+SELECT currency, product, kind, amount, rate, min_fee, max_fee, updated_at FROM transfer_fees
+WHERE currency = $1 AND product = $2 LIMIT 1
+`
+
+type GetTransferFeeParams struct {
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+}
+
+func (q *Queries) GetTransferFee(ctx context.Context, arg GetTransferFeeParams) (TransferFee, error) {
+	row := q.db.QueryRowContext(ctx, getTransferFee, arg.Currency, arg.Product)
+	var i TransferFee
+	err := row.Scan(
+		&i.Currency,
+		&i.Product,
+		&i.Kind,
+		&i.Amount,
+		&i.Rate,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// listTransferFees
+const listTransferFees = `
+SELECT currency, product, kind, amount, rate, min_fee, max_fee, updated_at FROM transfer_fees
+ORDER BY currency, product
+`
+
+func (q *Queries) ListTransferFees(ctx context.Context) ([]TransferFee, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferFees)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []TransferFee{}
+	for rows.Next() {
+		var i TransferFee
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Product,
+			&i.Kind,
+			&i.Amount,
+			&i.Rate,
+			&i.MinFee,
+			&i.MaxFee,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// upsertTransferFee
+const upsertTransferFee = `
+INSERT INTO transfer_fees (
+	currency,
+	product,
+	kind,
+	amount,
+	rate,
+	min_fee,
+	max_fee
+) VALUES (
+	$1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (currency, product) DO UPDATE SET
+	kind = EXCLUDED.kind,
+	amount = EXCLUDED.amount,
+	rate = EXCLUDED.rate,
+	min_fee = EXCLUDED.min_fee,
+	max_fee = EXCLUDED.max_fee,
+	updated_at = now()
+RETURNING currency, product, kind, amount, rate, min_fee, max_fee, updated_at
+`
+
+type UpsertTransferFeeParams struct {
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+	Kind     string `json:"kind"`
+	Amount   int64  `json:"amount"`
+	Rate     string `json:"rate"`
+	MinFee   int64  `json:"min_fee"`
+	MaxFee   *int64 `json:"max_fee"`
+}
+
+func (q *Queries) UpsertTransferFee(ctx context.Context, arg UpsertTransferFeeParams) (TransferFee, error) {
+	row := q.db.QueryRowContext(ctx, upsertTransferFee,
+		arg.Currency,
+		arg.Product,
+		arg.Kind,
+		arg.Amount,
+		arg.Rate,
+		arg.MinFee,
+		arg.MaxFee,
+	)
+	var i TransferFee
+	err := row.Scan(
+		&i.Currency,
+		&i.Product,
+		&i.Kind,
+		&i.Amount,
+		&i.Rate,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+// deleteTransferFee
+const deleteTransferFee = `
+DELETE FROM transfer_fees
+WHERE currency = $1 AND product = $2
+`
+
+type DeleteTransferFeeParams struct {
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+}
+
+func (q *Queries) DeleteTransferFee(ctx context.Context, arg DeleteTransferFeeParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTransferFee, arg.Currency, arg.Product)
+	return err
+}
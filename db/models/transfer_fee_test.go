@@ -0,0 +1,294 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simple_bank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setTransferFee installs a fee for the savings accounts of currency and
+// removes it when the test ends, so that other tests transfer for free.
+func setTransferFee(t *testing.T, arg UpsertTransferFeeParams) TransferFee {
+	arg.Product = ProductSavings
+	if arg.Rate == "" {
+		arg.Rate = "0"
+	}
+
+	fee, err := testQueries.UpsertTransferFee(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Currency, fee.Currency)
+	require.Equal(t, arg.Kind, fee.Kind)
+	require.Equal(t, arg.Amount, fee.Amount)
+	require.Equal(t, arg.MinFee, fee.MinFee)
+	require.Equal(t, arg.MaxFee, fee.MaxFee)
+
+	t.Cleanup(func() {
+		err := testQueries.DeleteTransferFee(context.Background(), DeleteTransferFeeParams{
+			Currency: arg.Currency,
+			Product:  arg.Product,
+		})
+		require.NoError(t, err)
+	})
+	return fee
+}
+
+func getFeeRevenue(t *testing.T, currency string) int64 {
+	account, err := getSystemAccount(context.Background(), testQueries, FeesUsername, currency)
+	require.NoError(t, err)
+	return account.Balance
+}
+
+func TestUpsertTransferFee(t *testing.T) {
+	maxFee := int64(500)
+	setTransferFee(t, UpsertTransferFeeParams{
+		Currency: util.USD,
+		Kind:     FeePercentage,
+		Rate:     "0.01",
+		MinFee:   50,
+		MaxFee:   &maxFee,
+	})
+
+	// the fee of a currency and product is replaced, not added to
+	fee := setTransferFee(t, UpsertTransferFeeParams{
+		Currency: util.USD,
+		Kind:     FeeFlat,
+		Amount:   25,
+	})
+	require.Nil(t, fee.MaxFee)
+
+	got, err := testQueries.GetTransferFee(context.Background(), GetTransferFeeParams{
+		Currency: util.USD,
+		Product:  ProductSavings,
+	})
+	require.NoError(t, err)
+	require.Equal(t, FeeFlat, got.Kind)
+	require.Equal(t, int64(25), got.Amount)
+
+	fees, err := testQueries.ListTransferFees(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, fees, got)
+
+	_, err = testQueries.GetTransferFee(context.Background(), GetTransferFeeParams{
+		Currency: util.USD,
+		Product:  ProductChecking,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestComputeFee(t *testing.T) {
+	maxFee := int64(500)
+
+	testCases := []struct {
+		name     string
+		schedule TransferFee
+		amount   int64
+		fee      int64
+	}{
+		{
+			name:     "Flat",
+			schedule: TransferFee{Kind: FeeFlat, Amount: 25},
+			amount:   100000,
+			fee:      25,
+		},
+		{
+			name:     "Percentage",
+			schedule: TransferFee{Kind: FeePercentage, Rate: "0.01", MaxFee: &maxFee},
+			amount:   12345,
+			fee:      123,
+		},
+		{
+			name:     "PercentageRoundsHalfUp",
+			schedule: TransferFee{Kind: FeePercentage, Rate: "0.01"},
+			amount:   150,
+			fee:      2,
+		},
+		{
+			name:     "MinFee",
+			schedule: TransferFee{Kind: FeePercentage, Rate: "0.01", MinFee: 50, MaxFee: &maxFee},
+			amount:   1000,
+			fee:      50,
+		},
+		{
+			name:     "MaxFee",
+			schedule: TransferFee{Kind: FeePercentage, Rate: "0.01", MinFee: 50, MaxFee: &maxFee},
+			amount:   1000000,
+			fee:      500,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := ComputeFee(tc.schedule, tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.fee, fee.Amount)
+		})
+	}
+
+	_, err := ComputeFee(TransferFee{Kind: FeePercentage, Rate: "1%"}, 100)
+	require.Error(t, err)
+}
+
+func TestTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	maxFee := int64(500)
+	setTransferFee(t, UpsertTransferFeeParams{
+		Currency: util.USD,
+		Kind:     FeePercentage,
+		Rate:     "0.01",
+		MinFee:   50,
+		MaxFee:   &maxFee,
+	})
+
+	from := createSavingsAccount(t, 10000)
+	to := createFundedAccountInCurrency(t, util.USD, 0)
+	revenue := getFeeRevenue(t, util.USD)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Fee)
+	require.Equal(t, FeePercentage, result.Fee.Kind)
+	require.Equal(t, int64(10), result.Fee.Calculated)
+	require.Equal(t, int64(50), result.Fee.Amount)
+
+	require.Len(t, result.FeeEntries, 2)
+	require.Equal(t, from.ID, result.FeeEntries[0].AccountID)
+	require.Equal(t, int64(-50), result.FeeEntries[0].Amount)
+	require.Equal(t, int64(50), result.FeeEntries[1].Amount)
+	for _, entry := range result.FeeEntries {
+		require.Equal(t, result.Transfer.ID, *entry.TransferID)
+	}
+
+	require.Equal(t, int64(1000), result.Transfer.Amount)
+	require.Equal(t, int64(8950), result.FromAccount.Balance)
+	require.Equal(t, int64(1000), result.ToAccount.Balance)
+	require.Equal(t, revenue+50, getFeeRevenue(t, util.USD))
+
+	// the fee counts against the funds of the account
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        8950,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxWithoutFee(t *testing.T) {
+	store := NewStore(testDB)
+	setTransferFee(t, UpsertTransferFeeParams{
+		Currency: util.USD,
+		Kind:     FeeFlat,
+		Amount:   25,
+	})
+
+	// the fee only applies to savings accounts
+	from := createFundedAccountInCurrency(t, util.USD, 1000)
+	to := createFundedAccountInCurrency(t, util.USD, 0)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Fee)
+	require.Empty(t, result.FeeEntries)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+func TestBatchTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	setTransferFee(t, UpsertTransferFeeParams{
+		Currency: util.USD,
+		Kind:     FeeFlat,
+		Amount:   25,
+	})
+
+	payroll := createSavingsAccount(t, 1050)
+	account1 := createFundedAccountInCurrency(t, util.USD, 0)
+	account2 := createFundedAccountInCurrency(t, util.USD, 0)
+	revenue := getFeeRevenue(t, util.USD)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: payroll.ID, ToAccountID: account1.ID, Amount: 300},
+			{FromAccountID: payroll.ID, ToAccountID: account2.ID, Amount: 700},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, leg := range result.Transfers {
+		require.NotNil(t, leg.Fee)
+		require.Equal(t, int64(25), leg.Fee.Amount)
+		require.Len(t, leg.FeeEntries, 2)
+	}
+
+	balances := make(map[int64]int64)
+	for _, account := range result.Accounts {
+		balances[account.ID] = account.Balance
+	}
+	require.Len(t, balances, 3)
+	require.Zero(t, balances[payroll.ID])
+	require.Equal(t, revenue+50, getFeeRevenue(t, util.USD))
+
+	// without enough left for the fees, nothing is transferred
+	savings := createSavingsAccount(t, 700)
+	_, err = store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Transfers: []TransferTxParams{
+			{FromAccountID: savings.ID, ToAccountID: account1.ID, Amount: 700},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestExchangeTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	setTransferFee(t, UpsertTransferFeeParams{
+		Currency: util.USD,
+		Kind:     FeeFlat,
+		Amount:   25,
+	})
+
+	from := createSavingsAccount(t, 1000)
+	to := createFundedAccountInCurrency(t, util.EUR, 0)
+	revenue := getFeeRevenue(t, util.USD)
+
+	arg := ExchangeTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        500,
+		},
+		ToAmount: 450,
+	}
+
+	quote := createRandomExchangeQuote(t, createRandomUser(t), util.USD, util.EUR)
+	arg.ExchangeRate, arg.ExchangeSpread, arg.QuoteID = quote.Rate, quote.Spread, quote.ID
+	result, err := store.ExchangeTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// the fee is charged in the currency of the from account
+	require.NotNil(t, result.Fee)
+	require.Equal(t, util.USD, result.Fee.Currency)
+	require.Equal(t, int64(25), result.Fee.Amount)
+	require.Len(t, result.FeeEntries, 2)
+	require.Equal(t, int64(-25), result.FeeEntries[0].Amount)
+
+	require.Equal(t, int64(475), result.FromAccount.Balance)
+	require.Equal(t, int64(450), result.ToAccount.Balance)
+	require.Equal(t, revenue+25, getFeeRevenue(t, util.USD))
+
+	// the fee counts against the funds of the account
+	quote = createRandomExchangeQuote(t, createRandomUser(t), util.USD, util.EUR)
+	arg.Amount = 475
+	arg.ExchangeRate, arg.ExchangeSpread, arg.QuoteID = quote.Rate, quote.Spread, quote.ID
+	_, err = store.ExchangeTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
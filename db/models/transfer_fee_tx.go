@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+)
+
+// FeesUsername owns the per-currency revenue accounts that collect transfer
+// fees. A fee is debited from the sender on top of the amount of the transfer
+// and credited to the revenue account of its currency, so the entries of the
+// ledger still sum up to zero.
+const FeesUsername = "fees"
+
+const feeEntryDescription = "Transfer fee"
+
+// FeeBreakdown tells how the fee of a transfer was computed from the fee
+// schedule of the from account.
+type FeeBreakdown struct {
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+	Kind     string `json:"kind"`
+	// Rate is the share of the amount charged by a percentage fee.
+	Rate string `json:"rate,omitempty"`
+	// Calculated is the flat amount or the share of the amount, before the
+	// min and max fee of the schedule are applied.
+	Calculated int64  `json:"calculated"`
+	MinFee     int64  `json:"min_fee"`
+	MaxFee     *int64 `json:"max_fee"`
+	// Amount is what the sender is charged.
+	Amount int64 `json:"amount"`
+}
+
+// ComputeFee returns the fee of transferring amount under schedule. A
+// percentage fee is rounded half up to the minor unit.
+func ComputeFee(schedule TransferFee, amount int64) (FeeBreakdown, error) {
+	fee := FeeBreakdown{
+		Currency: schedule.Currency,
+		Product:  schedule.Product,
+		Kind:     schedule.Kind,
+		MinFee:   schedule.MinFee,
+		MaxFee:   schedule.MaxFee,
+	}
+
+	switch schedule.Kind {
+	case FeeFlat:
+		fee.Calculated = schedule.Amount
+	case FeePercentage:
+		rate, ok := new(big.Rat).SetString(schedule.Rate)
+		if !ok || rate.Sign() < 0 {
+			return fee, fmt.Errorf("invalid fee rate %q", schedule.Rate)
+		}
+		fee.Rate = schedule.Rate
+
+		share := new(big.Rat).Mul(big.NewRat(amount, 1), rate)
+		share.Add(share, big.NewRat(1, 2))
+		fee.Calculated = new(big.Int).Quo(share.Num(), share.Denom()).Int64()
+	default:
+		return fee, fmt.Errorf("unsupported fee kind %q", schedule.Kind)
+	}
+
+	fee.Amount = fee.Calculated
+	if fee.Amount < fee.MinFee {
+		fee.Amount = fee.MinFee
+	}
+	if fee.MaxFee != nil && fee.Amount > *fee.MaxFee {
+		fee.Amount = *fee.MaxFee
+	}
+	return fee, nil
+}
+
+// transferFee returns the fee of transferring amount from account, or nil
+// when its currency and product have no fee schedule or the fee is zero.
+func transferFee(ctx context.Context, q *Queries, account Account, amount int64) (*FeeBreakdown, error) {
+	schedule, err := q.GetTransferFee(ctx, GetTransferFeeParams{
+		Currency: account.Currency,
+		Product:  account.Product,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	fee, err := ComputeFee(schedule, amount)
+	if err != nil || fee.Amount == 0 {
+		return nil, err
+	}
+	return &fee, nil
+}
+
+// postFee records the entries moving fee from the from account of a
+// transfer to the revenue account of its currency. Balances are left to the
+// caller; the revenue account is returned so it can be credited last.
+func postFee(ctx context.Context, q *Queries, transfer Transfer, fee FeeBreakdown) (entries []Entry, revenueAccountID int64, err error) {
+	revenue, err := getSystemAccount(ctx, q, FeesUsername, fee.Currency)
+	if err != nil {
+		return
+	}
+
+	postings := []CreateEntryParams{
+		{AccountID: transfer.FromAccountID, Amount: -fee.Amount},
+		{AccountID: revenue.ID, Amount: fee.Amount},
+	}
+
+	entries = make([]Entry, len(postings))
+	for i, posting := range postings {
+		posting.TransferID = &transfer.ID
+		posting.Description = feeEntryDescription
+		entries[i], err = q.CreateEntry(ctx, posting)
+		if err != nil {
+			return
+		}
+	}
+	return entries, revenue.ID, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"simple_bank/util"
 	"testing"
 	"time"
@@ -10,10 +11,12 @@ import (
 )
 
 func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
+	amount := util.RandomMoney()
 	arg := CreateTransferParams{
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
-		Amount:        util.RandomMoney(),
+		Amount:        amount,
+		ToAmount:      amount,
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
@@ -23,6 +26,11 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.ToAmount, transfer.ToAmount)
+	require.Nil(t, transfer.ExchangeRate)
+	require.Empty(t, transfer.Description)
+	require.Nil(t, transfer.ExternalReference)
+	require.JSONEq(t, `{}`, string(transfer.Metadata))
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -62,10 +70,9 @@ func TestListTransfers(t *testing.T) {
 	}
 
 	arg := ListTransfersParams{
-		FromAccountID: account1.ID,
-		ToAccountID:   account1.ID,
-		Limit:         5,
-		Offset:        0,
+		AccountID: account1.ID,
+		Limit:     5,
+		Offset:    0,
 	}
 
 	transfers, err := testQueries.ListTransfers(context.Background(), arg)
@@ -77,3 +84,181 @@ func TestListTransfers(t *testing.T) {
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+func TestCreateTransferWithDetails(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	reference := util.RandomString(12)
+	arg := CreateTransferParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            10,
+		ToAmount:          10,
+		Description:       "rent for march",
+		ExternalReference: &reference,
+		Metadata:          json.RawMessage(`{"invoice": "2024-03", "lines": [1, 2]}`),
+	}
+
+	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Description, transfer.Description)
+	require.Equal(t, reference, *transfer.ExternalReference)
+	require.JSONEq(t, string(arg.Metadata), string(transfer.Metadata))
+
+	got, err := testQueries.GetTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Description, got.Description)
+	require.JSONEq(t, string(arg.Metadata), string(got.Metadata))
+}
+
+func TestListOwnerTransfersByReference(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	reference := util.RandomString(12)
+	var referenced []Transfer
+	for i := 0; i < 2; i++ {
+		transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+			FromAccountID:     account1.ID,
+			ToAccountID:       account2.ID,
+			Amount:            10,
+			ToAmount:          10,
+			ExternalReference: &reference,
+		})
+		require.NoError(t, err)
+		referenced = append(referenced, transfer)
+	}
+	createRandomTransfer(t, account1, account2)
+
+	// same reference, but between accounts of other owners
+	_, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID:     other.ID,
+		ToAccountID:       other.ID,
+		Amount:            10,
+		ToAmount:          10,
+		ExternalReference: &reference,
+	})
+	require.NoError(t, err)
+
+	for _, owner := range []string{account1.Owner, account2.Owner} {
+		rows, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+			Owner:             owner,
+			ExternalReference: &reference,
+			Limit:             10,
+		})
+		require.NoError(t, err)
+		require.Len(t, rows, len(referenced))
+		for i, row := range rows {
+			require.Equal(t, referenced[i].ID, row.Transfer.ID)
+			require.Equal(t, account1.Currency, row.FromCurrency)
+			require.Equal(t, account2.Currency, row.ToCurrency)
+			require.Equal(t, account2.Owner, row.ToOwner)
+		}
+	}
+
+	rows, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner: account1.Owner,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, len(referenced)+1)
+}
+
+func TestListOwnerTransfersFilters(t *testing.T) {
+	account1 := createFundedAccountInCurrency(t, util.USD, 0)
+	account2 := createFundedAccountInCurrency(t, util.USD, 0)
+	account3 := createFundedAccountInCurrency(t, util.EUR, 0)
+
+	createTransfer := func(from, to Account, amount int64) Transfer {
+		transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+			ToAmount:      amount,
+		})
+		require.NoError(t, err)
+		return transfer
+	}
+
+	since := time.Now().Add(-time.Minute)
+	sent := createTransfer(account1, account2, 100)
+	received := createTransfer(account2, account1, 500)
+	exchanged := createTransfer(account3, account1, 900)
+
+	list := func(arg ListOwnerTransfersParams) []int64 {
+		arg.Owner = account1.Owner
+		arg.Limit = 10
+		rows, err := testQueries.ListOwnerTransfers(context.Background(), arg)
+		require.NoError(t, err)
+
+		ids := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.Transfer.ID)
+		}
+		return ids
+	}
+
+	outgoing := TransferOutgoing
+	incoming := TransferIncoming
+	require.Equal(t, []int64{sent.ID}, list(ListOwnerTransfersParams{Direction: &outgoing}))
+	require.Equal(t, []int64{received.ID, exchanged.ID}, list(ListOwnerTransfersParams{Direction: &incoming}))
+
+	require.Equal(t, []int64{sent.ID, received.ID}, list(ListOwnerTransfersParams{CounterpartyAccountID: &account2.ID}))
+	require.Equal(t, []int64{exchanged.ID}, list(ListOwnerTransfersParams{CounterpartyAccountID: &account3.ID}))
+
+	eur := util.EUR
+	require.Equal(t, []int64{exchanged.ID}, list(ListOwnerTransfersParams{Currency: &eur}))
+
+	usd := util.USD
+	minAmount, maxAmount := int64(200), int64(600)
+	require.Equal(t, []int64{received.ID}, list(ListOwnerTransfersParams{
+		Currency:  &usd,
+		MinAmount: &minAmount,
+		MaxAmount: &maxAmount,
+	}))
+
+	until := time.Now().Add(time.Minute)
+	require.Len(t, list(ListOwnerTransfersParams{Since: &since, Until: &until}), 3)
+	require.Empty(t, list(ListOwnerTransfersParams{Since: &until}))
+}
+
+func TestCountOwnerTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	since := time.Now().Add(-time.Minute)
+	createRandomTransfer(t, account1, account2)
+	createRandomTransfer(t, account1, account2)
+	createRandomTransfer(t, account2, account1)
+
+	count, err := testQueries.CountOwnerTransfersSince(context.Background(), CountOwnerTransfersSinceParams{
+		Owner: account1.Owner,
+		Since: since,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = testQueries.CountOwnerTransfersSince(context.Background(), CountOwnerTransfersSinceParams{
+		Owner: account1.Owner,
+		Since: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	count, err = testQueries.CountOwnerTransfersTo(context.Background(), CountOwnerTransfersToParams{
+		Owner:       account1.Owner,
+		ToAccountID: account2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = testQueries.CountOwnerTransfersTo(context.Background(), CountOwnerTransfersToParams{
+		Owner:       account1.Owner,
+		ToAccountID: account3.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
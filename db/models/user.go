@@ -11,7 +11,7 @@ INSERT INTO users (
 	email
 ) VALUES (
 	$1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+) RETURNING username, hashed_password, full_name, email, role, password_changed_at, created_at
 `
 
 type CreateUserParams struct {
@@ -29,6 +29,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&user.HashedPassword,
 		&user.FullName,
 		&user.Email,
+		&user.Role,
 		&user.PasswordChangedAt,
 		&user.CreatedAt,
 	)
@@ -37,7 +38,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 
 // getUser
 const getUser = `
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at
+SELECT username, hashed_password, full_name, email, role, password_changed_at, created_at
 FROM users WHERE username = $1
 LIMIT 1
 `
@@ -50,6 +51,29 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&user.HashedPassword,
 		&user.FullName,
 		&user.Email,
+		&user.Role,
+		&user.PasswordChangedAt,
+		&user.CreatedAt,
+	)
+	return user, err
+}
+
+// getUserByEmail
+const getUserByEmail = `
+SELECT username, hashed_password, full_name, email, role, password_changed_at, created_at
+FROM users WHERE email = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var user User
+	err := row.Scan(
+		&user.Username,
+		&user.HashedPassword,
+		&user.FullName,
+		&user.Email,
+		&user.Role,
 		&user.PasswordChangedAt,
 		&user.CreatedAt,
 	)
@@ -0,0 +1,32 @@
+package fx
+
+import (
+	"context"
+	db "simple_bank/db/models"
+)
+
+// Import loads the rates in path and stores them, completed with the inverse
+// and cross rates, in the exchange_rates table. All rates in the file must be
+// quoted against the same base currency. It returns the number of stored rates.
+func Import(ctx context.Context, store db.Store, path string) (int, error) {
+	rates, err := LoadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	rates, err = CrossRates(rates)
+	if err != nil {
+		return 0, err
+	}
+
+	arg := make([]db.UpsertExchangeRateParams, len(rates))
+	for i, rate := range rates {
+		arg[i] = db.UpsertExchangeRateParams{
+			BaseCurrency:  rate.Base,
+			QuoteCurrency: rate.Quote,
+			Rate:          FormatDecimal(rate.Rate),
+		}
+	}
+
+	return len(arg), store.UpsertExchangeRatesTx(ctx, arg)
+}
@@ -0,0 +1,29 @@
+package fx
+
+import (
+	"context"
+	"path/filepath"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("UpsertExchangeRatesTx", mock.Anything, mock.MatchedBy(func(arg []db.UpsertExchangeRateParams) bool {
+			return len(arg) == 6 && arg[0] == db.UpsertExchangeRateParams{
+				BaseCurrency:  "EUR",
+				QuoteCurrency: "USD",
+				Rate:          "1.1128",
+			}
+		})).
+		Return(nil)
+
+	n, err := Import(context.Background(), storeMock, filepath.Join("testdata", "eurofxref.xml"))
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+}
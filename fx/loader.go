@@ -0,0 +1,102 @@
+package fx
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ecbBaseCurrency is the base of the euro foreign exchange reference rates.
+const ecbBaseCurrency = "EUR"
+
+// LoadFile reads exchange rates from a .csv file or an ECB-style .xml file.
+func LoadFile(path string) ([]Rate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(file)
+	case ".xml":
+		return ParseECB(file)
+	}
+	return nil, fmt.Errorf("unsupported exchange rate file %q", path)
+}
+
+// ParseCSV reads rows of base_currency,quote_currency,rate. The first row is
+// a header.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	rates := make([]Rate, 0, len(records)-1)
+	for _, record := range records[1:] {
+		rate, err := ParseDecimal(record[2])
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, Rate{
+			Base:  strings.ToUpper(record[0]),
+			Quote: strings.ToUpper(record[1]),
+			Rate:  rate,
+		})
+	}
+	return rates, nil
+}
+
+type ecbEnvelope struct {
+	Days []ecbDay `xml:"Cube>Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// ParseECB reads the euro reference rates published by the European Central
+// Bank. When the file holds several days, the first (most recent) one is used.
+func ParseECB(r io.Reader) ([]Rate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if len(envelope.Days) == 0 {
+		return nil, fmt.Errorf("%w: no rates in file", ErrInvalidRate)
+	}
+
+	day := envelope.Days[0]
+	rates := make([]Rate, 0, len(day.Rates))
+	for _, ecb := range day.Rates {
+		rate, err := ParseDecimal(ecb.Rate)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, Rate{
+			Base:  ecbBaseCurrency,
+			Quote: ecb.Currency,
+			Rate:  rate,
+		})
+	}
+	return rates, nil
+}
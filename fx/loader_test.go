@@ -0,0 +1,41 @@
+package fx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCSV(t *testing.T) {
+	rates, err := LoadFile(filepath.Join("testdata", "rates.csv"))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+
+	require.Equal(t, "USD", rates[0].Base)
+	require.Equal(t, "EUR", rates[0].Quote)
+	require.Equal(t, "0.9", FormatDecimal(rates[0].Rate))
+
+	require.Equal(t, "USD", rates[1].Base)
+	require.Equal(t, "CAD", rates[1].Quote)
+	require.Equal(t, "1.25", FormatDecimal(rates[1].Rate))
+}
+
+func TestLoadECB(t *testing.T) {
+	rates, err := LoadFile(filepath.Join("testdata", "eurofxref.xml"))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+
+	// only the most recent day is used
+	require.Equal(t, "EUR", rates[0].Base)
+	require.Equal(t, "USD", rates[0].Quote)
+	require.Equal(t, "1.1128", FormatDecimal(rates[0].Rate))
+
+	require.Equal(t, "CAD", rates[1].Quote)
+	require.Equal(t, "1.4054", FormatDecimal(rates[1].Rate))
+}
+
+func TestLoadUnsupportedFile(t *testing.T) {
+	_, err := LoadFile(filepath.Join("testdata", "rates.json"))
+	require.Error(t, err)
+}
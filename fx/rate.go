@@ -0,0 +1,118 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// rateScale is the number of decimals kept when a rate is stored.
+const rateScale = 10
+
+var (
+	ErrInvalidRate        = errors.New("invalid exchange rate")
+	ErrConversionOverflow = errors.New("converted amount overflows int64")
+)
+
+// Rate is the price of one unit of Base expressed in Quote.
+type Rate struct {
+	Base  string
+	Quote string
+	Rate  *big.Rat
+}
+
+// ParseDecimal parses a non-negative decimal number such as "1.0845".
+func ParseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return r, nil
+}
+
+// FormatDecimal renders r with rateScale decimals, without trailing zeros.
+func FormatDecimal(r *big.Rat) string {
+	s := r.FloatString(rateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// ApplySpread returns the rate offered to customers, which is the mid rate
+// lowered by spread, e.g. 0.005 for half a percent.
+func ApplySpread(rate *big.Rat, spread *big.Rat) *big.Rat {
+	factor := new(big.Rat).Sub(big.NewRat(1, 1), spread)
+	return factor.Mul(factor, rate)
+}
+
+// Convert returns amount multiplied by rate, rounded down so the bank never
+// credits more than the rate allows.
+func Convert(amount int64, rate *big.Rat) (int64, error) {
+	product := new(big.Rat).Mul(big.NewRat(amount, 1), rate)
+	converted := new(big.Int).Quo(product.Num(), product.Denom())
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("%w: %d at %s", ErrConversionOverflow, amount, FormatDecimal(rate))
+	}
+	return converted.Int64(), nil
+}
+
+// ConvertMinorUnits converts an amount of minor units of a currency with
+// fromExponent decimals into minor units of a currency with toExponent
+// decimals, e.g. USD cents into JPY yen. The result is rounded down.
+func ConvertMinorUnits(amount int64, rate *big.Rat, fromExponent int, toExponent int) (int64, error) {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil)
+	scaled := new(big.Rat).Set(rate)
+	if toExponent > fromExponent {
+		scaled.Mul(scaled, new(big.Rat).SetInt(scale))
+	} else {
+		scaled.Quo(scaled, new(big.Rat).SetInt(scale))
+	}
+	return Convert(amount, scaled)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// CrossRates completes rates quoted against a single base currency, as
+// published by central banks, with the inverse and cross rates between every
+// pair of currencies.
+func CrossRates(rates []Rate) ([]Rate, error) {
+	if len(rates) == 0 {
+		return nil, nil
+	}
+
+	base := rates[0].Base
+	perBase := map[string]*big.Rat{base: big.NewRat(1, 1)}
+	currencies := []string{base}
+	for _, rate := range rates {
+		if rate.Base != base {
+			return nil, fmt.Errorf("%w: rates use different base currencies %s and %s", ErrInvalidRate, base, rate.Base)
+		}
+		if rate.Rate.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: %s/%s is not positive", ErrInvalidRate, rate.Base, rate.Quote)
+		}
+		if _, ok := perBase[rate.Quote]; !ok {
+			currencies = append(currencies, rate.Quote)
+		}
+		perBase[rate.Quote] = rate.Rate
+	}
+
+	var result []Rate
+	for _, from := range currencies {
+		for _, to := range currencies {
+			if from == to {
+				continue
+			}
+			result = append(result, Rate{
+				Base:  from,
+				Quote: to,
+				Rate:  new(big.Rat).Quo(perBase[to], perBase[from]),
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package fx
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	rate, err := ParseDecimal("1.0845")
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(10845, 10000), rate)
+	require.Equal(t, "1.0845", FormatDecimal(rate))
+
+	_, err = ParseDecimal("-1")
+	require.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = ParseDecimal("abc")
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestFormatDecimal(t *testing.T) {
+	require.Equal(t, "1", FormatDecimal(big.NewRat(1, 1)))
+	require.Equal(t, "0.5", FormatDecimal(big.NewRat(1, 2)))
+	require.Equal(t, "0.3333333333", FormatDecimal(big.NewRat(1, 3)))
+}
+
+func TestApplySpread(t *testing.T) {
+	rate := ApplySpread(big.NewRat(2, 1), big.NewRat(1, 100))
+	require.Equal(t, "1.98", FormatDecimal(rate))
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		amount    int64
+		rate      *big.Rat
+		converted int64
+	}{
+		{100, big.NewRat(198, 100), 198},
+		// rounds down
+		{100, big.NewRat(1, 3), 33},
+		{1, big.NewRat(1, 2), 0},
+	}
+
+	for _, tc := range testCases {
+		converted, err := Convert(tc.amount, tc.rate)
+		require.NoError(t, err)
+		require.Equal(t, tc.converted, converted)
+	}
+}
+
+func TestConvertOverflow(t *testing.T) {
+	_, err := Convert(math.MaxInt64, big.NewRat(2, 1))
+	require.ErrorIs(t, err, ErrConversionOverflow)
+
+	_, err = ConvertMinorUnits(math.MaxInt64/10, big.NewRat(150, 1), 0, 2)
+	require.ErrorIs(t, err, ErrConversionOverflow)
+}
+
+func TestConvertMinorUnits(t *testing.T) {
+	testCases := []struct {
+		amount       int64
+		rate         *big.Rat
+		fromExponent int
+		toExponent   int
+		converted    int64
+	}{
+		// 12.34 USD at 150 JPY per USD is 1851 JPY
+		{1234, big.NewRat(150, 1), 2, 0, 1851},
+		// 1000 JPY at 0.0021 KWD per JPY is 2.100 KWD
+		{1000, big.NewRat(21, 10000), 0, 3, 2100},
+		// same exponent
+		{100, big.NewRat(9, 10), 2, 2, 90},
+	}
+
+	for _, tc := range testCases {
+		converted, err := ConvertMinorUnits(tc.amount, tc.rate, tc.fromExponent, tc.toExponent)
+		require.NoError(t, err)
+		require.Equal(t, tc.converted, converted)
+	}
+}
+
+func TestCrossRates(t *testing.T) {
+	rates, err := CrossRates([]Rate{
+		{Base: "EUR", Quote: "USD", Rate: big.NewRat(11, 10)},
+		{Base: "EUR", Quote: "CAD", Rate: big.NewRat(14, 10)},
+	})
+	require.NoError(t, err)
+	require.Len(t, rates, 6)
+
+	got := make(map[string]string)
+	for _, rate := range rates {
+		got[rate.Base+"/"+rate.Quote] = FormatDecimal(rate.Rate)
+	}
+	require.Equal(t, map[string]string{
+		"EUR/USD": "1.1",
+		"EUR/CAD": "1.4",
+		"USD/EUR": "0.9090909091",
+		"USD/CAD": "1.2727272727",
+		"CAD/EUR": "0.7142857143",
+		"CAD/USD": "0.7857142857",
+	}, got)
+}
+
+func TestCrossRatesMixedBase(t *testing.T) {
+	_, err := CrossRates([]Rate{
+		{Base: "EUR", Quote: "USD", Rate: big.NewRat(11, 10)},
+		{Base: "USD", Quote: "CAD", Rate: big.NewRat(13, 10)},
+	})
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
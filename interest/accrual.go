@@ -0,0 +1,175 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	db "simple_bank/db/models"
+	"time"
+)
+
+// amountScale is the number of decimals of a minor unit kept per accrual, so
+// that rounding only happens once, when the month is posted.
+const amountScale = 10
+
+const (
+	defaultInterval     = time.Hour
+	defaultLookbackDays = 7
+)
+
+// Accruer computes the daily interest of savings accounts and posts it
+// monthly. Every step is idempotent, so it can run on several replicas and be
+// re-run for days that were missed.
+type Accruer struct {
+	store        db.Store
+	lookbackDays int
+	now          func() time.Time
+}
+
+// NewAccruer returns an Accruer that, on every run, accrues each of the last
+// lookbackDays days that were not accrued yet.
+func NewAccruer(store db.Store, lookbackDays int) *Accruer {
+	if lookbackDays <= 0 {
+		lookbackDays = defaultLookbackDays
+	}
+	return &Accruer{
+		store:        store,
+		lookbackDays: lookbackDays,
+		now:          time.Now,
+	}
+}
+
+// Accrue records the interest earned on date by every account with a positive
+// balance at the end of that day. Accounts that already accrued date are
+// skipped. It returns the number of new accruals.
+func (a *Accruer) Accrue(ctx context.Context, date time.Time) (int, error) {
+	date = Date(date)
+	nextDate := date.AddDate(0, 0, 1)
+
+	balances, err := a.store.ListInterestBearingBalances(ctx, db.ListInterestBearingBalancesParams{
+		AccrualDate: date,
+		EndTime:     nextDate,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, balance := range balances {
+		if balance.Balance <= 0 {
+			continue
+		}
+
+		amount, err := dailyInterest(balance, date, nextDate)
+		if err != nil {
+			return n, fmt.Errorf("account %d: %w", balance.AccountID, err)
+		}
+
+		_, err = a.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+			AccountID:    balance.AccountID,
+			AccrualDate:  date,
+			Balance:      balance.Balance,
+			InterestRate: balance.InterestRate,
+			DayCount:     balance.DayCount,
+			Amount:       amount,
+		})
+		if err == sql.ErrNoRows {
+			// another replica accrued this day in the meantime
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// dailyInterest returns balance * rate * year fraction of the day, in minor
+// units with amountScale decimals.
+func dailyInterest(balance db.ListInterestBearingBalancesRow, date time.Time, nextDate time.Time) (string, error) {
+	rate, ok := new(big.Rat).SetString(balance.InterestRate)
+	if !ok {
+		return "", fmt.Errorf("invalid interest rate %q", balance.InterestRate)
+	}
+
+	fraction, err := YearFraction(balance.DayCount, date, nextDate)
+	if err != nil {
+		return "", err
+	}
+
+	amount := new(big.Rat).SetInt64(balance.Balance)
+	amount.Mul(amount, rate).Mul(amount, fraction)
+	return amount.FloatString(amountScale), nil
+}
+
+// Post pays the interest accrued before the given date to every account that
+// has some unposted. It returns the number of accounts paid.
+func (a *Accruer) Post(ctx context.Context, before time.Time) (int, error) {
+	before = Date(before)
+
+	accountIDs, err := a.store.ListUnpostedInterestAccounts(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, accountID := range accountIDs {
+		result, err := a.store.PostInterestTx(ctx, db.PostInterestTxParams{
+			AccountID: accountID,
+			Before:    before,
+		})
+		if err != nil {
+			return n, fmt.Errorf("account %d: %w", accountID, err)
+		}
+		if result.Transfer.ID != 0 {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RunOnce accrues every missing day of the lookback window up to yesterday,
+// then posts the interest of the months that are over.
+func (a *Accruer) RunOnce(ctx context.Context) error {
+	today := Date(a.now())
+
+	for date := today.AddDate(0, 0, -a.lookbackDays); date.Before(today); date = date.AddDate(0, 0, 1) {
+		if _, err := a.Accrue(ctx, date); err != nil {
+			return fmt.Errorf("cannot accrue interest for %s: %w", date.Format("2006-01-02"), err)
+		}
+	}
+
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if _, err := a.Post(ctx, monthStart); err != nil {
+		return fmt.Errorf("cannot post interest: %w", err)
+	}
+	return nil
+}
+
+// Run calls RunOnce immediately and then every interval until ctx is done.
+func (a *Accruer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	if err := a.RunOnce(ctx); err != nil {
+		log.Println(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.RunOnce(ctx); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrue(t *testing.T) {
+	day := date(2023, 3, 14)
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListInterestBearingBalances", mock.Anything, db.ListInterestBearingBalancesParams{
+			AccrualDate: day,
+			EndTime:     date(2023, 3, 15),
+		}).
+		Return([]db.ListInterestBearingBalancesRow{
+			{AccountID: 1, Currency: "USD", Balance: 3650000, InterestRate: "0.02", DayCount: Actual365},
+			{AccountID: 2, Currency: "USD", Balance: 0, InterestRate: "0.02", DayCount: Actual365},
+			{AccountID: 3, Currency: "USD", Balance: -500, InterestRate: "0.02", DayCount: Actual365},
+			{AccountID: 4, Currency: "EUR", Balance: 100, InterestRate: "0.03", DayCount: Actual360},
+			{AccountID: 5, Currency: "EUR", Balance: 100, InterestRate: "0.03", DayCount: Actual360},
+		}, nil)
+	storeMock.
+		On("CreateInterestAccrual", mock.Anything, db.CreateInterestAccrualParams{
+			AccountID:    1,
+			AccrualDate:  day,
+			Balance:      3650000,
+			InterestRate: "0.02",
+			DayCount:     Actual365,
+			Amount:       "200.0000000000",
+		}).
+		Return(db.InterestAccrual{}, nil)
+	storeMock.
+		On("CreateInterestAccrual", mock.Anything, db.CreateInterestAccrualParams{
+			AccountID:    4,
+			AccrualDate:  day,
+			Balance:      100,
+			InterestRate: "0.03",
+			DayCount:     Actual360,
+			Amount:       "0.0083333333",
+		}).
+		Return(db.InterestAccrual{}, nil)
+	storeMock.
+		On("CreateInterestAccrual", mock.Anything, mock.MatchedBy(func(arg db.CreateInterestAccrualParams) bool {
+			return arg.AccountID == 5
+		})).
+		Return(db.InterestAccrual{}, sql.ErrNoRows)
+
+	n, err := NewAccruer(storeMock, 1).Accrue(context.Background(), day.Add(13*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestAccrueUnknownDayCount(t *testing.T) {
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListInterestBearingBalances", mock.Anything, mock.Anything).
+		Return([]db.ListInterestBearingBalancesRow{
+			{AccountID: 1, Currency: "USD", Balance: 100, InterestRate: "0.02", DayCount: "ACT/364"},
+		}, nil)
+
+	_, err := NewAccruer(storeMock, 1).Accrue(context.Background(), date(2023, 3, 14))
+	require.ErrorIs(t, err, ErrUnknownDayCount)
+}
+
+func TestPost(t *testing.T) {
+	before := date(2023, 4, 1)
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ListUnpostedInterestAccounts", mock.Anything, before).
+		Return([]int64{1, 2}, nil)
+	storeMock.
+		On("PostInterestTx", mock.Anything, db.PostInterestTxParams{AccountID: 1, Before: before}).
+		Return(db.TransferTxResult{Transfer: db.Transfer{ID: 10, Amount: 6200}}, nil)
+	// less than one minor unit accrued, carried over to the next month
+	storeMock.
+		On("PostInterestTx", mock.Anything, db.PostInterestTxParams{AccountID: 2, Before: before}).
+		Return(db.TransferTxResult{}, nil)
+
+	n, err := NewAccruer(storeMock, 1).Post(context.Background(), before.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestRunOnce(t *testing.T) {
+	storeMock := mocks.NewStore(t)
+	for _, day := range []time.Time{date(2023, 3, 30), date(2023, 3, 31)} {
+		storeMock.
+			On("ListInterestBearingBalances", mock.Anything, db.ListInterestBearingBalancesParams{
+				AccrualDate: day,
+				EndTime:     day.AddDate(0, 0, 1),
+			}).
+			Once().
+			Return([]db.ListInterestBearingBalancesRow{}, nil)
+	}
+	storeMock.
+		On("ListUnpostedInterestAccounts", mock.Anything, date(2023, 4, 1)).
+		Once().
+		Return([]int64{}, nil)
+
+	accruer := NewAccruer(storeMock, 2)
+	accruer.now = func() time.Time { return time.Date(2023, 4, 1, 0, 30, 0, 0, time.UTC) }
+
+	require.NoError(t, accruer.RunOnce(context.Background()))
+}
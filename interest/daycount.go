@@ -0,0 +1,76 @@
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Day-count conventions decide which fraction of a year a period is worth.
+const (
+	Actual365 = "ACT/365"
+	Actual360 = "ACT/360"
+	// ActualActual is the ISDA variant: days in leap years count 1/366.
+	ActualActual = "ACT/ACT"
+	// Thirty360 is the bond basis, where every month has 30 days.
+	Thirty360 = "30/360"
+)
+
+var ErrUnknownDayCount = errors.New("unknown day-count convention")
+
+// YearFraction returns the part of a year between the dates of start and end
+// under convention. Times of day are ignored.
+func YearFraction(convention string, start time.Time, end time.Time) (*big.Rat, error) {
+	start, end = Date(start), Date(end)
+
+	switch convention {
+	case Actual365:
+		return big.NewRat(days(start, end), 365), nil
+	case Actual360:
+		return big.NewRat(days(start, end), 360), nil
+	case ActualActual:
+		fraction := new(big.Rat)
+		for from := start; from.Before(end); {
+			to := time.Date(from.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			if to.After(end) {
+				to = end
+			}
+			fraction.Add(fraction, big.NewRat(days(from, to), daysInYear(from.Year())))
+			from = to
+		}
+		return fraction, nil
+	case Thirty360:
+		return big.NewRat(days360(start, end), 360), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownDayCount, convention)
+}
+
+// Date truncates t to midnight UTC of its calendar day.
+func Date(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func days(start time.Time, end time.Time) int64 {
+	return int64(end.Sub(start).Hours() / 24)
+}
+
+func daysInYear(year int) int64 {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
+
+func days360(start time.Time, end time.Time) int64 {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1))
+}
@@ -0,0 +1,58 @@
+package interest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestYearFraction(t *testing.T) {
+	testCases := []struct {
+		convention string
+		start      time.Time
+		end        time.Time
+		fraction   *big.Rat
+	}{
+		{Actual365, date(2023, 1, 1), date(2023, 1, 2), big.NewRat(1, 365)},
+		{Actual365, date(2024, 2, 1), date(2024, 3, 1), big.NewRat(29, 365)},
+		{Actual360, date(2023, 1, 1), date(2023, 1, 2), big.NewRat(1, 360)},
+		{Actual360, date(2023, 1, 1), date(2023, 2, 1), big.NewRat(31, 360)},
+		{ActualActual, date(2023, 6, 1), date(2023, 6, 2), big.NewRat(1, 365)},
+		{ActualActual, date(2024, 6, 1), date(2024, 6, 2), big.NewRat(1, 366)},
+		{ActualActual, date(2023, 12, 31), date(2024, 1, 2), new(big.Rat).Add(big.NewRat(1, 365), big.NewRat(1, 366))},
+		{Thirty360, date(2023, 1, 15), date(2023, 1, 16), big.NewRat(1, 360)},
+		{Thirty360, date(2023, 1, 30), date(2023, 1, 31), big.NewRat(0, 360)},
+		{Thirty360, date(2023, 1, 31), date(2023, 2, 1), big.NewRat(1, 360)},
+		{Thirty360, date(2023, 2, 28), date(2023, 3, 1), big.NewRat(3, 360)},
+		{Thirty360, date(2023, 1, 1), date(2023, 2, 1), big.NewRat(30, 360)},
+	}
+
+	for _, tc := range testCases {
+		name := tc.convention + " " + tc.start.Format("2006-01-02") + " " + tc.end.Format("2006-01-02")
+		t.Run(name, func(t *testing.T) {
+			fraction, err := YearFraction(tc.convention, tc.start, tc.end)
+			require.NoError(t, err)
+			require.Zero(t, tc.fraction.Cmp(fraction), "got %s", fraction)
+		})
+	}
+}
+
+func TestYearFractionIgnoresTimeOfDay(t *testing.T) {
+	start := time.Date(2023, 1, 1, 23, 59, 0, 0, time.UTC)
+	end := time.Date(2023, 1, 2, 0, 1, 0, 0, time.UTC)
+
+	fraction, err := YearFraction(Actual365, start, end)
+	require.NoError(t, err)
+	require.Zero(t, big.NewRat(1, 365).Cmp(fraction))
+}
+
+func TestYearFractionUnknownConvention(t *testing.T) {
+	_, err := YearFraction("ACT/364", date(2023, 1, 1), date(2023, 1, 2))
+	require.ErrorIs(t, err, ErrUnknownDayCount)
+}
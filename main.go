@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"simple_bank/api"
+	"simple_bank/approval"
 	db "simple_bank/db/models"
+	"simple_bank/fx"
+	"simple_bank/interest"
+	"simple_bank/risk"
+	"simple_bank/scheduler"
 	"simple_bank/util"
 
 	_ "github.com/lib/pq"
@@ -22,7 +28,39 @@ func main() {
 	}
 
 	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+
+	err = loadCurrencies(context.Background(), store, config.EnabledCurrencies)
+	if err != nil {
+		log.Fatal("cannot load currencies:", err)
+	}
+
+	if config.FXRatesFile != "" {
+		n, err := fx.Import(context.Background(), store, config.FXRatesFile)
+		if err != nil {
+			log.Fatal("cannot import exchange rates:", err)
+		}
+		log.Printf("imported %d exchange rates from %s", n, config.FXRatesFile)
+	}
+
+	accruer := interest.NewAccruer(store, config.InterestLookbackDays)
+	go accruer.Run(context.Background(), config.InterestAccrualInterval)
+
+	riskEngine, err := risk.Load(store, config.RiskRulesFile)
+	if err != nil {
+		log.Fatal("cannot load risk rules:", err)
+	}
+
+	transferScheduler := scheduler.New(store, scheduler.Config{
+		MaxAttempts: config.SchedulerMaxAttempts,
+		RetryDelay:  config.SchedulerRetryDelay,
+		Risk:        riskEngine,
+	})
+	go transferScheduler.Run(context.Background(), config.SchedulerPollInterval)
+
+	expirer := approval.NewExpirer(store)
+	go expirer.Run(context.Background(), config.ApprovalExpiryInterval)
+
+	server, err := api.NewServer(config, store, riskEngine)
 	if err != nil {
 		log.Fatal("cannot create server:", err)
 	}
@@ -32,3 +70,24 @@ func main() {
 		log.Fatal("cannot start server:", err)
 	}
 }
+
+// loadCurrencies replaces the built-in currency registry with the currencies
+// table. enabled, when set, overrides which of them accounts can be opened in.
+func loadCurrencies(ctx context.Context, store db.Store, enabled []string) error {
+	rows, err := store.ListCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	currencies := make([]util.Currency, len(rows))
+	for i, row := range rows {
+		currencies[i] = util.Currency{
+			Code:        row.Code,
+			NumericCode: row.NumericCode,
+			MinorUnits:  int(row.MinorUnits),
+			Symbol:      row.Symbol,
+			Enabled:     row.Enabled,
+		}
+	}
+	return util.SetCurrencies(currencies, enabled)
+}
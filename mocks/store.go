@@ -5,7 +5,9 @@ package mocks
 import (
 	context "context"
 	db "simple_bank/db/models"
+	time "time"
 
+	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -14,6 +16,27 @@ type Store struct {
 	mock.Mock
 }
 
+// AcceptPaymentRequestTx provides a mock function with given fields: ctx, arg
+func (_m *Store) AcceptPaymentRequestTx(ctx context.Context, arg db.AcceptPaymentRequestTxParams) (db.AcceptPaymentRequestTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.AcceptPaymentRequestTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.AcceptPaymentRequestTxParams) db.AcceptPaymentRequestTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.AcceptPaymentRequestTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.AcceptPaymentRequestTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddAccountBalance provides a mock function with given fields: ctx, arg
 func (_m *Store) AddAccountBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
 	ret := _m.Called(ctx, arg)
@@ -35,19 +58,19 @@ func (_m *Store) AddAccountBalance(ctx context.Context, arg db.AddAccountBalance
 	return r0, r1
 }
 
-// CreateAccount provides a mock function with given fields: ctx, arg
-func (_m *Store) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+// ApproveApprovalPolicyChangeTx provides a mock function with given fields: ctx, arg
+func (_m *Store) ApproveApprovalPolicyChangeTx(ctx context.Context, arg db.ApproveApprovalPolicyChangeTxParams) (db.SetApprovalPolicyTxResult, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 db.Account
-	if rf, ok := ret.Get(0).(func(context.Context, db.CreateAccountParams) db.Account); ok {
+	var r0 db.SetApprovalPolicyTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.ApproveApprovalPolicyChangeTxParams) db.SetApprovalPolicyTxResult); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.Account)
+		r0 = ret.Get(0).(db.SetApprovalPolicyTxResult)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.CreateAccountParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.ApproveApprovalPolicyChangeTxParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -56,19 +79,19 @@ func (_m *Store) CreateAccount(ctx context.Context, arg db.CreateAccountParams)
 	return r0, r1
 }
 
-// CreateEntry provides a mock function with given fields: ctx, arg
-func (_m *Store) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+// ApprovePendingTransferTx provides a mock function with given fields: ctx, arg
+func (_m *Store) ApprovePendingTransferTx(ctx context.Context, arg db.ApprovePendingTransferTxParams) (db.ApprovePendingTransferTxResult, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 db.Entry
-	if rf, ok := ret.Get(0).(func(context.Context, db.CreateEntryParams) db.Entry); ok {
+	var r0 db.ApprovePendingTransferTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.ApprovePendingTransferTxParams) db.ApprovePendingTransferTxResult); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.Entry)
+		r0 = ret.Get(0).(db.ApprovePendingTransferTxResult)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.CreateEntryParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.ApprovePendingTransferTxParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -77,19 +100,19 @@ func (_m *Store) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.
 	return r0, r1
 }
 
-// CreateTransfer provides a mock function with given fields: ctx, arg
-func (_m *Store) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+// BatchTransferTx provides a mock function with given fields: ctx, arg
+func (_m *Store) BatchTransferTx(ctx context.Context, arg db.BatchTransferTxParams) (db.BatchTransferTxResult, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 db.Transfer
-	if rf, ok := ret.Get(0).(func(context.Context, db.CreateTransferParams) db.Transfer); ok {
+	var r0 db.BatchTransferTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.BatchTransferTxParams) db.BatchTransferTxResult); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.Transfer)
+		r0 = ret.Get(0).(db.BatchTransferTxResult)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.CreateTransferParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.BatchTransferTxParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -98,19 +121,21 @@ func (_m *Store) CreateTransfer(ctx context.Context, arg db.CreateTransferParams
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: ctx, arg
-func (_m *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+// ClaimDueScheduledTransfers provides a mock function with given fields: ctx, arg
+func (_m *Store) ClaimDueScheduledTransfers(ctx context.Context, arg db.ClaimDueScheduledTransfersParams) ([]db.ScheduledTransfer, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 db.User
-	if rf, ok := ret.Get(0).(func(context.Context, db.CreateUserParams) db.User); ok {
+	var r0 []db.ScheduledTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.ClaimDueScheduledTransfersParams) []db.ScheduledTransfer); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.User)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ScheduledTransfer)
+		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.CreateUserParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.ClaimDueScheduledTransfersParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -119,34 +144,41 @@ func (_m *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.Us
 	return r0, r1
 }
 
-// DeleteAccount provides a mock function with given fields: ctx, id
-func (_m *Store) DeleteAccount(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
+// ClosePaymentRequest provides a mock function with given fields: ctx, arg
+func (_m *Store) ClosePaymentRequest(ctx context.Context, arg db.ClosePaymentRequestParams) (db.PaymentRequest, error) {
+	ret := _m.Called(ctx, arg)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(ctx, id)
+	var r0 db.PaymentRequest
+	if rf, ok := ret.Get(0).(func(context.Context, db.ClosePaymentRequestParams) db.PaymentRequest); ok {
+		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(db.PaymentRequest)
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ClosePaymentRequestParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetAccount provides a mock function with given fields: ctx, id
-func (_m *Store) GetAccount(ctx context.Context, id int64) (db.Account, error) {
-	ret := _m.Called(ctx, id)
+// CountOwnerTransfersSince provides a mock function with given fields: ctx, arg
+func (_m *Store) CountOwnerTransfersSince(ctx context.Context, arg db.CountOwnerTransfersSinceParams) (int64, error) {
+	ret := _m.Called(ctx, arg)
 
-	var r0 db.Account
-	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Account); ok {
-		r0 = rf(ctx, id)
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, db.CountOwnerTransfersSinceParams) int64); ok {
+		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.Account)
+		r0 = ret.Get(0).(int64)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, db.CountOwnerTransfersSinceParams) error); ok {
+		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -154,20 +186,41 @@ func (_m *Store) GetAccount(ctx context.Context, id int64) (db.Account, error) {
 	return r0, r1
 }
 
-// GetAccountForUpdate provides a mock function with given fields: ctx, id
-func (_m *Store) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
-	ret := _m.Called(ctx, id)
+// CountOwnerTransfersTo provides a mock function with given fields: ctx, arg
+func (_m *Store) CountOwnerTransfersTo(ctx context.Context, arg db.CountOwnerTransfersToParams) (int64, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, db.CountOwnerTransfersToParams) int64); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CountOwnerTransfersToParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAccount provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	ret := _m.Called(ctx, arg)
 
 	var r0 db.Account
-	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Account); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateAccountParams) db.Account); ok {
+		r0 = rf(ctx, arg)
 	} else {
 		r0 = ret.Get(0).(db.Account)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateAccountParams) error); ok {
+		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -175,20 +228,62 @@ func (_m *Store) GetAccountForUpdate(ctx context.Context, id int64) (db.Account,
 	return r0, r1
 }
 
-// GetEntry provides a mock function with given fields: ctx, id
-func (_m *Store) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
-	ret := _m.Called(ctx, id)
+// CreateAccountApprover provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateAccountApprover(ctx context.Context, arg db.CreateAccountApproverParams) (db.AccountApprover, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.AccountApprover
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateAccountApproverParams) db.AccountApprover); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.AccountApprover)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateAccountApproverParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateApprovalPolicyChange provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateApprovalPolicyChange(ctx context.Context, arg db.CreateApprovalPolicyChangeParams) (db.ApprovalPolicyChange, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ApprovalPolicyChange
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateApprovalPolicyChangeParams) db.ApprovalPolicyChange); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ApprovalPolicyChange)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateApprovalPolicyChangeParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateEntry provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+	ret := _m.Called(ctx, arg)
 
 	var r0 db.Entry
-	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Entry); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateEntryParams) db.Entry); ok {
+		r0 = rf(ctx, arg)
 	} else {
 		r0 = ret.Get(0).(db.Entry)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateEntryParams) error); ok {
+		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -196,20 +291,20 @@ func (_m *Store) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
 	return r0, r1
 }
 
-// GetTransfer provides a mock function with given fields: ctx, id
-func (_m *Store) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
-	ret := _m.Called(ctx, id)
+// CreateExchangeQuote provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateExchangeQuote(ctx context.Context, arg db.CreateExchangeQuoteParams) (db.ExchangeQuote, error) {
+	ret := _m.Called(ctx, arg)
 
-	var r0 db.Transfer
-	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Transfer); ok {
-		r0 = rf(ctx, id)
+	var r0 db.ExchangeQuote
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateExchangeQuoteParams) db.ExchangeQuote); ok {
+		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.Transfer)
+		r0 = ret.Get(0).(db.ExchangeQuote)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateExchangeQuoteParams) error); ok {
+		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -217,20 +312,20 @@ func (_m *Store) GetTransfer(ctx context.Context, id int64) (db.Transfer, error)
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: ctx, username
-func (_m *Store) GetUser(ctx context.Context, username string) (db.User, error) {
-	ret := _m.Called(ctx, username)
+// CreateIdempotencyKey provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateIdempotencyKey(ctx context.Context, arg db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	ret := _m.Called(ctx, arg)
 
-	var r0 db.User
-	if rf, ok := ret.Get(0).(func(context.Context, string) db.User); ok {
-		r0 = rf(ctx, username)
+	var r0 db.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateIdempotencyKeyParams) db.IdempotencyKey); ok {
+		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.User)
+		r0 = ret.Get(0).(db.IdempotencyKey)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, username)
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateIdempotencyKeyParams) error); ok {
+		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -238,21 +333,19 @@ func (_m *Store) GetUser(ctx context.Context, username string) (db.User, error)
 	return r0, r1
 }
 
-// ListAccounts provides a mock function with given fields: ctx, arg
-func (_m *Store) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+// CreateInterestAccrual provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateInterestAccrual(ctx context.Context, arg db.CreateInterestAccrualParams) (db.InterestAccrual, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 []db.Account
-	if rf, ok := ret.Get(0).(func(context.Context, db.ListAccountsParams) []db.Account); ok {
+	var r0 db.InterestAccrual
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateInterestAccrualParams) db.InterestAccrual); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Account)
-		}
+		r0 = ret.Get(0).(db.InterestAccrual)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.ListAccountsParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateInterestAccrualParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -261,21 +354,33 @@ func (_m *Store) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([
 	return r0, r1
 }
 
-// ListEntries provides a mock function with given fields: ctx, arg
-func (_m *Store) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+// CreateInterestCarryOver provides a mock function with given fields: ctx, transferID
+func (_m *Store) CreateInterestCarryOver(ctx context.Context, transferID int64) error {
+	ret := _m.Called(ctx, transferID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, transferID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePaymentRequest provides a mock function with given fields: ctx, arg
+func (_m *Store) CreatePaymentRequest(ctx context.Context, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 []db.Entry
-	if rf, ok := ret.Get(0).(func(context.Context, db.ListEntriesParams) []db.Entry); ok {
+	var r0 db.PaymentRequest
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreatePaymentRequestParams) db.PaymentRequest); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Entry)
-		}
+		r0 = ret.Get(0).(db.PaymentRequest)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.ListEntriesParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreatePaymentRequestParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -284,21 +389,19 @@ func (_m *Store) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]d
 	return r0, r1
 }
 
-// ListTransfers provides a mock function with given fields: ctx, arg
-func (_m *Store) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+// CreatePendingTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) CreatePendingTransfer(ctx context.Context, arg db.CreatePendingTransferParams) (db.PendingTransfer, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 []db.Transfer
-	if rf, ok := ret.Get(0).(func(context.Context, db.ListTransfersParams) []db.Transfer); ok {
+	var r0 db.PendingTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreatePendingTransferParams) db.PendingTransfer); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]db.Transfer)
-		}
+		r0 = ret.Get(0).(db.PendingTransfer)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.ListTransfersParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreatePendingTransferParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -307,19 +410,19 @@ func (_m *Store) ListTransfers(ctx context.Context, arg db.ListTransfersParams)
 	return r0, r1
 }
 
-// TransferTx provides a mock function with given fields: ctx, arg
-func (_m *Store) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+// CreatePendingTransferTx provides a mock function with given fields: ctx, arg
+func (_m *Store) CreatePendingTransferTx(ctx context.Context, arg db.CreatePendingTransferTxParams) (db.PendingTransfer, error) {
 	ret := _m.Called(ctx, arg)
 
-	var r0 db.TransferTxResult
-	if rf, ok := ret.Get(0).(func(context.Context, db.TransferTxParams) db.TransferTxResult); ok {
+	var r0 db.PendingTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreatePendingTransferTxParams) db.PendingTransfer); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.TransferTxResult)
+		r0 = ret.Get(0).(db.PendingTransfer)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.TransferTxParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreatePendingTransferTxParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -328,19 +431,1623 @@ func (_m *Store) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.Tr
 	return r0, r1
 }
 
-// UpdateAccount provides a mock function with given fields: ctx, arg
-func (_m *Store) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+// CreateRevokedToken provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateRevokedToken(ctx context.Context, arg db.CreateRevokedTokenParams) error {
 	ret := _m.Called(ctx, arg)
 
-	var r0 db.Account
-	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateAccountParams) db.Account); ok {
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateRevokedTokenParams) error); ok {
 		r0 = rf(ctx, arg)
 	} else {
-		r0 = ret.Get(0).(db.Account)
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRiskDecision provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateRiskDecision(ctx context.Context, arg db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.RiskDecision
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateRiskDecisionParams) db.RiskDecision); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.RiskDecision)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateAccountParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateRiskDecisionParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateScheduledTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateScheduledTransfer(ctx context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ScheduledTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateScheduledTransferParams) db.ScheduledTransfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ScheduledTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateScheduledTransferParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateScheduledTransferExecution provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateScheduledTransferExecution(ctx context.Context, arg db.CreateScheduledTransferExecutionParams) (db.ScheduledTransferExecution, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ScheduledTransferExecution
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateScheduledTransferExecutionParams) db.ScheduledTransferExecution); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ScheduledTransferExecution)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateScheduledTransferExecutionParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSession provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.Session
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateSessionParams) db.Session); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.Session)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateSessionParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSystemAccount provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateSystemAccount(ctx context.Context, arg db.CreateSystemAccountParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateSystemAccountParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.Transfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateTransferParams) db.Transfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.Transfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateTransferParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.User
+	if rf, ok := ret.Get(0).(func(context.Context, db.CreateUserParams) db.User); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CreateUserParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecideApprovalPolicyChange provides a mock function with given fields: ctx, arg
+func (_m *Store) DecideApprovalPolicyChange(ctx context.Context, arg db.DecideApprovalPolicyChangeParams) (db.ApprovalPolicyChange, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ApprovalPolicyChange
+	if rf, ok := ret.Get(0).(func(context.Context, db.DecideApprovalPolicyChangeParams) db.ApprovalPolicyChange); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ApprovalPolicyChange)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.DecideApprovalPolicyChangeParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecidePendingTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) DecidePendingTransfer(ctx context.Context, arg db.DecidePendingTransferParams) (db.PendingTransfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.PendingTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.DecidePendingTransferParams) db.PendingTransfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.PendingTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.DecidePendingTransferParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAccount provides a mock function with given fields: ctx, id
+func (_m *Store) DeleteAccount(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAccountApprovers provides a mock function with given fields: ctx, accountID
+func (_m *Store) DeleteAccountApprovers(ctx context.Context, accountID int64) error {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpiredRevokedTokens provides a mock function with given fields: ctx
+func (_m *Store) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTransferFee provides a mock function with given fields: ctx, arg
+func (_m *Store) DeleteTransferFee(ctx context.Context, arg db.DeleteTransferFeeParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, db.DeleteTransferFeeParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DepositTx provides a mock function with given fields: ctx, arg
+func (_m *Store) DepositTx(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.CashTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.CashTxParams) db.CashTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.CashTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CashTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExchangeTransferTx provides a mock function with given fields: ctx, arg
+func (_m *Store) ExchangeTransferTx(ctx context.Context, arg db.ExchangeTransferTxParams) (db.TransferTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.TransferTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.ExchangeTransferTxParams) db.TransferTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.TransferTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ExchangeTransferTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpirePendingTransfers provides a mock function with given fields: ctx, now
+func (_m *Store) ExpirePendingTransfers(ctx context.Context, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccount provides a mock function with given fields: ctx, id
+func (_m *Store) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Account); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.Account)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccountApprover provides a mock function with given fields: ctx, arg
+func (_m *Store) GetAccountApprover(ctx context.Context, arg db.GetAccountApproverParams) (db.AccountApprover, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.AccountApprover
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetAccountApproverParams) db.AccountApprover); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.AccountApprover)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetAccountApproverParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccountByOwnerAndCurrency provides a mock function with given fields: ctx, arg
+func (_m *Store) GetAccountByOwnerAndCurrency(ctx context.Context, arg db.GetAccountByOwnerAndCurrencyParams) (db.Account, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetAccountByOwnerAndCurrencyParams) db.Account); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.Account)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetAccountByOwnerAndCurrencyParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAccountForUpdate provides a mock function with given fields: ctx, id
+func (_m *Store) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Account); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.Account)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetApprovalPolicyChange provides a mock function with given fields: ctx, id
+func (_m *Store) GetApprovalPolicyChange(ctx context.Context, id int64) (db.ApprovalPolicyChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.ApprovalPolicyChange
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.ApprovalPolicyChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.ApprovalPolicyChange)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetApprovalPolicyChangeForUpdate provides a mock function with given fields: ctx, id
+func (_m *Store) GetApprovalPolicyChangeForUpdate(ctx context.Context, id int64) (db.ApprovalPolicyChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.ApprovalPolicyChange
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.ApprovalPolicyChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.ApprovalPolicyChange)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEntry provides a mock function with given fields: ctx, id
+func (_m *Store) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.Entry
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Entry); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.Entry)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExchangeQuote provides a mock function with given fields: ctx, id
+func (_m *Store) GetExchangeQuote(ctx context.Context, id uuid.UUID) (db.ExchangeQuote, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.ExchangeQuote
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) db.ExchangeQuote); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.ExchangeQuote)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExchangeRate provides a mock function with given fields: ctx, arg
+func (_m *Store) GetExchangeRate(ctx context.Context, arg db.GetExchangeRateParams) (db.ExchangeRate, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ExchangeRate
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetExchangeRateParams) db.ExchangeRate); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ExchangeRate)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetExchangeRateParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIdempotencyKey provides a mock function with given fields: ctx, arg
+func (_m *Store) GetIdempotencyKey(ctx context.Context, arg db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetIdempotencyKeyParams) db.IdempotencyKey); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.IdempotencyKey)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetIdempotencyKeyParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInterestAccrual provides a mock function with given fields: ctx, arg
+func (_m *Store) GetInterestAccrual(ctx context.Context, arg db.GetInterestAccrualParams) (db.InterestAccrual, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.InterestAccrual
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetInterestAccrualParams) db.InterestAccrual); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.InterestAccrual)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetInterestAccrualParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentRequest provides a mock function with given fields: ctx, id
+func (_m *Store) GetPaymentRequest(ctx context.Context, id int64) (db.PaymentRequest, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.PaymentRequest
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.PaymentRequest); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.PaymentRequest)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentRequestForUpdate provides a mock function with given fields: ctx, id
+func (_m *Store) GetPaymentRequestForUpdate(ctx context.Context, id int64) (db.PaymentRequest, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.PaymentRequest
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.PaymentRequest); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.PaymentRequest)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingTransfer provides a mock function with given fields: ctx, id
+func (_m *Store) GetPendingTransfer(ctx context.Context, id int64) (db.PendingTransfer, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.PendingTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.PendingTransfer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.PendingTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingTransferForUpdate provides a mock function with given fields: ctx, id
+func (_m *Store) GetPendingTransferForUpdate(ctx context.Context, id int64) (db.PendingTransfer, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.PendingTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.PendingTransfer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.PendingTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReversedAmount provides a mock function with given fields: ctx, transferID
+func (_m *Store) GetReversedAmount(ctx context.Context, transferID int64) (int64, error) {
+	ret := _m.Called(ctx, transferID)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+		r0 = rf(ctx, transferID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, transferID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRiskDecision provides a mock function with given fields: ctx, id
+func (_m *Store) GetRiskDecision(ctx context.Context, id int64) (db.RiskDecision, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.RiskDecision
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.RiskDecision); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.RiskDecision)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetScheduledTransfer provides a mock function with given fields: ctx, id
+func (_m *Store) GetScheduledTransfer(ctx context.Context, id int64) (db.ScheduledTransfer, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.ScheduledTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.ScheduledTransfer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.ScheduledTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSession provides a mock function with given fields: ctx, id
+func (_m *Store) GetSession(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.Session
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) db.Session); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.Session)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStatementBalances provides a mock function with given fields: ctx, arg
+func (_m *Store) GetStatementBalances(ctx context.Context, arg db.GetStatementBalancesParams) (db.GetStatementBalancesRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.GetStatementBalancesRow
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetStatementBalancesParams) db.GetStatementBalancesRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.GetStatementBalancesRow)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetStatementBalancesParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransfer provides a mock function with given fields: ctx, id
+func (_m *Store) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.Transfer
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Transfer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.Transfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransferFee provides a mock function with given fields: ctx, arg
+func (_m *Store) GetTransferFee(ctx context.Context, arg db.GetTransferFeeParams) (db.TransferFee, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.TransferFee
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetTransferFeeParams) db.TransferFee); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.TransferFee)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetTransferFeeParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransferForUpdate provides a mock function with given fields: ctx, id
+func (_m *Store) GetTransferForUpdate(ctx context.Context, id int64) (db.Transfer, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 db.Transfer
+	if rf, ok := ret.Get(0).(func(context.Context, int64) db.Transfer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(db.Transfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUnpostedInterest provides a mock function with given fields: ctx, arg
+func (_m *Store) GetUnpostedInterest(ctx context.Context, arg db.GetUnpostedInterestParams) (int64, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, db.GetUnpostedInterestParams) int64); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.GetUnpostedInterestParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, username
+func (_m *Store) GetUser(ctx context.Context, username string) (db.User, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 db.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) db.User); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(db.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *Store) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	ret := _m.Called(ctx, email)
+
+	var r0 db.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) db.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(db.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAccountApprovers provides a mock function with given fields: ctx, accountID
+func (_m *Store) ListAccountApprovers(ctx context.Context, accountID int64) ([]db.AccountApprover, error) {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 []db.AccountApprover
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []db.AccountApprover); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.AccountApprover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAccounts provides a mock function with given fields: ctx, arg
+func (_m *Store) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListAccountsParams) []db.Account); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListAccountsParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAccountsAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListAccountsAfter(ctx context.Context, arg db.ListAccountsAfterParams) ([]db.Account, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListAccountsAfterParams) []db.Account); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListAccountsAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListActiveRevokedTokens provides a mock function with given fields: ctx
+func (_m *Store) ListActiveRevokedTokens(ctx context.Context) ([]db.RevokedToken, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []db.RevokedToken
+	if rf, ok := ret.Get(0).(func(context.Context) []db.RevokedToken); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.RevokedToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListApprovalPolicyChangesAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListApprovalPolicyChangesAfter(ctx context.Context, arg db.ListApprovalPolicyChangesAfterParams) ([]db.ApprovalPolicyChange, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ApprovalPolicyChange
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListApprovalPolicyChangesAfterParams) []db.ApprovalPolicyChange); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ApprovalPolicyChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListApprovalPolicyChangesAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCurrencies provides a mock function with given fields: ctx
+func (_m *Store) ListCurrencies(ctx context.Context) ([]db.Currency, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []db.Currency
+	if rf, ok := ret.Get(0).(func(context.Context) []db.Currency); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Currency)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEntries provides a mock function with given fields: ctx, arg
+func (_m *Store) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.Entry
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListEntriesParams) []db.Entry); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListEntriesParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListInterestBearingBalances provides a mock function with given fields: ctx, arg
+func (_m *Store) ListInterestBearingBalances(ctx context.Context, arg db.ListInterestBearingBalancesParams) ([]db.ListInterestBearingBalancesRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ListInterestBearingBalancesRow
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListInterestBearingBalancesParams) []db.ListInterestBearingBalancesRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ListInterestBearingBalancesRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListInterestBearingBalancesParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOwnerTransfers provides a mock function with given fields: ctx, arg
+func (_m *Store) ListOwnerTransfers(ctx context.Context, arg db.ListOwnerTransfersParams) ([]db.ListOwnerTransfersRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ListOwnerTransfersRow
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListOwnerTransfersParams) []db.ListOwnerTransfersRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ListOwnerTransfersRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListOwnerTransfersParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPaymentRequestsAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListPaymentRequestsAfter(ctx context.Context, arg db.ListPaymentRequestsAfterParams) ([]db.PaymentRequest, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.PaymentRequest
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListPaymentRequestsAfterParams) []db.PaymentRequest); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.PaymentRequest)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListPaymentRequestsAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPendingTransfersAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListPendingTransfersAfter(ctx context.Context, arg db.ListPendingTransfersAfterParams) ([]db.PendingTransfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.PendingTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListPendingTransfersAfterParams) []db.PendingTransfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.PendingTransfer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListPendingTransfersAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListScheduledTransferExecutionsAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListScheduledTransferExecutionsAfter(ctx context.Context, arg db.ListScheduledTransferExecutionsAfterParams) ([]db.ScheduledTransferExecution, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ScheduledTransferExecution
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListScheduledTransferExecutionsAfterParams) []db.ScheduledTransferExecution); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ScheduledTransferExecution)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListScheduledTransferExecutionsAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListScheduledTransfersAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListScheduledTransfersAfter(ctx context.Context, arg db.ListScheduledTransfersAfterParams) ([]db.ScheduledTransfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ScheduledTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListScheduledTransfersAfterParams) []db.ScheduledTransfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ScheduledTransfer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListScheduledTransfersAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListStatementEntries provides a mock function with given fields: ctx, arg
+func (_m *Store) ListStatementEntries(ctx context.Context, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ListStatementEntriesRow
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListStatementEntriesParams) []db.ListStatementEntriesRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ListStatementEntriesRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListStatementEntriesParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListStatementEntriesAfter provides a mock function with given fields: ctx, arg
+func (_m *Store) ListStatementEntriesAfter(ctx context.Context, arg db.ListStatementEntriesAfterParams) ([]db.ListStatementEntriesRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.ListStatementEntriesRow
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListStatementEntriesAfterParams) []db.ListStatementEntriesRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.ListStatementEntriesRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListStatementEntriesAfterParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTransferFees provides a mock function with given fields: ctx
+func (_m *Store) ListTransferFees(ctx context.Context) ([]db.TransferFee, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []db.TransferFee
+	if rf, ok := ret.Get(0).(func(context.Context) []db.TransferFee); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.TransferFee)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTransfers provides a mock function with given fields: ctx, arg
+func (_m *Store) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []db.Transfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.ListTransfersParams) []db.Transfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]db.Transfer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ListTransfersParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUnpostedInterestAccounts provides a mock function with given fields: ctx, before
+func (_m *Store) ListUnpostedInterestAccounts(ctx context.Context, before time.Time) ([]int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkInterestPosted provides a mock function with given fields: ctx, arg
+func (_m *Store) MarkInterestPosted(ctx context.Context, arg db.MarkInterestPostedParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, db.MarkInterestPostedParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PostInterestTx provides a mock function with given fields: ctx, arg
+func (_m *Store) PostInterestTx(ctx context.Context, arg db.PostInterestTxParams) (db.TransferTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.TransferTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.PostInterestTxParams) db.TransferTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.TransferTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.PostInterestTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordScheduledRunTx provides a mock function with given fields: ctx, arg
+func (_m *Store) RecordScheduledRunTx(ctx context.Context, arg db.RecordScheduledRunTxParams) (db.ScheduledTransferExecution, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ScheduledTransferExecution
+	if rf, ok := ret.Get(0).(func(context.Context, db.RecordScheduledRunTxParams) db.ScheduledTransferExecution); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ScheduledTransferExecution)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.RecordScheduledRunTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReverseTransferTx provides a mock function with given fields: ctx, arg
+func (_m *Store) ReverseTransferTx(ctx context.Context, arg db.ReverseTransferTxParams) (db.TransferTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.TransferTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.ReverseTransferTxParams) db.TransferTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.TransferTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.ReverseTransferTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetApprovalPolicyTx provides a mock function with given fields: ctx, arg
+func (_m *Store) SetApprovalPolicyTx(ctx context.Context, arg db.SetApprovalPolicyTxParams) (db.SetApprovalPolicyTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.SetApprovalPolicyTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.SetApprovalPolicyTxParams) db.SetApprovalPolicyTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.SetApprovalPolicyTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.SetApprovalPolicyTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRiskDecisionPendingTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) SetRiskDecisionPendingTransfer(ctx context.Context, arg db.SetRiskDecisionPendingTransferParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, db.SetRiskDecisionPendingTransferParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRiskDecisionTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) SetRiskDecisionTransfer(ctx context.Context, arg db.SetRiskDecisionTransferParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, db.SetRiskDecisionTransferParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TransferTx provides a mock function with given fields: ctx, arg
+func (_m *Store) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.TransferTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.TransferTxParams) db.TransferTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.TransferTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.TransferTxParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAccount provides a mock function with given fields: ctx, arg
+func (_m *Store) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateAccountParams) db.Account); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.Account)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateAccountParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAccountApprovalThreshold provides a mock function with given fields: ctx, arg
+func (_m *Store) UpdateAccountApprovalThreshold(ctx context.Context, arg db.UpdateAccountApprovalThresholdParams) (db.Account, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateAccountApprovalThresholdParams) db.Account); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.Account)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateAccountApprovalThresholdParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAccountOverdraftLimit provides a mock function with given fields: ctx, arg
+func (_m *Store) UpdateAccountOverdraftLimit(ctx context.Context, arg db.UpdateAccountOverdraftLimitParams) (db.Account, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.Account
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateAccountOverdraftLimitParams) db.Account); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.Account)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateAccountOverdraftLimitParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateIdempotencyKeyResponse provides a mock function with given fields: ctx, arg
+func (_m *Store) UpdateIdempotencyKeyResponse(ctx context.Context, arg db.UpdateIdempotencyKeyResponseParams) (db.IdempotencyKey, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateIdempotencyKeyResponseParams) db.IdempotencyKey); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.IdempotencyKey)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateIdempotencyKeyResponseParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateScheduledTransfer provides a mock function with given fields: ctx, arg
+func (_m *Store) UpdateScheduledTransfer(ctx context.Context, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ScheduledTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateScheduledTransferParams) db.ScheduledTransfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ScheduledTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateScheduledTransferParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateScheduledTransferRun provides a mock function with given fields: ctx, arg
+func (_m *Store) UpdateScheduledTransferRun(ctx context.Context, arg db.UpdateScheduledTransferRunParams) (db.ScheduledTransfer, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ScheduledTransfer
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpdateScheduledTransferRunParams) db.ScheduledTransfer); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ScheduledTransfer)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpdateScheduledTransferRunParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpsertExchangeRate provides a mock function with given fields: ctx, arg
+func (_m *Store) UpsertExchangeRate(ctx context.Context, arg db.UpsertExchangeRateParams) (db.ExchangeRate, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.ExchangeRate
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpsertExchangeRateParams) db.ExchangeRate); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.ExchangeRate)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpsertExchangeRateParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpsertExchangeRatesTx provides a mock function with given fields: ctx, arg
+func (_m *Store) UpsertExchangeRatesTx(ctx context.Context, arg []db.UpsertExchangeRateParams) error {
+	ret := _m.Called(ctx, arg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []db.UpsertExchangeRateParams) error); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertTransferFee provides a mock function with given fields: ctx, arg
+func (_m *Store) UpsertTransferFee(ctx context.Context, arg db.UpsertTransferFeeParams) (db.TransferFee, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.TransferFee
+	if rf, ok := ret.Get(0).(func(context.Context, db.UpsertTransferFeeParams) db.TransferFee); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.TransferFee)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.UpsertTransferFeeParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WithdrawTx provides a mock function with given fields: ctx, arg
+func (_m *Store) WithdrawTx(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 db.CashTxResult
+	if rf, ok := ret.Get(0).(func(context.Context, db.CashTxParams) db.CashTxResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(db.CashTxResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, db.CashTxParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -0,0 +1,148 @@
+package risk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	db "simple_bank/db/models"
+	"simple_bank/util"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config lists the built-in rules to run, as read from a rules file. Amounts
+// are decimal strings in the major unit of their currency, such as "1000.00".
+type Config struct {
+	Velocity         []VelocityConfig        `json:"velocity" yaml:"velocity"`
+	AmountThresholds []AmountThresholdConfig `json:"amount_thresholds" yaml:"amount_thresholds"`
+	NewRecipient     *NewRecipientConfig     `json:"new_recipient" yaml:"new_recipient"`
+	Blocklist        BlocklistConfig         `json:"blocklist" yaml:"blocklist"`
+}
+
+// VelocityConfig limits how many transfers a user sends within a window,
+// such as "1h".
+type VelocityConfig struct {
+	Window       string `json:"window" yaml:"window"`
+	MaxTransfers int64  `json:"max_transfers" yaml:"max_transfers"`
+	// Decision is review or deny, review by default.
+	Decision string `json:"decision" yaml:"decision"`
+}
+
+// AmountThresholdConfig reviews or denies single transfers above an amount.
+// Either threshold may be left out.
+type AmountThresholdConfig struct {
+	Currency    string `json:"currency" yaml:"currency"`
+	ReviewAbove string `json:"review_above" yaml:"review_above"`
+	DenyAbove   string `json:"deny_above" yaml:"deny_above"`
+}
+
+// NewRecipientConfig limits the first transfer of a user to an account, per
+// currency. Currencies without a limit are not checked.
+type NewRecipientConfig struct {
+	Limits map[string]string `json:"limits" yaml:"limits"`
+	// Decision is review or deny, review by default.
+	Decision string `json:"decision" yaml:"decision"`
+}
+
+// BlocklistConfig denies transfers from or to the listed accounts, or those
+// of the listed users.
+type BlocklistConfig struct {
+	Accounts  []int64  `json:"accounts" yaml:"accounts"`
+	Usernames []string `json:"usernames" yaml:"usernames"`
+}
+
+// LoadFile reads the rules from a .json, .yaml or .yml file. Unknown keys are
+// rejected, so that a misspelled rule is not silently ignored.
+func LoadFile(path string) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&config)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&config)
+	default:
+		return config, fmt.Errorf("unsupported risk rules file %q", path)
+	}
+	if err != nil {
+		return config, fmt.Errorf("cannot parse risk rules file %q: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// rules builds the configured rules, blocklist first as it is the cheapest
+// and the most decisive.
+func (config Config) rules(store db.Store, now func() time.Time) ([]Rule, error) {
+	var rules []Rule
+
+	if len(config.Blocklist.Accounts) > 0 || len(config.Blocklist.Usernames) > 0 {
+		rules = append(rules, newBlocklistRule(config.Blocklist))
+	}
+
+	for i, threshold := range config.AmountThresholds {
+		rule, err := newAmountThresholdRule(threshold)
+		if err != nil {
+			return nil, fmt.Errorf("amount_thresholds[%d]: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if config.NewRecipient != nil {
+		rule, err := newNewRecipientRule(store, *config.NewRecipient)
+		if err != nil {
+			return nil, fmt.Errorf("new_recipient: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	for i, velocity := range config.Velocity {
+		rule, err := newVelocityRule(store, velocity, now)
+		if err != nil {
+			return nil, fmt.Errorf("velocity[%d]: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// parseDecision defaults to review. Allowing is not a decision a rule makes.
+func parseDecision(decision string) (string, error) {
+	switch decision {
+	case "":
+		return db.RiskReview, nil
+	case db.RiskReview, db.RiskDeny:
+		return decision, nil
+	}
+	return "", fmt.Errorf("decision must be %s or %s, not %q", db.RiskReview, db.RiskDeny, decision)
+}
+
+// parseAmount parses an optional threshold. Zero means none.
+func parseAmount(amount string, currency string) (int64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+
+	money, err := util.ParseMoney(amount, currency)
+	if err != nil {
+		return 0, err
+	}
+	if !money.IsPositive() {
+		return 0, fmt.Errorf("amount %s must be greater than zero", amount)
+	}
+	return money.Amount, nil
+}
@@ -0,0 +1,85 @@
+package risk
+
+import (
+	"path/filepath"
+	"simple_bank/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	yamlConfig, err := LoadFile(filepath.Join("testdata", "rules.yaml"))
+	require.NoError(t, err)
+
+	require.Equal(t, []int64{13}, yamlConfig.Blocklist.Accounts)
+	require.Equal(t, []string{"mallory"}, yamlConfig.Blocklist.Usernames)
+	require.Len(t, yamlConfig.AmountThresholds, 1)
+	require.Equal(t, "50000.00", yamlConfig.AmountThresholds[0].DenyAbove)
+	require.Equal(t, "1000.00", yamlConfig.NewRecipient.Limits["USD"])
+	require.Len(t, yamlConfig.Velocity, 2)
+	require.Equal(t, "24h", yamlConfig.Velocity[1].Window)
+
+	// both formats describe the same rules
+	jsonConfig, err := LoadFile(filepath.Join("testdata", "rules.json"))
+	require.NoError(t, err)
+	require.Equal(t, yamlConfig, jsonConfig)
+
+	_, err = NewEngine(mocks.NewStore(t), yamlConfig)
+	require.NoError(t, err)
+}
+
+func TestLoadFileUnknownKey(t *testing.T) {
+	_, err := LoadFile(filepath.Join("testdata", "misspelled.yaml"))
+	require.ErrorContains(t, err, "max_transfer")
+}
+
+func TestLoadUnsupportedFile(t *testing.T) {
+	_, err := LoadFile(filepath.Join("testdata", "rules.toml"))
+	require.Error(t, err)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+	}{
+		{
+			name:   "UnknownCurrency",
+			config: Config{AmountThresholds: []AmountThresholdConfig{{Currency: "XYZ", DenyAbove: "10"}}},
+		},
+		{
+			name:   "NoThreshold",
+			config: Config{AmountThresholds: []AmountThresholdConfig{{Currency: "USD"}}},
+		},
+		{
+			name:   "NegativeThreshold",
+			config: Config{AmountThresholds: []AmountThresholdConfig{{Currency: "USD", ReviewAbove: "-1"}}},
+		},
+		{
+			name:   "AllowDecision",
+			config: Config{Velocity: []VelocityConfig{{Window: "1h", MaxTransfers: 5, Decision: "allow"}}},
+		},
+		{
+			name:   "InvalidWindow",
+			config: Config{Velocity: []VelocityConfig{{Window: "daily", MaxTransfers: 5}}},
+		},
+		{
+			name:   "NoMaxTransfers",
+			config: Config{Velocity: []VelocityConfig{{Window: "1h"}}},
+		},
+		{
+			name:   "InvalidNewRecipientLimit",
+			config: Config{NewRecipient: &NewRecipientConfig{Limits: map[string]string{"USD": "ten"}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.config.rules(mocks.NewStore(t), time.Now)
+			require.Error(t, err)
+		})
+	}
+}
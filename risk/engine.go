@@ -0,0 +1,149 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	db "simple_bank/db/models"
+	"time"
+)
+
+// Transfer is what the rules look at.
+type Transfer struct {
+	Username      string
+	FromAccountID int64
+	ToAccountID   int64
+	// ToOwner is the owner of the to account.
+	ToOwner  string
+	Amount   int64
+	Currency string
+	// Earlier are the transfers screened before this one that are not
+	// stored yet, such as the previous legs of a batch. Rules that look at
+	// recent transfers count them too.
+	Earlier []Transfer
+}
+
+// Reason explains why a rule did not simply allow a transfer. Code is stable
+// and meant for clients, Message for people.
+type Reason struct {
+	Rule     string `json:"rule"`
+	Code     string `json:"code"`
+	Decision string `json:"decision"`
+	Message  string `json:"message"`
+}
+
+// Rule checks a transfer. It returns nil when the transfer is allowed as far
+// as it is concerned.
+type Rule interface {
+	Evaluate(ctx context.Context, transfer Transfer) (*Reason, error)
+}
+
+// Result is the decision on a transfer: the most severe decision of the
+// rules that matched, along with all of their reasons.
+type Result struct {
+	DecisionID int64    `json:"decision_id"`
+	Decision   string   `json:"decision"`
+	Reasons    []Reason `json:"reasons"`
+}
+
+// Denial returns the first reason for denying the transfer, or nil.
+func (result Result) Denial() *Reason {
+	for i := range result.Reasons {
+		if result.Reasons[i].Decision == db.RiskDeny {
+			return &result.Reasons[i]
+		}
+	}
+	return nil
+}
+
+// Engine runs the rules on transfers and records its decisions.
+type Engine struct {
+	store db.Store
+	rules []Rule
+}
+
+// NewEngine builds the built-in rules configured in config, followed by any
+// extra rules.
+func NewEngine(store db.Store, config Config, extra ...Rule) (*Engine, error) {
+	rules, err := config.rules(store, time.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Engine{
+		store: store,
+		rules: append(rules, extra...),
+	}, nil
+}
+
+// Load builds an engine from the rules file at path. Without a path it
+// returns nil, and transfers are not screened.
+func Load(store db.Store, path string) (*Engine, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	config, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(store, config)
+}
+
+// Evaluate runs every rule on transfer without recording the decision.
+func (engine *Engine) Evaluate(ctx context.Context, transfer Transfer) (Result, error) {
+	result := Result{
+		Decision: db.RiskAllow,
+		Reasons:  []Reason{},
+	}
+
+	for _, rule := range engine.rules {
+		reason, err := rule.Evaluate(ctx, transfer)
+		if err != nil {
+			return result, err
+		}
+		if reason == nil {
+			continue
+		}
+
+		result.Reasons = append(result.Reasons, *reason)
+		if severity[reason.Decision] > severity[result.Decision] {
+			result.Decision = reason.Decision
+		}
+	}
+	return result, nil
+}
+
+// Check evaluates transfer and records the decision with its reasons.
+func (engine *Engine) Check(ctx context.Context, transfer Transfer) (Result, error) {
+	result, err := engine.Evaluate(ctx, transfer)
+	if err != nil {
+		return result, err
+	}
+
+	reasons, err := json.Marshal(result.Reasons)
+	if err != nil {
+		return result, err
+	}
+
+	decision, err := engine.store.CreateRiskDecision(ctx, db.CreateRiskDecisionParams{
+		Username:      transfer.Username,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Currency:      transfer.Currency,
+		Decision:      result.Decision,
+		Reasons:       reasons,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.DecisionID = decision.ID
+	return result, nil
+}
+
+var severity = map[string]int{
+	db.RiskAllow:  0,
+	db.RiskReview: 1,
+	db.RiskDeny:   2,
+}
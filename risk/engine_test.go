@@ -0,0 +1,277 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testEngine(t *testing.T, store db.Store, config Config, now time.Time) *Engine {
+	rules, err := config.rules(store, func() time.Time { return now })
+	require.NoError(t, err)
+	return &Engine{store: store, rules: rules}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2023, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	config := Config{
+		Blocklist: BlocklistConfig{
+			Accounts:  []int64{13},
+			Usernames: []string{"mallory"},
+		},
+		AmountThresholds: []AmountThresholdConfig{
+			{Currency: util.USD, ReviewAbove: "5000.00", DenyAbove: "50000.00"},
+		},
+		NewRecipient: &NewRecipientConfig{
+			Limits: map[string]string{util.USD: "1000.00"},
+		},
+		Velocity: []VelocityConfig{
+			{Window: "1h", MaxTransfers: 10, Decision: db.RiskDeny},
+		},
+	}
+
+	transfer := Transfer{
+		Username:      "alice",
+		FromAccountID: 1,
+		ToAccountID:   2,
+		ToOwner:       "bob",
+		Amount:        10000,
+		Currency:      util.USD,
+	}
+
+	testCases := []struct {
+		name        string
+		transfer    func() Transfer
+		buildStubs  func(store *mocks.Store)
+		checkResult func(t *testing.T, result Result)
+	}{
+		{
+			name:     "Allow",
+			transfer: func() Transfer { return transfer },
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersSince", mock.Anything, db.CountOwnerTransfersSinceParams{
+					Owner: "alice",
+					Since: now.Add(-time.Hour),
+				}).Return(int64(9), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskAllow, result.Decision)
+				require.Empty(t, result.Reasons)
+				require.Nil(t, result.Denial())
+			},
+		},
+		{
+			name: "BlocklistedRecipient",
+			transfer: func() Transfer {
+				blocked := transfer
+				blocked.ToOwner = "mallory"
+				return blocked
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskDeny, result.Decision)
+				require.Equal(t, CodeBlocklisted, result.Denial().Code)
+			},
+		},
+		{
+			name: "BlocklistedAccount",
+			transfer: func() Transfer {
+				blocked := transfer
+				blocked.ToAccountID = 13
+				return blocked
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskDeny, result.Decision)
+				require.Equal(t, CodeBlocklisted, result.Denial().Code)
+			},
+		},
+		{
+			name: "AboveReviewThresholdToKnownRecipient",
+			transfer: func() Transfer {
+				large := transfer
+				large.Amount = 500001
+				return large
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersTo", mock.Anything, db.CountOwnerTransfersToParams{
+					Owner:       "alice",
+					ToAccountID: 2,
+				}).Return(int64(3), nil)
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskReview, result.Decision)
+				require.Len(t, result.Reasons, 1)
+				require.Equal(t, CodeAmountThreshold, result.Reasons[0].Code)
+				require.Equal(t, "amount is above 5000.00 USD", result.Reasons[0].Message)
+				require.Nil(t, result.Denial())
+			},
+		},
+		{
+			name: "AboveDenyThreshold",
+			transfer: func() Transfer {
+				large := transfer
+				large.Amount = 5000001
+				return large
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersTo", mock.Anything, mock.Anything).Return(int64(3), nil)
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskDeny, result.Decision)
+				require.Equal(t, CodeAmountThreshold, result.Denial().Code)
+			},
+		},
+		{
+			name: "OtherCurrency",
+			transfer: func() Transfer {
+				euros := transfer
+				euros.Amount = 5000001
+				euros.Currency = util.EUR
+				return euros
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskAllow, result.Decision)
+			},
+		},
+		{
+			name: "NewRecipient",
+			transfer: func() Transfer {
+				first := transfer
+				first.Amount = 100001
+				return first
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersTo", mock.Anything, mock.Anything).Return(int64(0), nil)
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskReview, result.Decision)
+				require.Len(t, result.Reasons, 1)
+				require.Equal(t, CodeNewRecipientLimit, result.Reasons[0].Code)
+			},
+		},
+		{
+			name:     "Velocity",
+			transfer: func() Transfer { return transfer },
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(10), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskDeny, result.Decision)
+				require.Equal(t, CodeVelocity, result.Denial().Code)
+			},
+		},
+		{
+			// the earlier legs of a batch are not stored yet
+			name: "VelocityWithEarlier",
+			transfer: func() Transfer {
+				leg := transfer
+				leg.Earlier = []Transfer{transfer, transfer}
+				return leg
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(8), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskDeny, result.Decision)
+				require.Equal(t, CodeVelocity, result.Denial().Code)
+			},
+		},
+		{
+			name: "NewRecipientWithEarlier",
+			transfer: func() Transfer {
+				earlier := transfer
+				earlier.Amount = 60000
+				leg := earlier
+				leg.Earlier = []Transfer{earlier}
+				return leg
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersTo", mock.Anything, mock.Anything).Return(int64(0), nil)
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskReview, result.Decision)
+				require.Equal(t, CodeNewRecipientLimit, result.Reasons[0].Code)
+			},
+		},
+		{
+			name: "MostSevereWins",
+			transfer: func() Transfer {
+				first := transfer
+				first.Amount = 500001
+				return first
+			},
+			buildStubs: func(store *mocks.Store) {
+				store.On("CountOwnerTransfersTo", mock.Anything, mock.Anything).Return(int64(0), nil)
+				store.On("CountOwnerTransfersSince", mock.Anything, mock.Anything).Return(int64(10), nil)
+			},
+			checkResult: func(t *testing.T, result Result) {
+				require.Equal(t, db.RiskDeny, result.Decision)
+				require.Len(t, result.Reasons, 3)
+				require.Equal(t, CodeVelocity, result.Denial().Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			store := mocks.NewStore(t)
+			tc.buildStubs(store)
+
+			result, err := testEngine(t, store, config, now).Evaluate(context.Background(), tc.transfer())
+			require.NoError(t, err)
+			tc.checkResult(t, result)
+		})
+	}
+}
+
+func TestCheckRecordsDecision(t *testing.T) {
+	transfer := Transfer{
+		Username:      "mallory",
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        100,
+		Currency:      util.USD,
+	}
+
+	store := mocks.NewStore(t)
+	store.
+		On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+			var reasons []Reason
+			if err := json.Unmarshal(arg.Reasons, &reasons); err != nil || len(reasons) != 1 {
+				return false
+			}
+			return arg.Username == "mallory" &&
+				arg.Amount == 100 &&
+				arg.Decision == db.RiskDeny &&
+				reasons[0].Code == CodeBlocklisted
+		})).
+		Return(db.RiskDecision{ID: 42}, nil)
+
+	engine := testEngine(t, store, Config{Blocklist: BlocklistConfig{Usernames: []string{"mallory"}}}, time.Now())
+
+	result, err := engine.Check(context.Background(), transfer)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), result.DecisionID)
+	require.Equal(t, db.RiskDeny, result.Decision)
+}
@@ -0,0 +1,225 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simple_bank/db/models"
+	"simple_bank/util"
+	"strings"
+	"time"
+)
+
+// Codes of the built-in rules, returned to clients when a transfer is denied.
+const (
+	CodeBlocklisted       = "blocklisted"
+	CodeAmountThreshold   = "amount_threshold_exceeded"
+	CodeNewRecipientLimit = "new_recipient_limit_exceeded"
+	CodeVelocity          = "velocity_exceeded"
+)
+
+type blocklistRule struct {
+	accounts  map[int64]bool
+	usernames map[string]bool
+}
+
+func newBlocklistRule(config BlocklistConfig) *blocklistRule {
+	rule := &blocklistRule{
+		accounts:  make(map[int64]bool, len(config.Accounts)),
+		usernames: make(map[string]bool, len(config.Usernames)),
+	}
+	for _, id := range config.Accounts {
+		rule.accounts[id] = true
+	}
+	for _, username := range config.Usernames {
+		rule.usernames[username] = true
+	}
+	return rule
+}
+
+func (rule *blocklistRule) Evaluate(ctx context.Context, transfer Transfer) (*Reason, error) {
+	var message string
+	switch {
+	case rule.accounts[transfer.FromAccountID]:
+		message = fmt.Sprintf("account %d is blocklisted", transfer.FromAccountID)
+	case rule.accounts[transfer.ToAccountID]:
+		message = fmt.Sprintf("account %d is blocklisted", transfer.ToAccountID)
+	case rule.usernames[transfer.Username]:
+		message = fmt.Sprintf("user %s is blocklisted", transfer.Username)
+	case rule.usernames[transfer.ToOwner]:
+		message = "recipient is blocklisted"
+	default:
+		return nil, nil
+	}
+
+	return &Reason{
+		Rule:     "blocklist",
+		Code:     CodeBlocklisted,
+		Decision: db.RiskDeny,
+		Message:  message,
+	}, nil
+}
+
+type amountThresholdRule struct {
+	currency    string
+	reviewAbove int64
+	denyAbove   int64
+}
+
+func newAmountThresholdRule(config AmountThresholdConfig) (*amountThresholdRule, error) {
+	currency := strings.ToUpper(config.Currency)
+	if !util.IsSupportedCurrency(currency) {
+		return nil, fmt.Errorf("unsupported currency %q", config.Currency)
+	}
+
+	reviewAbove, err := parseAmount(config.ReviewAbove, currency)
+	if err != nil {
+		return nil, fmt.Errorf("review_above: %w", err)
+	}
+	denyAbove, err := parseAmount(config.DenyAbove, currency)
+	if err != nil {
+		return nil, fmt.Errorf("deny_above: %w", err)
+	}
+	if reviewAbove == 0 && denyAbove == 0 {
+		return nil, errors.New("review_above or deny_above is required")
+	}
+
+	return &amountThresholdRule{
+		currency:    currency,
+		reviewAbove: reviewAbove,
+		denyAbove:   denyAbove,
+	}, nil
+}
+
+func (rule *amountThresholdRule) Evaluate(ctx context.Context, transfer Transfer) (*Reason, error) {
+	if transfer.Currency != rule.currency {
+		return nil, nil
+	}
+
+	decision, threshold := db.RiskDeny, rule.denyAbove
+	if threshold == 0 || transfer.Amount <= threshold {
+		decision, threshold = db.RiskReview, rule.reviewAbove
+	}
+	if threshold == 0 || transfer.Amount <= threshold {
+		return nil, nil
+	}
+
+	return &Reason{
+		Rule:     "amount_threshold",
+		Code:     CodeAmountThreshold,
+		Decision: decision,
+		Message:  "amount is above " + util.NewMoney(threshold, rule.currency).String(),
+	}, nil
+}
+
+type newRecipientRule struct {
+	store    db.Store
+	limits   map[string]int64
+	decision string
+}
+
+func newNewRecipientRule(store db.Store, config NewRecipientConfig) (*newRecipientRule, error) {
+	decision, err := parseDecision(config.Decision)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &newRecipientRule{
+		store:    store,
+		limits:   make(map[string]int64, len(config.Limits)),
+		decision: decision,
+	}
+	for currency, limit := range config.Limits {
+		currency = strings.ToUpper(currency)
+		if !util.IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("unsupported currency %q", currency)
+		}
+
+		rule.limits[currency], err = parseAmount(limit, currency)
+		if err != nil {
+			return nil, fmt.Errorf("limits[%s]: %w", currency, err)
+		}
+	}
+	return rule, nil
+}
+
+func (rule *newRecipientRule) Evaluate(ctx context.Context, transfer Transfer) (*Reason, error) {
+	// earlier transfers to the same recipient count towards the first one
+	amount := transfer.Amount
+	for _, earlier := range transfer.Earlier {
+		if earlier.ToAccountID == transfer.ToAccountID {
+			amount += earlier.Amount
+		}
+	}
+
+	limit, ok := rule.limits[transfer.Currency]
+	if !ok || amount <= limit {
+		return nil, nil
+	}
+
+	count, err := rule.store.CountOwnerTransfersTo(ctx, db.CountOwnerTransfersToParams{
+		Owner:       transfer.Username,
+		ToAccountID: transfer.ToAccountID,
+	})
+	if err != nil || count > 0 {
+		return nil, err
+	}
+
+	return &Reason{
+		Rule:     "new_recipient",
+		Code:     CodeNewRecipientLimit,
+		Decision: rule.decision,
+		Message:  "first transfer to this recipient is above " + util.NewMoney(limit, transfer.Currency).String(),
+	}, nil
+}
+
+type velocityRule struct {
+	store        db.Store
+	window       time.Duration
+	maxTransfers int64
+	decision     string
+	now          func() time.Time
+}
+
+func newVelocityRule(store db.Store, config VelocityConfig, now func() time.Time) (*velocityRule, error) {
+	window, err := time.ParseDuration(config.Window)
+	if err != nil {
+		return nil, fmt.Errorf("window: %w", err)
+	}
+	if window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+	if config.MaxTransfers <= 0 {
+		return nil, errors.New("max_transfers must be positive")
+	}
+
+	decision, err := parseDecision(config.Decision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &velocityRule{
+		store:        store,
+		window:       window,
+		maxTransfers: config.MaxTransfers,
+		decision:     decision,
+		now:          now,
+	}, nil
+}
+
+func (rule *velocityRule) Evaluate(ctx context.Context, transfer Transfer) (*Reason, error) {
+	count, err := rule.store.CountOwnerTransfersSince(ctx, db.CountOwnerTransfersSinceParams{
+		Owner: transfer.Username,
+		Since: rule.now().Add(-rule.window),
+	})
+	if err != nil || count+int64(len(transfer.Earlier)) < rule.maxTransfers {
+		return nil, err
+	}
+
+	return &Reason{
+		Rule:     "velocity",
+		Code:     CodeVelocity,
+		Decision: rule.decision,
+		Message:  fmt.Sprintf("more than %d transfers within %s", rule.maxTransfers, rule.window),
+	}, nil
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseCron(t *testing.T) {
+	testCases := []struct {
+		spec string
+		from string
+		next string
+	}{
+		{"0 9 1 * *", "2023-03-14 10:00", "2023-04-01 09:00"},
+		{"0 9 1 * *", "2023-04-01 08:59", "2023-04-01 09:00"},
+		{"0 9 1 * *", "2023-04-01 09:00", "2023-05-01 09:00"},
+		{"*/15 * * * *", "2023-03-14 10:07", "2023-03-14 10:15"},
+		{"30 8-10 * * *", "2023-03-14 10:30", "2023-03-15 08:30"},
+		{"0 0 * * 1,5", "2023-03-14 10:00", "2023-03-17 00:00"},
+		{"0 0 * * 7", "2023-03-14 10:00", "2023-03-19 00:00"},
+		{"0 0 31 * *", "2023-04-01 00:00", "2023-05-31 00:00"},
+		{"0 0 29 2 *", "2023-01-01 00:00", "2024-02-29 00:00"},
+		// day of month and day of week both restricted match either
+		{"0 0 13 * 5", "2023-03-14 00:00", "2023-03-17 00:00"},
+		{"@monthly", "2023-12-15 12:00", "2024-01-01 00:00"},
+		{"@weekly", "2023-03-14 12:00", "2023-03-19 00:00"},
+		{"@daily", "2023-03-14 12:00", "2023-03-15 00:00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec+" from "+tc.from, func(t *testing.T) {
+			schedule, err := Parse(tc.spec, time.Time{})
+			require.NoError(t, err)
+			require.Equal(t, at(tc.next), schedule.Next(at(tc.from)))
+		})
+	}
+}
+
+func TestParseCronNeverMatches(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *", time.Time{})
+	require.NoError(t, err)
+	require.True(t, schedule.Next(at("2023-01-01 00:00")).IsZero())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every",
+		"@every 30s",
+		"@every month",
+		"@sometimes",
+	} {
+		_, err := Parse(spec, time.Time{})
+		require.ErrorIs(t, err, ErrInvalidSchedule, spec)
+	}
+}
+
+func TestIntervalSchedule(t *testing.T) {
+	anchor := at("2023-03-14 09:00")
+	schedule, err := Parse("@every 168h", anchor)
+	require.NoError(t, err)
+
+	require.Equal(t, anchor, schedule.Next(at("2023-03-01 00:00")))
+	require.Equal(t, at("2023-03-21 09:00"), schedule.Next(anchor))
+	require.Equal(t, at("2023-03-28 09:00"), schedule.Next(at("2023-03-22 00:00")))
+}
+
+func TestFirstRun(t *testing.T) {
+	monthly, err := Parse("0 9 1 * *", time.Time{})
+	require.NoError(t, err)
+
+	// a start on an activation runs right away
+	require.Equal(t, at("2023-04-01 09:00"), FirstRun(monthly, at("2023-04-01 09:00"), at("2023-03-14 00:00")))
+	// a start in the past runs at the next activation from now
+	require.Equal(t, at("2023-04-01 09:00"), FirstRun(monthly, at("2023-01-01 00:00"), at("2023-03-14 00:00")))
+
+	start := at("2023-03-14 09:00")
+	weekly, err := Parse("@every 168h", start)
+	require.NoError(t, err)
+	require.Equal(t, start, FirstRun(weekly, start, at("2023-03-14 08:00")))
+	require.Equal(t, at("2023-03-21 09:00"), FirstRun(weekly, start, at("2023-03-15 08:00")))
+}
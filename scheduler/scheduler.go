@@ -0,0 +1,292 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	db "simple_bank/db/models"
+	"simple_bank/risk"
+	"simple_bank/util"
+	"time"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultMaxAttempts  = 5
+	defaultRetryDelay   = time.Minute
+
+	// claimLease is how long a claimed transfer is hidden from other
+	// replicas. It must be longer than executing a batch takes.
+	claimLease = 5 * time.Minute
+	batchSize  = 100
+)
+
+// errNeedsApproval fails an occurrence whose amount went above the approval
+// threshold of the from account after the transfer was scheduled. Nobody waits
+// for an approver on a scheduled run, so it is not held like a transfer made
+// through the API.
+var errNeedsApproval = errors.New("amount is above the approval threshold of the account")
+
+// errRiskDenied fails an occurrence denied by the risk rules. The rules are
+// run on every occurrence, as they may have changed since the transfer was
+// scheduled.
+var errRiskDenied = errors.New("transfer denied")
+
+type Config struct {
+	// MaxAttempts is how many times an occurrence is tried before it is
+	// recorded as failed and skipped.
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubled on every
+	// further attempt.
+	RetryDelay time.Duration
+	// Risk screens every occurrence. Occurrences are not screened without
+	// it.
+	Risk *risk.Engine
+}
+
+// Scheduler executes due scheduled transfers through Store.TransferTx. Due
+// transfers are leased to one replica at a time, and every occurrence is
+// transferred under its own idempotency key, so even a replica that lost its
+// lease cannot pay an occurrence twice.
+type Scheduler struct {
+	store  db.Store
+	config Config
+	now    func() time.Time
+}
+
+func New(store db.Store, config Config) *Scheduler {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRetryDelay
+	}
+	return &Scheduler{
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// RunOnce executes the transfers due now and returns how many it claimed. A
+// transfer whose outcome cannot be recorded is logged and picked up again
+// once its lease expires.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+
+	due, err := s.store.ClaimDueScheduledTransfers(ctx, db.ClaimDueScheduledTransfersParams{
+		Now:         now,
+		LockedUntil: now.Add(claimLease),
+		Limit:       batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, scheduled := range due {
+		if err := s.execute(ctx, scheduled, now); err != nil {
+			log.Printf("cannot execute scheduled transfer %d: %v", scheduled.ID, err)
+		}
+	}
+	return len(due), nil
+}
+
+func (s *Scheduler) execute(ctx context.Context, scheduled db.ScheduledTransfer, now time.Time) error {
+	result, err := s.transfer(ctx, scheduled)
+
+	attempt := scheduled.Attempts + 1
+	record := db.RecordScheduledRunTxParams{
+		Execution: db.CreateScheduledTransferExecutionParams{
+			ScheduledTransferID: scheduled.ID,
+			ScheduledFor:        scheduled.NextRunAt,
+			Attempt:             attempt,
+			Status:              db.ExecutionSucceeded,
+		},
+	}
+
+	switch {
+	case err == nil:
+		record.Execution.TransferID = &result.Transfer.ID
+		record.Run = s.nextRun(scheduled, now)
+	case isTransient(err) && int(attempt) < s.config.MaxAttempts:
+		message := err.Error()
+		retryAt := now.Add(s.config.RetryDelay << (attempt - 1))
+		record.Execution.Status = db.ExecutionRetrying
+		record.Execution.Error = &message
+		record.Run = db.UpdateScheduledTransferRunParams{
+			ID:          scheduled.ID,
+			NextRunAt:   scheduled.NextRunAt,
+			Status:      db.ScheduledTransferActive,
+			Attempts:    attempt,
+			LockedUntil: &retryAt,
+		}
+	default:
+		message := err.Error()
+		record.Execution.Status = db.ExecutionFailed
+		record.Execution.Error = &message
+		record.Run = s.nextRun(scheduled, now)
+	}
+
+	_, err = s.store.RecordScheduledRunTx(ctx, record)
+	return err
+}
+
+// transfer executes the current occurrence of scheduled, unless the from
+// account now requires an approval for its amount.
+func (s *Scheduler) transfer(ctx context.Context, scheduled db.ScheduledTransfer) (db.TransferTxResult, error) {
+	fromAccount, err := s.store.GetAccount(ctx, scheduled.FromAccountID)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	if fromAccount.ApprovalThreshold != nil && scheduled.Amount > *fromAccount.ApprovalThreshold {
+		return db.TransferTxResult{}, errNeedsApproval
+	}
+
+	arg := transferParams(scheduled)
+	arg.RiskDecisionID, err = s.screen(ctx, scheduled, arg)
+	if err != nil {
+		return db.TransferTxResult{}, err
+	}
+
+	return s.store.TransferTx(ctx, arg)
+}
+
+// screen runs the risk rules on the current occurrence of scheduled and
+// returns the decision to link to its transfer. A retry of an occurrence whose
+// idempotency key is already used is not screened again, as the transfer only
+// replays the first result.
+func (s *Scheduler) screen(ctx context.Context, scheduled db.ScheduledTransfer, arg db.TransferTxParams) (*int64, error) {
+	if s.config.Risk == nil {
+		return nil, nil
+	}
+
+	_, err := s.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	toAccount, err := s.store.GetAccount(ctx, scheduled.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.config.Risk.Check(ctx, risk.Transfer{
+		Username:      scheduled.Owner,
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		ToOwner:       toAccount.Owner,
+		Amount:        scheduled.Amount,
+		Currency:      scheduled.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if denial := result.Denial(); denial != nil {
+		return nil, fmt.Errorf("%w: %s", errRiskDenied, denial.Message)
+	}
+	return &result.DecisionID, nil
+}
+
+// nextRun moves scheduled past now. Occurrences missed while no scheduler was
+// running are skipped rather than executed in a burst.
+func (s *Scheduler) nextRun(scheduled db.ScheduledTransfer, now time.Time) db.UpdateScheduledTransferRunParams {
+	run := db.UpdateScheduledTransferRunParams{
+		ID:        scheduled.ID,
+		NextRunAt: scheduled.NextRunAt,
+		Status:    db.ScheduledTransferActive,
+	}
+
+	schedule, err := Parse(scheduled.Schedule, scheduled.StartAt)
+	if err != nil {
+		// the API only stores valid schedules; wait for the owner to fix it
+		run.Status = db.ScheduledTransferPaused
+		return run
+	}
+
+	after := scheduled.NextRunAt
+	if now.After(after) {
+		after = now
+	}
+	next := schedule.Next(after)
+	if next.IsZero() || scheduled.EndAt != nil && next.After(*scheduled.EndAt) {
+		run.Status = db.ScheduledTransferCompleted
+		return run
+	}
+
+	run.NextRunAt = next
+	return run
+}
+
+// transferParams builds the transfer of the current occurrence. Its
+// idempotency key is derived from the occurrence, so executing it again
+// replays the first result.
+func transferParams(scheduled db.ScheduledTransfer) db.TransferTxParams {
+	arg := db.TransferTxParams{
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+	}
+
+	request := fmt.Sprintf("%d:%d:%d", arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	sum := sha256.Sum256([]byte(request))
+
+	arg.Idempotency = &db.CreateIdempotencyKeyParams{
+		Key:         fmt.Sprintf("scheduled_transfer:%d:%d", scheduled.ID, scheduled.NextRunAt.Unix()),
+		Username:    scheduled.Owner,
+		RequestHash: hex.EncodeToString(sum[:]),
+	}
+	return arg
+}
+
+// isTransient reports whether retrying the same transfer later may succeed.
+// Missing funds are not retried within an occurrence: the next one will try
+// again.
+func isTransient(err error) bool {
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, util.ErrAmountOverflow),
+		errors.Is(err, errNeedsApproval),
+		errors.Is(err, errRiskDenied),
+		errors.Is(err, db.ErrIdempotencyKeyReused),
+		errors.Is(err, sql.ErrNoRows):
+		return false
+	}
+	return true
+}
+
+// Run calls RunOnce immediately and then every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	if _, err := s.RunOnce(ctx); err != nil {
+		log.Println("cannot run scheduled transfers:", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx); err != nil {
+				log.Println("cannot run scheduled transfers:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/risk"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOnce(t *testing.T) {
+	now := at("2023-04-01 09:00")
+	endAt := at("2023-05-15 00:00")
+
+	monthly := db.ScheduledTransfer{
+		ID:            1,
+		Owner:         "alice",
+		FromAccountID: 10,
+		ToAccountID:   20,
+		Amount:        150000,
+		Currency:      "USD",
+		Schedule:      "0 9 1 * *",
+		StartAt:       at("2023-01-01 00:00"),
+		NextRunAt:     at("2023-04-01 09:00"),
+		Status:        db.ScheduledTransferActive,
+	}
+
+	threshold := int64(100000)
+
+	testCases := []struct {
+		name        string
+		scheduled   func() db.ScheduledTransfer
+		threshold   *int64
+		transferErr error
+		checkRecord func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams)
+	}{
+		{
+			name:      "Succeeded",
+			scheduled: func() db.ScheduledTransfer { return monthly },
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionSucceeded, arg.Execution.Status)
+				require.Equal(t, int32(1), arg.Execution.Attempt)
+				require.Equal(t, scheduled.NextRunAt, arg.Execution.ScheduledFor)
+				require.Equal(t, int64(99), *arg.Execution.TransferID)
+				require.Nil(t, arg.Execution.Error)
+
+				require.Equal(t, db.ScheduledTransferActive, arg.Run.Status)
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+				require.Zero(t, arg.Run.Attempts)
+				require.Nil(t, arg.Run.LockedUntil)
+			},
+		},
+		{
+			name: "LastRunBeforeEnd",
+			scheduled: func() db.ScheduledTransfer {
+				scheduled := monthly
+				scheduled.NextRunAt = at("2023-05-01 09:00")
+				scheduled.EndAt = &endAt
+				return scheduled
+			},
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionSucceeded, arg.Execution.Status)
+				require.Equal(t, db.ScheduledTransferCompleted, arg.Run.Status)
+			},
+		},
+		{
+			name: "SkipsMissedOccurrences",
+			scheduled: func() db.ScheduledTransfer {
+				scheduled := monthly
+				scheduled.NextRunAt = at("2023-01-01 09:00")
+				return scheduled
+			},
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, at("2023-01-01 09:00"), arg.Execution.ScheduledFor)
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+			},
+		},
+		{
+			name:        "InsufficientFunds",
+			scheduled:   func() db.ScheduledTransfer { return monthly },
+			transferErr: fmt.Errorf("%w: account 10", db.ErrInsufficientFunds),
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionFailed, arg.Execution.Status)
+				require.Nil(t, arg.Execution.TransferID)
+				require.Contains(t, *arg.Execution.Error, "insufficient funds")
+
+				require.Equal(t, db.ScheduledTransferActive, arg.Run.Status)
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+			},
+		},
+		{
+			name:        "AccountDeleted",
+			scheduled:   func() db.ScheduledTransfer { return monthly },
+			transferErr: sql.ErrNoRows,
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionFailed, arg.Execution.Status)
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+			},
+		},
+		{
+			name:      "AboveApprovalThreshold",
+			scheduled: func() db.ScheduledTransfer { return monthly },
+			threshold: &threshold,
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionFailed, arg.Execution.Status)
+				require.Nil(t, arg.Execution.TransferID)
+				require.Equal(t, errNeedsApproval.Error(), *arg.Execution.Error)
+
+				require.Equal(t, db.ScheduledTransferActive, arg.Run.Status)
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+			},
+		},
+		{
+			name: "TransientRetried",
+			scheduled: func() db.ScheduledTransfer {
+				scheduled := monthly
+				scheduled.Attempts = 1
+				return scheduled
+			},
+			transferErr: sql.ErrConnDone,
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionRetrying, arg.Execution.Status)
+				require.Equal(t, int32(2), arg.Execution.Attempt)
+
+				require.Equal(t, db.ScheduledTransferActive, arg.Run.Status)
+				require.Equal(t, scheduled.NextRunAt, arg.Run.NextRunAt)
+				require.Equal(t, int32(2), arg.Run.Attempts)
+				// the delay doubles on every retry
+				require.Equal(t, now.Add(2*time.Minute), *arg.Run.LockedUntil)
+			},
+		},
+		{
+			name: "TransientExhausted",
+			scheduled: func() db.ScheduledTransfer {
+				scheduled := monthly
+				scheduled.Attempts = 2
+				return scheduled
+			},
+			transferErr: sql.ErrConnDone,
+			checkRecord: func(t *testing.T, scheduled db.ScheduledTransfer, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionFailed, arg.Execution.Status)
+				require.Equal(t, int32(3), arg.Execution.Attempt)
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+				require.Zero(t, arg.Run.Attempts)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			scheduled := tc.scheduled()
+
+			storeMock := mocks.NewStore(t)
+			storeMock.
+				On("ClaimDueScheduledTransfers", mock.Anything, db.ClaimDueScheduledTransfersParams{
+					Now:         now,
+					LockedUntil: now.Add(claimLease),
+					Limit:       batchSize,
+				}).
+				Return([]db.ScheduledTransfer{scheduled}, nil)
+			storeMock.
+				On("GetAccount", mock.Anything, scheduled.FromAccountID).
+				Return(db.Account{ID: scheduled.FromAccountID, ApprovalThreshold: tc.threshold}, nil)
+			storeMock.
+				On("TransferTx", mock.Anything, mock.MatchedBy(func(arg db.TransferTxParams) bool {
+					return arg.FromAccountID == scheduled.FromAccountID &&
+						arg.ToAccountID == scheduled.ToAccountID &&
+						arg.Amount == scheduled.Amount &&
+						arg.Idempotency.Username == scheduled.Owner &&
+						arg.Idempotency.Key == fmt.Sprintf("scheduled_transfer:%d:%d", scheduled.ID, scheduled.NextRunAt.Unix())
+				})).
+				Return(db.TransferTxResult{Transfer: db.Transfer{ID: 99}}, tc.transferErr).
+				Maybe()
+			storeMock.
+				On("RecordScheduledRunTx", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					arg := args.Get(1).(db.RecordScheduledRunTxParams)
+					require.Equal(t, scheduled.ID, arg.Execution.ScheduledTransferID)
+					require.Equal(t, scheduled.ID, arg.Run.ID)
+					tc.checkRecord(t, scheduled, arg)
+				}).
+				Return(db.ScheduledTransferExecution{}, nil)
+
+			s := New(storeMock, Config{MaxAttempts: 3, RetryDelay: time.Minute})
+			s.now = func() time.Time { return now }
+
+			n, err := s.RunOnce(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+		})
+	}
+}
+
+func TestRunOnceRisk(t *testing.T) {
+	now := at("2023-04-01 09:00")
+
+	scheduled := db.ScheduledTransfer{
+		ID:            1,
+		Owner:         "alice",
+		FromAccountID: 10,
+		ToAccountID:   20,
+		Amount:        150000,
+		Currency:      "USD",
+		Schedule:      "0 9 1 * *",
+		StartAt:       at("2023-01-01 00:00"),
+		NextRunAt:     at("2023-04-01 09:00"),
+		Status:        db.ScheduledTransferActive,
+	}
+	key := db.GetIdempotencyKeyParams{
+		Username: scheduled.Owner,
+		Key:      fmt.Sprintf("scheduled_transfer:%d:%d", scheduled.ID, scheduled.NextRunAt.Unix()),
+	}
+
+	testCases := []struct {
+		name        string
+		denyAbove   string
+		replay      bool
+		checkRecord func(t *testing.T, arg db.RecordScheduledRunTxParams)
+	}{
+		{
+			name:      "Denied",
+			denyAbove: "1000.00",
+			checkRecord: func(t *testing.T, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionFailed, arg.Execution.Status)
+				require.Nil(t, arg.Execution.TransferID)
+				require.Contains(t, *arg.Execution.Error, errRiskDenied.Error())
+				require.Equal(t, at("2023-05-01 09:00"), arg.Run.NextRunAt)
+			},
+		},
+		{
+			name:      "Allowed",
+			denyAbove: "10000.00",
+			checkRecord: func(t *testing.T, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionSucceeded, arg.Execution.Status)
+			},
+		},
+		{
+			// the first attempt was screened; its retry only replays it
+			name:      "Replay",
+			denyAbove: "1000.00",
+			replay:    true,
+			checkRecord: func(t *testing.T, arg db.RecordScheduledRunTxParams) {
+				require.Equal(t, db.ExecutionSucceeded, arg.Execution.Status)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := mocks.NewStore(t)
+			storeMock.
+				On("ClaimDueScheduledTransfers", mock.Anything, mock.Anything).
+				Return([]db.ScheduledTransfer{scheduled}, nil)
+			storeMock.
+				On("GetAccount", mock.Anything, scheduled.FromAccountID).
+				Return(db.Account{ID: scheduled.FromAccountID}, nil)
+			if tc.replay {
+				storeMock.
+					On("GetIdempotencyKey", mock.Anything, key).
+					Return(db.IdempotencyKey{Username: key.Username, Key: key.Key}, nil)
+			} else {
+				storeMock.
+					On("GetIdempotencyKey", mock.Anything, key).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				storeMock.
+					On("GetAccount", mock.Anything, scheduled.ToAccountID).
+					Return(db.Account{ID: scheduled.ToAccountID, Owner: "bob"}, nil)
+				storeMock.
+					On("CreateRiskDecision", mock.Anything, mock.MatchedBy(func(arg db.CreateRiskDecisionParams) bool {
+						return arg.Username == scheduled.Owner && arg.Amount == scheduled.Amount
+					})).
+					Return(db.RiskDecision{ID: 5}, nil)
+			}
+			storeMock.
+				On("TransferTx", mock.Anything, mock.MatchedBy(func(arg db.TransferTxParams) bool {
+					if tc.replay {
+						return arg.RiskDecisionID == nil
+					}
+					return arg.RiskDecisionID != nil && *arg.RiskDecisionID == 5
+				})).
+				Return(db.TransferTxResult{Transfer: db.Transfer{ID: 99}}, nil).
+				Maybe()
+			storeMock.
+				On("RecordScheduledRunTx", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					tc.checkRecord(t, args.Get(1).(db.RecordScheduledRunTxParams))
+				}).
+				Return(db.ScheduledTransferExecution{}, nil)
+
+			engine, err := risk.NewEngine(storeMock, risk.Config{
+				AmountThresholds: []risk.AmountThresholdConfig{
+					{Currency: "USD", DenyAbove: tc.denyAbove},
+				},
+			})
+			require.NoError(t, err)
+
+			s := New(storeMock, Config{MaxAttempts: 3, RetryDelay: time.Minute, Risk: engine})
+			s.now = func() time.Time { return now }
+
+			n, err := s.RunOnce(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+		})
+	}
+}
+
+func TestRunOnceClaimError(t *testing.T) {
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("ClaimDueScheduledTransfers", mock.Anything, mock.Anything).
+		Return(nil, sql.ErrConnDone)
+
+	_, err := New(storeMock, Config{}).RunOnce(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestTransferParamsIdempotencyKey(t *testing.T) {
+	scheduled := db.ScheduledTransfer{ID: 1, Owner: "alice", FromAccountID: 10, ToAccountID: 20, Amount: 100, NextRunAt: at("2023-04-01 09:00")}
+
+	first := transferParams(scheduled)
+	require.Equal(t, first, transferParams(scheduled))
+
+	// every occurrence is a transfer of its own
+	scheduled.NextRunAt = at("2023-05-01 09:00")
+	next := transferParams(scheduled)
+	require.NotEqual(t, first.Idempotency.Key, next.Idempotency.Key)
+	require.Equal(t, first.Idempotency.RequestHash, next.Idempotency.RequestHash)
+}
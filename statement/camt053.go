@@ -0,0 +1,180 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// Camt053Writer renders an ISO 20022 BankToCustomerStatement (camt.053.001.02).
+type Camt053Writer struct{}
+
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	Statement camtBkToCstmr `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmr struct {
+	GroupHeader camtGroupHeader `xml:"GrpHdr"`
+	Statement   camtStatement   `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	ID        string        `xml:"Id"`
+	CreatedAt string        `xml:"CreDtTm"`
+	FromTo    camtFromTo    `xml:"FrToDt"`
+	Account   camtAccount   `xml:"Acct"`
+	Balances  []camtBalance `xml:"Bal"`
+	Entries   []camtEntry   `xml:"Ntry"`
+}
+
+type camtFromTo struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+	Owner    string `xml:"Ownr>Nm"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type        string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Date        string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	Reference   string        `xml:"NtryRef"`
+	Amount      camtAmount    `xml:"Amt"`
+	CreditDebit string        `xml:"CdtDbtInd"`
+	Status      string        `xml:"Sts"`
+	BookingDate string        `xml:"BookgDt>DtTm"`
+	ValueDate   string        `xml:"ValDt>DtTm"`
+	ServicerRef string        `xml:"AcctSvcrRef"`
+	BankTxCode  string        `xml:"BkTxCd>Prtry>Cd"`
+	Details     camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTxDetails struct {
+	Refs           *camtRefs `xml:"Refs"`
+	AdditionalInfo string    `xml:"AddtlTxInf"`
+}
+
+type camtRefs struct {
+	TransactionID string `xml:"TxId"`
+}
+
+func (Camt053Writer) ContentType() string {
+	return "application/xml"
+}
+
+func (Camt053Writer) FileExtension() string {
+	return "xml"
+}
+
+func (Camt053Writer) Write(w io.Writer, statement Statement) error {
+	currency := statement.Account.Currency
+	statementID := fmt.Sprintf("%d-%s", statement.Account.ID, statement.From.UTC().Format("20060102150405"))
+
+	entries := make([]camtEntry, len(statement.Entries))
+	for i, entry := range statement.Entries {
+		id := strconv.FormatInt(entry.ID, 10)
+		bankTxCode := "CASH"
+		details := camtTxDetails{AdditionalInfo: describe(entry)}
+		if entry.TransferID != nil {
+			bankTxCode = "TRANSFER"
+			details.Refs = &camtRefs{TransactionID: strconv.FormatInt(*entry.TransferID, 10)}
+		}
+
+		entries[i] = camtEntry{
+			Reference:   id,
+			Amount:      camtAmount{Currency: currency, Value: formatAmount(abs(entry.Amount), currency)},
+			CreditDebit: creditDebit(entry.Amount),
+			Status:      "BOOK",
+			BookingDate: formatCamtTime(entry.CreatedAt),
+			ValueDate:   formatCamtTime(entry.CreatedAt),
+			ServicerRef: id,
+			BankTxCode:  bankTxCode,
+			Details:     details,
+		}
+	}
+
+	doc := camtDocument{
+		Namespace: camt053Namespace,
+		Statement: camtBkToCstmr{
+			GroupHeader: camtGroupHeader{
+				MessageID: statementID,
+				CreatedAt: formatCamtTime(statement.GeneratedAt),
+			},
+			Statement: camtStatement{
+				ID:        statementID,
+				CreatedAt: formatCamtTime(statement.GeneratedAt),
+				FromTo: camtFromTo{
+					From: formatCamtTime(statement.From),
+					To:   formatCamtTime(statement.To),
+				},
+				Account: camtAccount{
+					ID:       strconv.FormatInt(statement.Account.ID, 10),
+					Currency: currency,
+					Owner:    statement.Account.Owner,
+				},
+				Balances: []camtBalance{
+					newCamtBalance("OPBD", statement.OpeningBalance, currency, statement.From),
+					newCamtBalance("CLBD", statement.ClosingBalance, currency, statement.To),
+				},
+				Entries: entries,
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newCamtBalance(balanceType string, amount int64, currency string, date time.Time) camtBalance {
+	return camtBalance{
+		Type:        balanceType,
+		Amount:      camtAmount{Currency: currency, Value: formatAmount(abs(amount), currency)},
+		CreditDebit: creditDebit(amount),
+		Date:        formatCamtTime(date),
+	}
+}
+
+// creditDebit returns the camt indicator for amount. A zero amount is
+// reported as a credit.
+func creditDebit(amount int64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func formatCamtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
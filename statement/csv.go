@@ -0,0 +1,63 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVWriter renders one row per entry, with the balance after each entry.
+type CSVWriter struct{}
+
+var csvHeader = []string{
+	"booking_date",
+	"entry_id",
+	"transfer_id",
+	"counterparty_account_id",
+	"description",
+	"amount",
+	"currency",
+	"balance",
+}
+
+func (CSVWriter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (CSVWriter) FileExtension() string {
+	return "csv"
+}
+
+func (CSVWriter) Write(w io.Writer, statement Statement) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range statement.Entries {
+		record := []string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(entry.ID, 10),
+			formatOptionalID(entry.TransferID),
+			formatOptionalID(entry.CounterpartyAccountID),
+			describe(entry),
+			formatAmount(entry.Amount, statement.Account.Currency),
+			statement.Account.Currency,
+			formatAmount(entry.RunningBalance, statement.Account.Currency),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatOptionalID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
@@ -0,0 +1,148 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxTimeLayout = "20060102150405.000[0:GMT]"
+	ofxBankID     = "SIMPLEBANK"
+)
+
+// OFXWriter renders an OFX 2.2 bank statement response.
+type OFXWriter struct{}
+
+type ofxDocument struct {
+	XMLName xml.Name     `xml:"OFX"`
+	SignOn  ofxSignOn    `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStmtTrnRs `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStmtTrnRs struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs ofxStmtRs `xml:"STMTRS"`
+}
+
+type ofxStmtRs struct {
+	CurDef       string          `xml:"CURDEF"`
+	BankAcctFrom ofxBankAccount  `xml:"BANKACCTFROM"`
+	BankTranList ofxBankTranList `xml:"BANKTRANLIST"`
+	LedgerBal    ofxBalance      `xml:"LEDGERBAL"`
+}
+
+type ofxBankAccount struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxBankTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+func (OFXWriter) ContentType() string {
+	return "application/x-ofx"
+}
+
+func (OFXWriter) FileExtension() string {
+	return "ofx"
+}
+
+func (OFXWriter) Write(w io.Writer, statement Statement) error {
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+
+	transactions := make([]ofxTransaction, len(statement.Entries))
+	for i, entry := range statement.Entries {
+		trnType := "CREDIT"
+		if entry.Amount < 0 {
+			trnType = "DEBIT"
+		}
+		if entry.TransferID != nil {
+			trnType = "XFER"
+		}
+
+		transactions[i] = ofxTransaction{
+			TrnType:  trnType,
+			DTPosted: formatOFXTime(entry.CreatedAt),
+			TrnAmt:   formatAmount(entry.Amount, statement.Account.Currency),
+			FITID:    strconv.FormatInt(entry.ID, 10),
+			Name:     describe(entry),
+		}
+	}
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ok,
+			DTServer: formatOFXTime(statement.GeneratedAt),
+			Language: "ENG",
+		},
+		Bank: ofxStmtTrnRs{
+			TrnUID: "0",
+			Status: ok,
+			StmtRs: ofxStmtRs{
+				CurDef: statement.Account.Currency,
+				BankAcctFrom: ofxBankAccount{
+					BankID:   ofxBankID,
+					AcctID:   strconv.FormatInt(statement.Account.ID, 10),
+					AcctType: "CHECKING",
+				},
+				BankTranList: ofxBankTranList{
+					DTStart:      formatOFXTime(statement.From),
+					DTEnd:        formatOFXTime(statement.To),
+					Transactions: transactions,
+				},
+				LedgerBal: ofxBalance{
+					BalAmt: formatAmount(statement.ClosingBalance, statement.Account.Currency),
+					DTAsOf: formatOFXTime(statement.To),
+				},
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatOFXTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeLayout)
+}
@@ -0,0 +1,78 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	db "simple_bank/db/models"
+	"simple_bank/util"
+	"time"
+)
+
+// Supported export formats.
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported statement format")
+
+// Statement is the ledger history of one account over [From, To).
+type Statement struct {
+	Account        db.Account
+	From           time.Time
+	To             time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	Entries        []db.ListStatementEntriesRow
+	GeneratedAt    time.Time
+}
+
+// Writer renders a statement in a specific file format.
+type Writer interface {
+	// ContentType returns the MIME type of the rendered document.
+	ContentType() string
+	// FileExtension returns the extension used for downloads, without the dot.
+	FileExtension() string
+	Write(w io.Writer, statement Statement) error
+}
+
+// NewWriter returns the writer for the given format.
+func NewWriter(format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return CSVWriter{}, nil
+	case FormatOFX:
+		return OFXWriter{}, nil
+	case FormatCamt053:
+		return Camt053Writer{}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+func describe(entry db.ListStatementEntriesRow) string {
+	switch {
+	case entry.Description != "":
+		return entry.Description
+	case entry.CounterpartyAccountID != nil && entry.Amount < 0:
+		return fmt.Sprintf("Transfer to account %d", *entry.CounterpartyAccountID)
+	case entry.CounterpartyAccountID != nil:
+		return fmt.Sprintf("Transfer from account %d", *entry.CounterpartyAccountID)
+	case entry.Amount < 0:
+		return "Debit"
+	default:
+		return "Credit"
+	}
+}
+
+func formatAmount(amount int64, currency string) string {
+	return util.FormatAmount(amount, currency)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	db "simple_bank/db/models"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func fixedStatement() Statement {
+	from := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	transferID := int64(31)
+	counterpartyID := int64(8)
+	cashTime := from.Add(26 * time.Hour)
+
+	return Statement{
+		Account: db.Account{
+			ID:       7,
+			Owner:    "alice",
+			Balance:  120,
+			Currency: util.USD,
+		},
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		ClosingBalance: 120,
+		GeneratedAt:    time.Date(2022, time.April, 1, 9, 30, 0, 0, time.UTC),
+		Entries: []db.ListStatementEntriesRow{
+			{
+				ID:             101,
+				AccountID:      7,
+				Amount:         50,
+				CreatedAt:      cashTime,
+				RunningBalance: 150,
+			},
+			{
+				ID:                    102,
+				AccountID:             7,
+				Amount:                -30,
+				TransferID:            &transferID,
+				CreatedAt:             from.Add(50 * time.Hour),
+				CounterpartyAccountID: &counterpartyID,
+				RunningBalance:        120,
+			},
+		},
+	}
+}
+
+func TestWriters(t *testing.T) {
+	testCases := []struct {
+		format string
+		golden string
+	}{
+		{format: FormatCSV, golden: "statement.csv"},
+		{format: FormatOFX, golden: "statement.ofx"},
+		{format: FormatCamt053, golden: "statement.camt053.xml"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.format, func(t *testing.T) {
+			writer, err := NewWriter(tc.format)
+			require.NoError(t, err)
+			require.NotEmpty(t, writer.ContentType())
+			require.NotEmpty(t, writer.FileExtension())
+
+			var buf bytes.Buffer
+			err = writer.Write(&buf, fixedStatement())
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				err = os.WriteFile(path, buf.Bytes(), 0644)
+				require.NoError(t, err)
+			}
+
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
+
+func TestNewWriterUnsupportedFormat(t *testing.T) {
+	writer, err := NewWriter("pdf")
+	require.True(t, errors.Is(err, ErrUnsupportedFormat))
+	require.Nil(t, writer)
+}
+
+func TestDescribe(t *testing.T) {
+	counterpartyID := int64(8)
+	entry := db.ListStatementEntriesRow{Amount: -30, CounterpartyAccountID: &counterpartyID}
+	require.Equal(t, "Transfer to account 8", describe(entry))
+
+	entry.Description = "March rent"
+	require.Equal(t, "March rent", describe(entry))
+}
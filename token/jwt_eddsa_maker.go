@@ -0,0 +1,79 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type JWTEdDSAMaker struct {
+	keys *KeySet
+}
+
+func NewJWTEdDSAMaker(keys *KeySet) (Maker, error) {
+	if keys == nil {
+		return nil, errors.New("key set must not be nil")
+	}
+	return &JWTEdDSAMaker{keys}, nil
+}
+
+func (maker *JWTEdDSAMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	claims := CustomJWTClaims{
+		payload.ID,
+		payload.Username,
+		payload.Role,
+		payload.Type,
+		jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+		},
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	jwtToken.Header["kid"] = maker.keys.activeKeyID
+	token, err := jwtToken.SignedString(maker.keys.privateKey)
+	return token, payload, err
+}
+
+func (maker *JWTEdDSAMaker) VerifyToken(tokenString string, tokenType TokenType) (*Payload, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, ErrInvalidToken
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		publicKey, ok := maker.keys.PublicKey(keyID)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		validErr, ok := err.(*jwt.ValidationError)
+		if ok {
+			switch validErr.Errors {
+			case jwt.ValidationErrorExpired:
+				return nil, ErrExpiredToken
+			}
+		}
+		return nil, ErrInvalidToken
+	}
+
+	if claims, ok := token.Claims.(*CustomJWTClaims); ok && token.Valid {
+		return checkType(&Payload{
+			ID:        claims.ID,
+			Username:  claims.Username,
+			Role:      claims.Role,
+			Type:      claims.Type,
+			IssuedAt:  claims.IssuedAt.Local(),
+			ExpiresAt: claims.ExpiresAt.Local(),
+		}, tokenType)
+	} else {
+		return nil, ErrInvalidToken
+	}
+}
@@ -10,47 +10,67 @@ import (
 
 const minSecretKeySize = 32
 
+// JWTMaker signs new tokens with the primary secret and verifies them with any
+// secret of the keyring.
 type JWTMaker struct {
-	secretKey string
+	secretKeys []string
 }
 
 type CustomJWTClaims struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	Type     TokenType `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < minSecretKeySize {
-		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+func NewJWTMaker(secretKey string, previousKeys ...string) (Maker, error) {
+	secretKeys := append([]string{secretKey}, previousKeys...)
+	for _, key := range secretKeys {
+		if len(key) < minSecretKeySize {
+			return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+		}
 	}
-	return &JWTMaker{secretKey}, nil
+	return &JWTMaker{secretKeys}, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	tokenID, err := uuid.NewRandom()
+func (maker *JWTMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	claims := CustomJWTClaims{
-		tokenID,
-		username,
+		payload.ID,
+		payload.Username,
+		payload.Role,
+		payload.Type,
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
 		},
 	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return jwtToken.SignedString([]byte(maker.secretKey))
+	token, err := jwtToken.SignedString([]byte(maker.secretKeys[0]))
+	return token, payload, err
+}
+
+func (maker *JWTMaker) VerifyToken(tokenString string, tokenType TokenType) (*Payload, error) {
+	for _, secretKey := range maker.secretKeys {
+		payload, err := maker.verifyWithKey(tokenString, secretKey, tokenType)
+		if err == nil || err == ErrExpiredToken {
+			return payload, err
+		}
+	}
+	return nil, ErrInvalidToken
 }
 
-func (maker *JWTMaker) VerifyToken(tokenString string) (*Payload, error) {
+func (maker *JWTMaker) verifyWithKey(tokenString string, secretKey string, tokenType TokenType) (*Payload, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &CustomJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(maker.secretKey), nil
+		return []byte(secretKey), nil
 	})
 	if err != nil {
 		validErr, ok := err.(*jwt.ValidationError)
@@ -64,13 +84,15 @@ func (maker *JWTMaker) VerifyToken(tokenString string) (*Payload, error) {
 	}
 
 	if claims, ok := token.Claims.(*CustomJWTClaims); ok && token.Valid {
-		return &Payload{
+		return checkType(&Payload{
 			ID:        claims.ID,
 			Username:  claims.Username,
+			Role:      claims.Role,
+			Type:      claims.Type,
 			IssuedAt:  claims.IssuedAt.Local(),
 			ExpiresAt: claims.ExpiresAt.Local(),
-		}, nil
+		}, tokenType)
 	} else {
-		return nil, err
+		return nil, ErrInvalidToken
 	}
 }
@@ -14,19 +14,22 @@ func TestJWTMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiresAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, TokenTypeAccessToken, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
 	require.NoError(t, err)
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiresAt, payload.ExpiresAt, time.Second)
 }
@@ -36,13 +39,15 @@ func TestExpiredJWTToken(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := -time.Minute
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, TokenTypeAccessToken, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
@@ -56,8 +61,70 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	maker, err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldKey := util.RandomString(32)
+	newKey := util.RandomString(32)
+
+	oldMaker, err := NewJWTMaker(oldKey)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	rotatedMaker, err := NewJWTMaker(newKey, oldKey)
+	require.NoError(t, err)
+
+	newToken, _, err := rotatedMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// tokens issued before the rotation are still accepted
+	payload, err := rotatedMaker.VerifyToken(oldToken, TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.NotNil(t, payload)
+
+	// tokens issued after the rotation use the new primary key
+	payload, err = rotatedMaker.VerifyToken(newToken, TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.NotNil(t, payload)
+
+	payload, err = oldMaker.VerifyToken(newToken, TokenTypeAccessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	// once the old key is retired its tokens are rejected
+	retiredMaker, err := NewJWTMaker(newKey)
+	require.NoError(t, err)
+
+	payload, err = retiredMaker.VerifyToken(oldToken, TokenTypeAccessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestExpiredJWTTokenWithPreviousKey(t *testing.T) {
+	oldKey := util.RandomString(32)
+
+	oldMaker, err := NewJWTMaker(oldKey)
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, -time.Minute)
+	require.NoError(t, err)
+
+	rotatedMaker, err := NewJWTMaker(util.RandomString(32), oldKey)
+	require.NoError(t, err)
+
+	payload, err := rotatedMaker.VerifyToken(token, TokenTypeAccessToken)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTPreviousKeySize(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32), util.RandomString(8))
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
@@ -0,0 +1,104 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeySet holds the Ed25519 keys used by the asymmetric makers. Every key can
+// verify tokens, but only the active one signs new tokens, which lets keys be
+// rotated without invalidating tokens that are still in flight.
+type KeySet struct {
+	activeKeyID string
+	privateKey  ed25519.PrivateKey
+	publicKeys  map[string]ed25519.PublicKey
+	keyIDs      []string
+}
+
+func NewKeySet(activeKeyID string, privateKeys map[string]ed25519.PrivateKey) (*KeySet, error) {
+	active, ok := privateKeys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in key set", activeKeyID)
+	}
+
+	keySet := &KeySet{
+		activeKeyID: activeKeyID,
+		privateKey:  active,
+		publicKeys:  make(map[string]ed25519.PublicKey),
+	}
+	for keyID, privateKey := range privateKeys {
+		if len(privateKey) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("invalid key size for key %q", keyID)
+		}
+		keySet.publicKeys[keyID] = privateKey.Public().(ed25519.PublicKey)
+		keySet.keyIDs = append(keySet.keyIDs, keyID)
+	}
+	sort.Strings(keySet.keyIDs)
+	return keySet, nil
+}
+
+// ParseKeySet builds a KeySet from a comma separated list of
+// "<key id>:<base64 encoded 32 byte seed>" pairs.
+func ParseKeySet(activeKeyID string, spec string) (*KeySet, error) {
+	privateKeys := make(map[string]ed25519.PrivateKey)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		fields := strings.SplitN(pair, ":", 2)
+		if len(fields) != 2 || fields[0] == "" {
+			return nil, errors.New("invalid key format: must be <key id>:<base64 seed>")
+		}
+
+		seed, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode key %q: %w", fields[0], err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid seed size for key %q: must be exactly %d bytes", fields[0], ed25519.SeedSize)
+		}
+
+		privateKeys[fields[0]] = ed25519.NewKeyFromSeed(seed)
+	}
+	return NewKeySet(activeKeyID, privateKeys)
+}
+
+func (keySet *KeySet) PublicKey(keyID string) (ed25519.PublicKey, bool) {
+	publicKey, ok := keySet.publicKeys[keyID]
+	return publicKey, ok
+}
+
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	X         string `json:"x"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS exports the public half of every key in the set as described in RFC 8037.
+func (keySet *KeySet) JWKS() JSONWebKeySet {
+	jwks := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, keyID := range keySet.keyIDs {
+		jwks.Keys = append(jwks.Keys, JSONWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			KeyID:     keyID,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			X:         base64.RawURLEncoding.EncodeToString(keySet.publicKeys[keyID]),
+		})
+	}
+	return jwks
+}
@@ -0,0 +1,77 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomKeySet(t *testing.T, activeKeyID string, keyIDs ...string) *KeySet {
+	privateKeys := make(map[string]ed25519.PrivateKey)
+	for _, keyID := range append(keyIDs, activeKeyID) {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		privateKeys[keyID] = privateKey
+	}
+
+	keySet, err := NewKeySet(activeKeyID, privateKeys)
+	require.NoError(t, err)
+	return keySet
+}
+
+func TestParseKeySet(t *testing.T) {
+	seed1 := make([]byte, ed25519.SeedSize)
+	seed2 := make([]byte, ed25519.SeedSize)
+	_, err := rand.Read(seed1)
+	require.NoError(t, err)
+	_, err = rand.Read(seed2)
+	require.NoError(t, err)
+
+	spec := fmt.Sprintf("k1:%s, k2:%s",
+		base64.StdEncoding.EncodeToString(seed1),
+		base64.StdEncoding.EncodeToString(seed2),
+	)
+
+	keySet, err := ParseKeySet("k2", spec)
+	require.NoError(t, err)
+	require.Equal(t, "k2", keySet.activeKeyID)
+
+	publicKey, ok := keySet.PublicKey("k1")
+	require.True(t, ok)
+	require.Equal(t, ed25519.NewKeyFromSeed(seed1).Public(), publicKey)
+
+	_, err = ParseKeySet("k3", spec)
+	require.Error(t, err)
+
+	_, err = ParseKeySet("k1", "k1:"+base64.StdEncoding.EncodeToString(seed1[:10]))
+	require.Error(t, err)
+
+	_, err = ParseKeySet("k1", "invalid")
+	require.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	keySet := randomKeySet(t, "k2", "k1")
+
+	jwks := keySet.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, "k1", jwks.Keys[0].KeyID)
+	require.Equal(t, "k2", jwks.Keys[1].KeyID)
+
+	for _, key := range jwks.Keys {
+		require.Equal(t, "OKP", key.KeyType)
+		require.Equal(t, "Ed25519", key.Curve)
+		require.Equal(t, "EdDSA", key.Algorithm)
+
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		require.NoError(t, err)
+
+		publicKey, ok := keySet.PublicKey(key.KeyID)
+		require.True(t, ok)
+		require.Equal(t, []byte(publicKey), x)
+	}
+}
@@ -10,16 +10,57 @@ import (
 var (
 	ErrInvalidToken = errors.New("token is invalid")
 	ErrExpiredToken = errors.New("token is expired")
+	ErrRevokedToken = errors.New("token has been revoked")
+)
+
+// TokenType tells access tokens, which authenticate requests, apart from
+// refresh tokens, which only renew access tokens.
+type TokenType string
+
+const (
+	TokenTypeAccessToken  TokenType = "access"
+	TokenTypeRefreshToken TokenType = "refresh"
 )
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
-	VerifyToken(token string) (*Payload, error)
+	CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
+	// VerifyToken fails with ErrInvalidToken when the token is not of
+	// tokenType.
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
 }
 
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Type      TokenType `json:"token_type"`
 	IssuedAt  time.Time `json:"iat"`
 	ExpiresAt time.Time `json:"exp"`
 }
+
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (*Payload, error) {
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  username,
+		Role:      role,
+		Type:      tokenType,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(duration),
+	}
+	return payload, nil
+}
+
+// checkType returns payload if it is of tokenType, for the VerifyToken of
+// every maker.
+func checkType(payload *Payload, tokenType TokenType) (*Payload, error) {
+	if payload.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
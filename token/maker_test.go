@@ -0,0 +1,46 @@
+package token
+
+import (
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenType(t *testing.T) {
+	makers := map[string]func(t *testing.T) (Maker, error){
+		"JWT": func(t *testing.T) (Maker, error) {
+			return NewJWTMaker(util.RandomString(32))
+		},
+		"JWTEdDSA": func(t *testing.T) (Maker, error) {
+			return NewJWTEdDSAMaker(randomKeySet(t, "k1"))
+		},
+		"Paseto": func(t *testing.T) (Maker, error) {
+			return NewPasetoMaker(util.RandomString(32))
+		},
+		"PasetoPublic": func(t *testing.T) (Maker, error) {
+			return NewPasetoPublicMaker(randomKeySet(t, "k1"))
+		},
+	}
+
+	for name, newMaker := range makers {
+		newMaker := newMaker
+		t.Run(name, func(t *testing.T) {
+			maker, err := newMaker(t)
+			require.NoError(t, err)
+
+			token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeRefreshToken, time.Minute)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token, TokenTypeRefreshToken)
+			require.NoError(t, err)
+			require.Equal(t, TokenTypeRefreshToken, payload.Type)
+
+			// a refresh token is not accepted where an access token is expected
+			payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
+			require.ErrorIs(t, err, ErrInvalidToken)
+			require.Nil(t, payload)
+		})
+	}
+}
@@ -9,51 +9,64 @@ import (
 	"golang.org/x/crypto/chacha20poly1305"
 )
 
+// PasetoMaker encrypts new tokens with the primary key and decrypts them with
+// any key of the keyring, so tokens issued before a rotation stay valid until
+// the previous key is removed.
 type PasetoMaker struct {
-	paseto       *paseto.V2
-	symmetricKey []byte
+	paseto        *paseto.V2
+	symmetricKeys [][]byte
 }
 
 type CustomPasetoClaims struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	Type     TokenType `json:"token_type"`
 	paseto.JSONToken
 }
 
-func NewPasetoMaker(symmetricKey string) (Maker, error) {
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+func NewPasetoMaker(symmetricKey string, previousKeys ...string) (Maker, error) {
+	maker := &PasetoMaker{
+		paseto: paseto.NewV2(),
 	}
 
-	maker := &PasetoMaker{
-		paseto:       paseto.NewV2(),
-		symmetricKey: []byte(symmetricKey),
+	for _, key := range append([]string{symmetricKey}, previousKeys...) {
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+		}
+		maker.symmetricKeys = append(maker.symmetricKeys, []byte(key))
 	}
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	tokenID, err := uuid.NewRandom()
+func (maker *PasetoMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	now := time.Now()
-	exp := now.Add(duration)
 
 	jsonToken := paseto.JSONToken{
-		IssuedAt:   now,
-		Expiration: exp,
+		IssuedAt:   payload.IssuedAt,
+		Expiration: payload.ExpiresAt,
 	}
-	jsonToken.Set("id", tokenID.String())
-	jsonToken.Set("username", username)
+	jsonToken.Set("id", payload.ID.String())
+	jsonToken.Set("username", payload.Username)
+	jsonToken.Set("role", payload.Role)
+	jsonToken.Set("token_type", string(payload.Type))
 
-	return maker.paseto.Encrypt(maker.symmetricKey, jsonToken, nil)
+	token, err := maker.paseto.Encrypt(maker.symmetricKeys[0], jsonToken, nil)
+	return token, payload, err
 }
 
-func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+func (maker *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
 	var payload paseto.JSONToken
 
-	err := maker.paseto.Decrypt(token, maker.symmetricKey, &payload, nil)
+	err := ErrInvalidToken
+	for _, key := range maker.symmetricKeys {
+		if err = maker.paseto.Decrypt(token, key, &payload, nil); err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
@@ -66,10 +79,12 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
-	return &Payload{
+	return checkType(&Payload{
 		ID:        uuid,
 		Username:  payload.Get("username"),
+		Role:      payload.Get("role"),
+		Type:      TokenType(payload.Get("token_type")),
 		IssuedAt:  payload.IssuedAt,
 		ExpiresAt: payload.Expiration,
-	}, nil
+	}, tokenType)
 }
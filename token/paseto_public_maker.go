@@ -0,0 +1,86 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/o1egl/paseto"
+)
+
+type PasetoPublicMaker struct {
+	paseto *paseto.V2
+	keys   *KeySet
+}
+
+type pasetoKeyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+func NewPasetoPublicMaker(keys *KeySet) (Maker, error) {
+	if keys == nil {
+		return nil, errors.New("key set must not be nil")
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto: paseto.NewV2(),
+		keys:   keys,
+	}
+	return maker, nil
+}
+
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	jsonToken := paseto.JSONToken{
+		IssuedAt:   payload.IssuedAt,
+		Expiration: payload.ExpiresAt,
+	}
+	jsonToken.Set("id", payload.ID.String())
+	jsonToken.Set("username", payload.Username)
+	jsonToken.Set("role", payload.Role)
+	jsonToken.Set("token_type", string(payload.Type))
+
+	footer := pasetoKeyFooter{KeyID: maker.keys.activeKeyID}
+	token, err := maker.paseto.Sign(maker.keys.privateKey, jsonToken, footer)
+	return token, payload, err
+}
+
+func (maker *PasetoPublicMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	var footer pasetoKeyFooter
+	err := paseto.ParseFooter(token, &footer)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	publicKey, ok := maker.keys.PublicKey(footer.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	var payload paseto.JSONToken
+	err = maker.paseto.Verify(token, publicKey, &payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().After(payload.Expiration) {
+		return nil, ErrExpiredToken
+	}
+
+	uuid, err := uuid.Parse(payload.Get("id"))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return checkType(&Payload{
+		ID:        uuid,
+		Username:  payload.Get("username"),
+		Role:      payload.Get("role"),
+		Type:      TokenType(payload.Get("token_type")),
+		IssuedAt:  payload.IssuedAt,
+		ExpiresAt: payload.Expiration,
+	}, tokenType)
+}
@@ -0,0 +1,100 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomKeySet(t, "k1"))
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiresAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, TokenTypeAccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiresAt, payload.ExpiresAt, time.Second)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomKeySet(t, "k1"))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicKeyRotation(t *testing.T) {
+	_, key1, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, key2, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	oldKeys, err := NewKeySet("k1", map[string]ed25519.PrivateKey{"k1": key1})
+	require.NoError(t, err)
+	oldMaker, err := NewPasetoPublicMaker(oldKeys)
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	rotatedKeys, err := NewKeySet("k2", map[string]ed25519.PrivateKey{"k1": key1, "k2": key2})
+	require.NoError(t, err)
+	rotatedMaker, err := NewPasetoPublicMaker(rotatedKeys)
+	require.NoError(t, err)
+
+	payload, err := rotatedMaker.VerifyToken(token, TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.NotNil(t, payload)
+
+	retiredKeys, err := NewKeySet("k2", map[string]ed25519.PrivateKey{"k2": key2})
+	require.NoError(t, err)
+	retiredMaker, err := NewPasetoPublicMaker(retiredKeys)
+	require.NoError(t, err)
+
+	payload, err = retiredMaker.VerifyToken(token, TokenTypeAccessToken)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidPasetoPublicTokenUnknownKey(t *testing.T) {
+	maker1, err := NewPasetoPublicMaker(randomKeySet(t, "k1"))
+	require.NoError(t, err)
+	maker2, err := NewPasetoPublicMaker(randomKeySet(t, "k1"))
+	require.NoError(t, err)
+
+	token, _, err := maker1.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker2.VerifyToken(token, TokenTypeAccessToken)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
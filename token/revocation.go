@@ -0,0 +1,108 @@
+package token
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	db "simple_bank/db/models"
+
+	"github.com/google/uuid"
+)
+
+const defaultSyncInterval = time.Minute
+
+// RevocationStore keeps track of tokens invalidated before their expiry.
+// Revocations are persisted in Postgres and mirrored in memory so that
+// lookups on every request do not hit the database. Other replicas pick
+// up new revocations on their next Sync.
+type RevocationStore struct {
+	store   db.Querier
+	mu      sync.RWMutex
+	revoked map[uuid.UUID]time.Time
+}
+
+func NewRevocationStore(store db.Querier) *RevocationStore {
+	return &RevocationStore{
+		store:   store,
+		revoked: make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *RevocationStore) Revoke(ctx context.Context, payload *Payload) error {
+	err := r.store.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.revoked[payload.ID] = payload.ExpiresAt
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RevocationStore) IsRevoked(id uuid.UUID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.revoked[id]
+	return ok
+}
+
+// Sync prunes expired revocations and reloads the active ones from the database.
+func (r *RevocationStore) Sync(ctx context.Context) error {
+	err := r.store.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	revokedTokens, err := r.store.ListActiveRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, expiresAt := range r.revoked {
+		if !expiresAt.After(now) {
+			delete(r.revoked, id)
+		}
+	}
+	for _, revokedToken := range revokedTokens {
+		r.revoked[revokedToken.ID] = revokedToken.ExpiresAt
+	}
+	return nil
+}
+
+// Run calls Sync immediately and then every interval until ctx is done.
+func (r *RevocationStore) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	if err := r.Sync(ctx); err != nil {
+		log.Println("cannot sync revoked tokens:", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Sync(ctx); err != nil {
+				log.Println("cannot sync revoked tokens:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	db "simple_bank/db/models"
+	"simple_bank/mocks"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeToken(t *testing.T) {
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("CreateRevokedToken", mock.Anything, db.CreateRevokedTokenParams{
+			ID:        payload.ID,
+			Username:  payload.Username,
+			ExpiresAt: payload.ExpiresAt,
+		}).
+		Return(nil)
+
+	revocations := NewRevocationStore(storeMock)
+	require.False(t, revocations.IsRevoked(payload.ID))
+
+	err = revocations.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revocations.IsRevoked(payload.ID))
+}
+
+func TestRevokeTokenError(t *testing.T) {
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("CreateRevokedToken", mock.Anything, mock.Anything).
+		Return(sql.ErrConnDone)
+
+	revocations := NewRevocationStore(storeMock)
+	err = revocations.Revoke(context.Background(), payload)
+	require.Error(t, err)
+	require.False(t, revocations.IsRevoked(payload.ID))
+}
+
+func TestSyncRevokedTokens(t *testing.T) {
+	expired, err := NewPayload(util.RandomOwner(), util.DepositorRole, TokenTypeAccessToken, -time.Minute)
+	require.NoError(t, err)
+
+	remote := db.RevokedToken{
+		ID:        uuid.New(),
+		Username:  util.RandomOwner(),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	storeMock := mocks.NewStore(t)
+	storeMock.
+		On("CreateRevokedToken", mock.Anything, mock.Anything).
+		Return(nil)
+	storeMock.
+		On("DeleteExpiredRevokedTokens", mock.Anything).
+		Return(nil)
+	storeMock.
+		On("ListActiveRevokedTokens", mock.Anything).
+		Return([]db.RevokedToken{remote}, nil)
+
+	revocations := NewRevocationStore(storeMock)
+	err = revocations.Revoke(context.Background(), expired)
+	require.NoError(t, err)
+	require.True(t, revocations.IsRevoked(expired.ID))
+
+	err = revocations.Sync(context.Background())
+	require.NoError(t, err)
+	require.False(t, revocations.IsRevoked(expired.ID))
+	require.True(t, revocations.IsRevoked(remote.ID))
+}
@@ -7,11 +7,31 @@ import (
 )
 
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBDriver                string        `mapstructure:"DB_DRIVER"`
+	DBSource                string        `mapstructure:"DB_SOURCE"`
+	ServerAddress           string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType               string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey       string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenPreviousKeys       []string      `mapstructure:"TOKEN_PREVIOUS_SYMMETRIC_KEYS"`
+	TokenSigningKeys        string        `mapstructure:"TOKEN_SIGNING_KEYS"`
+	TokenActiveKeyID        string        `mapstructure:"TOKEN_ACTIVE_KEY_ID"`
+	AccessTokenDuration     time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration    time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RevocationSyncInterval  time.Duration `mapstructure:"REVOCATION_SYNC_INTERVAL"`
+	CursorSecretKey         string        `mapstructure:"CURSOR_SECRET_KEY"`
+	EnabledCurrencies       []string      `mapstructure:"ENABLED_CURRENCIES"`
+	FXRatesFile             string        `mapstructure:"FX_RATES_FILE"`
+	FXSpread                string        `mapstructure:"FX_SPREAD"`
+	FXQuoteDuration         time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	InterestAccrualInterval time.Duration `mapstructure:"INTEREST_ACCRUAL_INTERVAL"`
+	InterestLookbackDays    int           `mapstructure:"INTEREST_LOOKBACK_DAYS"`
+	SchedulerPollInterval   time.Duration `mapstructure:"SCHEDULER_POLL_INTERVAL"`
+	SchedulerMaxAttempts    int           `mapstructure:"SCHEDULER_MAX_ATTEMPTS"`
+	SchedulerRetryDelay     time.Duration `mapstructure:"SCHEDULER_RETRY_DELAY"`
+	PendingTransferDuration time.Duration `mapstructure:"PENDING_TRANSFER_DURATION"`
+	ApprovalExpiryInterval  time.Duration `mapstructure:"PENDING_TRANSFER_EXPIRY_INTERVAL"`
+	RiskRulesFile           string        `mapstructure:"RISK_RULES_FILE"`
+	PaymentRequestDuration  time.Duration `mapstructure:"PAYMENT_REQUEST_DURATION"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
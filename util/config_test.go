@@ -0,0 +1,19 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("TOKEN_TYPE", "jwt_eddsa")
+	t.Setenv("TOKEN_SIGNING_KEYS", "k1:abc")
+	t.Setenv("TOKEN_ACTIVE_KEY_ID", "k1")
+
+	config, err := LoadConfig("..")
+	require.NoError(t, err)
+	require.Equal(t, "jwt_eddsa", config.TokenType)
+	require.Equal(t, "k1:abc", config.TokenSigningKeys)
+	require.Equal(t, "k1", config.TokenActiveKeyID)
+}
@@ -1,15 +1,149 @@
 package util
 
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
+	JPY = "JPY"
+	KWD = "KWD"
 )
 
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Currency describes an ISO 4217 currency. MinorUnits is the number of
+// decimals between the major unit and the amounts stored in the ledger, e.g.
+// 2 for USD cents, 0 for JPY and 3 for KWD fils.
+type Currency struct {
+	Code        string `json:"code"`
+	NumericCode string `json:"numeric_code"`
+	MinorUnits  int    `json:"minor_units"`
+	Symbol      string `json:"symbol"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// defaultCurrencies is used until the registry is loaded from the database.
+var defaultCurrencies = []Currency{
+	{Code: USD, NumericCode: "840", MinorUnits: 2, Symbol: "$", Enabled: true},
+	{Code: EUR, NumericCode: "978", MinorUnits: 2, Symbol: "€", Enabled: true},
+	{Code: CAD, NumericCode: "124", MinorUnits: 2, Symbol: "CA$", Enabled: true},
+	{Code: JPY, NumericCode: "392", MinorUnits: 0, Symbol: "¥"},
+	{Code: KWD, NumericCode: "414", MinorUnits: 3, Symbol: "KD"},
+}
+
+var currencies = struct {
+	sync.RWMutex
+	byCode map[string]Currency
+}{byCode: indexCurrencies(defaultCurrencies)}
+
+func indexCurrencies(list []Currency) map[string]Currency {
+	byCode := make(map[string]Currency, len(list))
+	for _, currency := range list {
+		byCode[currency.Code] = currency
+	}
+	return byCode
+}
+
+// SetCurrencies replaces the currency registry. When enabled is not empty,
+// only the listed codes are enabled, whatever the Enabled flag of list says.
+func SetCurrencies(list []Currency, enabled []string) error {
+	byCode := indexCurrencies(list)
+	if len(enabled) > 0 {
+		for code, currency := range byCode {
+			currency.Enabled = false
+			byCode[code] = currency
+		}
+		for _, code := range enabled {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			currency, ok := byCode[code]
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+			}
+			currency.Enabled = true
+			byCode[code] = currency
+		}
+	}
+
+	currencies.Lock()
+	currencies.byCode = byCode
+	currencies.Unlock()
+	return nil
+}
+
+// LookupCurrency returns the registered currency with the given code, enabled
+// or not.
+func LookupCurrency(code string) (Currency, bool) {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	currency, ok := currencies.byCode[code]
+	return currency, ok
+}
+
+// EnabledCurrencies returns the currencies accounts can be opened in, sorted
+// by code.
+func EnabledCurrencies() []Currency {
+	currencies.RLock()
+	defer currencies.RUnlock()
+
+	var enabled []Currency
+	for _, currency := range currencies.byCode {
+		if currency.Enabled {
+			enabled = append(enabled, currency)
+		}
+	}
+	sort.Slice(enabled, func(i, j int) bool { return enabled[i].Code < enabled[j].Code })
+	return enabled
+}
+
+// IsSupportedCurrency reports whether currency is registered. Accounts that
+// were opened before their currency was disabled keep working.
 func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
+	_, ok := LookupCurrency(currency)
+	return ok
+}
+
+// IsEnabledCurrency reports whether new accounts may be opened in currency.
+func IsEnabledCurrency(currency string) bool {
+	c, ok := LookupCurrency(currency)
+	return ok && c.Enabled
+}
+
+// MinorUnits returns the exponent of currency. Unknown currencies are assumed
+// to have two decimals.
+func MinorUnits(currency string) int {
+	if c, ok := LookupCurrency(currency); ok {
+		return c.MinorUnits
+	}
+	return 2
+}
+
+// FormatAmount renders an amount of minor units as a decimal number in the
+// major unit of currency, e.g. -1234 USD as "-12.34" and 1234 JPY as "1234".
+func FormatAmount(amount int64, currency string) string {
+	exponent := MinorUnits(currency)
+	if exponent == 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	digits := new(big.Int).Abs(big.NewInt(amount)).String()
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
 	}
-	return false
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
 }
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "12.34", FormatAmount(1234, USD))
+	require.Equal(t, "-12.34", FormatAmount(-1234, USD))
+	require.Equal(t, "0.05", FormatAmount(5, EUR))
+	require.Equal(t, "-0.05", FormatAmount(-5, EUR))
+	require.Equal(t, "0.00", FormatAmount(0, CAD))
+	require.Equal(t, "1234", FormatAmount(1234, JPY))
+	require.Equal(t, "1.234", FormatAmount(1234, KWD))
+	require.Equal(t, "0.007", FormatAmount(7, KWD))
+}
+
+func TestSetCurrencies(t *testing.T) {
+	defer SetCurrencies(defaultCurrencies, nil)
+
+	require.True(t, IsEnabledCurrency(USD))
+	require.False(t, IsEnabledCurrency(JPY))
+	require.False(t, IsEnabledCurrency("XXX"))
+
+	err := SetCurrencies(defaultCurrencies, []string{"jpy", USD})
+	require.NoError(t, err)
+	require.True(t, IsEnabledCurrency(JPY))
+	require.True(t, IsEnabledCurrency(USD))
+	require.False(t, IsEnabledCurrency(EUR))
+
+	// a disabled currency is still known, for the accounts already opened
+	require.True(t, IsSupportedCurrency(EUR))
+	require.False(t, IsSupportedCurrency("XXX"))
+
+	var codes []string
+	for _, currency := range EnabledCurrencies() {
+		codes = append(codes, currency.Code)
+	}
+	require.Equal(t, []string{JPY, USD}, codes)
+
+	jpy, ok := LookupCurrency(JPY)
+	require.True(t, ok)
+	require.Zero(t, jpy.MinorUnits)
+	require.Equal(t, "392", jpy.NumericCode)
+
+	err = SetCurrencies(defaultCurrencies, []string{"XXX"})
+	require.ErrorIs(t, err, ErrUnknownCurrency)
+}
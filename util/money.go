@@ -0,0 +1,187 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrAmountOverflow   = errors.New("amount overflows int64")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// Money is an amount of minor units of a currency, e.g. 1234 USD is 12.34 $.
+// Arithmetic is checked, so amounts never silently wrap around or mix
+// currencies.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount in the major unit of currency, such as
+// "12.34" for USD. It rejects more decimals than the currency has.
+func ParseMoney(s string, currency string) (Money, error) {
+	exponent := MinorUnits(currency)
+
+	value := strings.TrimSpace(s)
+	negative := strings.HasPrefix(value, "-")
+	if negative || strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	whole, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		whole, fraction = value[:i], value[i+1:]
+	}
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: %s has at most %d decimals", ErrInvalidAmount, currency, exponent)
+	}
+
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Money{}, fmt.Errorf("%w: %q", ErrAmountOverflow, s)
+		}
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if negative {
+		amount = -amount
+	}
+	return NewMoney(amount, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	if other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount ||
+		other.Amount < 0 && m.Amount < math.MinInt64-other.Amount {
+		return Money{}, fmt.Errorf("%w: %d + %d", ErrAmountOverflow, m.Amount, other.Amount)
+	}
+	return NewMoney(m.Amount+other.Amount, m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: %d - %d", ErrAmountOverflow, m.Amount, other.Amount)
+	}
+	return m.Add(NewMoney(-other.Amount, other.Currency))
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Decimal renders the amount in the major unit, e.g. "12.34".
+func (m Money) Decimal() string {
+	return FormatAmount(m.Amount, m.Currency)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount    json.RawMessage `json:"amount"`
+	Currency  string          `json:"currency"`
+	Formatted string          `json:"formatted,omitempty"`
+}
+
+// MarshalJSON encodes the amount in minor units, e.g.
+// {"amount":1234,"currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:   json.RawMessage(strconv.FormatInt(m.Amount, 10)),
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON accepts the amount either as an integer number of minor
+// units, or as a decimal string in the major unit such as "12.34".
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	money, err := ParseMoneyJSON(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// ParseMoneyJSON decodes a JSON amount of currency, which is either an integer
+// number of minor units or a decimal string in the major unit.
+func ParseMoneyJSON(amount json.RawMessage, currency string) (Money, error) {
+	amount = bytes.TrimSpace(amount)
+	if len(amount) == 0 || bytes.Equal(amount, []byte("null")) {
+		return Money{}, fmt.Errorf("%w: missing amount", ErrInvalidAmount)
+	}
+
+	if amount[0] == '"' {
+		var s string
+		if err := json.Unmarshal(amount, &s); err != nil {
+			return Money{}, err
+		}
+		return ParseMoney(s, currency)
+	}
+
+	minor, err := strconv.ParseInt(string(amount), 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Money{}, fmt.Errorf("%w: %s", ErrAmountOverflow, amount)
+		}
+		return Money{}, fmt.Errorf("%w: %s is not an integer number of minor units", ErrInvalidAmount, amount)
+	}
+	return NewMoney(minor, currency), nil
+}
+
+// Formatted returns m with a JSON encoding that also carries the amount in
+// the major unit, e.g. {"amount":1234,"currency":"USD","formatted":"12.34"}.
+func (m Money) Formatted() FormattedMoney {
+	return FormattedMoney(m)
+}
+
+// FormattedMoney is Money that always encodes its formatted amount. Whether
+// the formatted amount is sent is chosen by the type of a field, not per
+// request: API responses use FormattedMoney so that clients never have to
+// know the minor units of a currency, while Money leaves it out.
+type FormattedMoney Money
+
+func (m FormattedMoney) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:    json.RawMessage(strconv.FormatInt(m.Amount, 10)),
+		Currency:  m.Currency,
+		Formatted: Money(m).Decimal(),
+	})
+}
+
+func (m *FormattedMoney) UnmarshalJSON(data []byte) error {
+	return (*Money)(m).UnmarshalJSON(data)
+}
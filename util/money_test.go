@@ -0,0 +1,117 @@
+package util
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		input    string
+		currency string
+		amount   int64
+		err      error
+	}{
+		{input: "12.34", currency: USD, amount: 1234},
+		{input: "12.3", currency: USD, amount: 1230},
+		{input: "12", currency: USD, amount: 1200},
+		{input: ".5", currency: EUR, amount: 50},
+		{input: "-0.05", currency: EUR, amount: -5},
+		{input: "1234", currency: JPY, amount: 1234},
+		{input: "1.234", currency: KWD, amount: 1234},
+		{input: "12.345", currency: USD, err: ErrInvalidAmount},
+		{input: "1.5", currency: JPY, err: ErrInvalidAmount},
+		{input: "1,50", currency: USD, err: ErrInvalidAmount},
+		{input: "", currency: USD, err: ErrInvalidAmount},
+		{input: ".", currency: USD, err: ErrInvalidAmount},
+		{input: "1e3", currency: USD, err: ErrInvalidAmount},
+		{input: "+5", currency: USD, amount: 500},
+		{input: "-+5", currency: USD, err: ErrInvalidAmount},
+		{input: "+-5", currency: USD, err: ErrInvalidAmount},
+		{input: "--5", currency: USD, err: ErrInvalidAmount},
+		{input: "-", currency: USD, err: ErrInvalidAmount},
+		{input: "92233720368547758.08", currency: USD, err: ErrAmountOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			money, err := ParseMoney(tc.input, tc.currency)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, NewMoney(tc.amount, tc.currency), money)
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(1234, USD).Add(NewMoney(66, USD))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(1300, USD), sum)
+
+	diff, err := NewMoney(1234, USD).Sub(NewMoney(2000, USD))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(-766, USD), diff)
+
+	_, err = NewMoney(1, USD).Add(NewMoney(1, EUR))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = NewMoney(math.MaxInt64, USD).Add(NewMoney(1, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(math.MinInt64, USD).Sub(NewMoney(1, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(0, USD).Sub(NewMoney(math.MinInt64, USD))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	money := NewMoney(-1234, USD)
+
+	data, err := json.Marshal(money)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":-1234,"currency":"USD"}`, string(data))
+
+	data, err = json.Marshal(money.Formatted())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":-1234,"currency":"USD","formatted":"-12.34"}`, string(data))
+
+	var got Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":1234,"currency":"KWD"}`), &got))
+	require.Equal(t, NewMoney(1234, KWD), got)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"1.234","currency":"KWD"}`), &got))
+	require.Equal(t, NewMoney(1234, KWD), got)
+
+	var formatted FormattedMoney
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":50,"currency":"EUR","formatted":"0.50"}`), &formatted))
+	require.Equal(t, NewMoney(50, EUR), Money(formatted))
+
+	err = json.Unmarshal([]byte(`{"amount":12.5,"currency":"USD"}`), &got)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestParseMoneyJSON(t *testing.T) {
+	money, err := ParseMoneyJSON(json.RawMessage(`"0.10"`), USD)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(10, USD), money)
+
+	money, err = ParseMoneyJSON(json.RawMessage(`10`), USD)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(10, USD), money)
+
+	_, err = ParseMoneyJSON(nil, USD)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = ParseMoneyJSON(json.RawMessage(`null`), USD)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = ParseMoneyJSON(json.RawMessage(`99999999999999999999`), USD)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+}
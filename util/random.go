@@ -38,9 +38,9 @@ func RandomMoney() int64 {
 }
 
 func RandomCurrency() string {
-	currencies := []string{"EUR", "USD", "CAD"}
+	currencies := EnabledCurrencies()
 	n := len(currencies)
-	return currencies[rand.Intn(n)]
+	return currencies[rand.Intn(n)].Code
 }
 
 func RandomEmail() string {